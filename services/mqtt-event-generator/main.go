@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"math/rand"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,15 +12,22 @@ import (
 	"syscall"
 	"time"
 
+	"mqtt-event-generator/simulator"
+
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
 var (
-	// Límites de temperatura para la generación aleatoria
+	// Límites de temperatura para la generación de lecturas
 	MinTemperature = 07.0
 	MaxTemperature = 12.0
 )
 
+// sim es el simulador activo: arranca en SteadyState (el comportamiento
+// aleatorio original) y puede cambiar de perfil o recibir una anomalía
+// manual en caliente vía los handlers HTTP más abajo.
+var sim = simulator.NewSimulator(simulator.SteadyState{})
+
 type Event struct {
 	ID        string    `json:"id"`
 	Timestamp time.Time `json:"timestamp"`
@@ -30,11 +36,10 @@ type Event struct {
 	Data      EventData `json:"data"`
 }
 
-type EventData struct {
-	Temperature float64 `json:"temperature"`
-	Humidity    float64 `json:"humidity"`
-	Status      string  `json:"status"`
-}
+// EventData es un alias de simulator.EventData: la lectura de sensor ahora
+// la produce el Profile activo de sim, pero el resto de main.go (y el JSON
+// que publica) no cambia.
+type EventData = simulator.EventData
 
 type HealthResponse struct {
 	Status    string    `json:"status"`
@@ -54,11 +59,51 @@ type TemperatureLimitsResponse struct {
 	Timestamp      time.Time `json:"timestamp"`
 }
 
+// ProfileRequest selecciona el Profile activo de sim; Profile es el nombre
+// devuelto por Profile.Name() (ej. "linear_drift").
+type ProfileRequest struct {
+	Profile string `json:"profile"`
+}
+
+type ProfileResponse struct {
+	Message   string    `json:"message"`
+	Profile   string    `json:"profile"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AnomalyRequest dispara una anomalía manual de una sola vez sobre el
+// perfil activo, sin cambiarlo: AmplitudeCelsius se suma a la temperatura
+// de cada lectura durante DurationSeconds.
+type AnomalyRequest struct {
+	AmplitudeCelsius float64 `json:"amplitude_celsius"`
+	DurationSeconds  float64 `json:"duration_seconds"`
+}
+
+type AnomalyResponse struct {
+	Message          string    `json:"message"`
+	AmplitudeCelsius float64   `json:"amplitude_celsius"`
+	DurationSeconds  float64   `json:"duration_seconds"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// ControlMessage es el payload que el servicio de batch publica en
+// controlTopic ("events/control" por defecto) para cerrar el loop entre
+// OrderService.processDamage y los límites del sensor: cuando un batch se
+// marca como dañado, puede pedir que se ajusten (normalmente se ajusten,
+// estrechen) los límites de temperatura vigentes. Reutiliza la forma de
+// TemperatureLimitsRequest ya que es el mismo ajuste, solo que llega por
+// MQTT en vez de HTTP.
+type ControlMessage struct {
+	MinTemperature float64 `json:"min_temperature"`
+	MaxTemperature float64 `json:"max_temperature"`
+}
+
 func main() {
 	// Configuración MQTT
 	broker := getEnv("MQTT_BROKER", "tcp://localhost:1883")
 	clientID := getEnv("MQTT_CLIENT_ID", "event-generator")
 	topic := getEnv("MQTT_TOPIC", "events/sensor")
+	controlTopic := getEnv("MQTT_CONTROL_TOPIC", "events/control")
 	username := getEnv("MQTT_USERNAME", "")
 	password := getEnv("MQTT_PASSWORD", "")
 
@@ -101,8 +146,16 @@ func main() {
 		log.Fatalf("Error conectando a MQTT broker: %v", token.Error())
 	}
 
+	// Suscribirse al topic de control para que el servicio de batch pueda
+	// ajustar los límites de temperatura (ej. estrecharlos cuando marca un
+	// batch como dañado) sin reiniciar el generador.
+	if token := client.Subscribe(controlTopic, 0, controlMessageHandler); token.Wait() && token.Error() != nil {
+		log.Fatalf("Error suscribiéndose al topic de control %s: %v", controlTopic, token.Error())
+	}
+
 	log.Printf("Conectado al broker MQTT: %s", broker)
 	log.Printf("Publicando eventos en el topic: %s", topic)
+	log.Printf("Escuchando ajustes de límites en el topic: %s", controlTopic)
 	log.Printf("Frecuencia de eventos: cada %d milisegundos (%.2f segundos)", eventInterval, float64(eventInterval)/1000.0)
 	log.Printf("Rango de temperatura: %.2f°C - %.2f°C", MinTemperature, MaxTemperature)
 
@@ -172,25 +225,16 @@ func publishEvent(client mqtt.Client, topic string) {
 }
 
 func generateEvent() Event {
+	now := time.Now()
 	return Event{
-		ID:        fmt.Sprintf("evt_%d", time.Now().Unix()),
-		Timestamp: time.Now(),
+		ID:        fmt.Sprintf("evt_%d", now.Unix()),
+		Timestamp: now,
 		Type:      "sensor_reading",
 		Source:    "temperature_sensor_03",
-		Data: EventData{
-			Temperature: GetTemperatureRandom(MinTemperature, MaxTemperature),
-			Humidity:    50.0 + (float64(time.Now().Unix()%30) - 15), // Simula humedad entre 35-65%
-			Status:      "active",
-		},
+		Data:      sim.Next(now, simulator.Limits{Min: MinTemperature, Max: MaxTemperature}),
 	}
 }
 
-// GetTemperatureRandom genera una temperatura aleatoria entre los límites especificados
-func GetTemperatureRandom(lowerLimit, upperLimit float64) float64 {
-	// Generar temperatura aleatoria entre lowerLimit y upperLimit
-	return lowerLimit + rand.Float64()*(upperLimit-lowerLimit)
-}
-
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -231,11 +275,33 @@ var connectLostHandler mqtt.ConnectionLostHandler = func(client mqtt.Client, err
 	log.Printf("Conexión MQTT perdida: %v", err)
 }
 
+// controlMessageHandler aplica los ajustes de límites de temperatura que el
+// servicio de batch publica en el topic de control, cerrando el loop entre
+// OrderService.processDamage y los límites de este sensor.
+var controlMessageHandler mqtt.MessageHandler = func(client mqtt.Client, msg mqtt.Message) {
+	var ctrl ControlMessage
+	if err := json.Unmarshal(msg.Payload(), &ctrl); err != nil {
+		log.Printf("Mensaje de control inválido en topic %s: %v", msg.Topic(), err)
+		return
+	}
+
+	if ctrl.MinTemperature >= ctrl.MaxTemperature {
+		log.Printf("Mensaje de control descartado: min_temperature (%.2f) debe ser menor que max_temperature (%.2f)", ctrl.MinTemperature, ctrl.MaxTemperature)
+		return
+	}
+
+	MinTemperature = ctrl.MinTemperature
+	MaxTemperature = ctrl.MaxTemperature
+	log.Printf("Límites de temperatura ajustados desde topic de control %s: Min=%.2f, Max=%.2f", msg.Topic(), MinTemperature, MaxTemperature)
+}
+
 // Configuración de rutas HTTP
 func setupRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", healthHandler)
 	mux.HandleFunc("/temperature-limits", temperatureLimitsHandler)
+	mux.HandleFunc("/profile", profileHandler)
+	mux.HandleFunc("/inject-anomaly", injectAnomalyHandler)
 	return mux
 }
 
@@ -304,3 +370,81 @@ func temperatureLimitsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// Handler para el endpoint de cambio de perfil de simulación en caliente
+func profileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	profile, ok := simulator.NewProfile(req.Profile)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown profile %q", req.Profile), http.StatusBadRequest)
+		return
+	}
+
+	sim.SetProfile(profile)
+	log.Printf("Perfil de simulación cambiado a: %s", profile.Name())
+
+	response := ProfileResponse{
+		Message:   "Profile switched successfully",
+		Profile:   profile.Name(),
+		Timestamp: time.Now(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding profile response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Handler para disparar una anomalía manual de una sola vez sobre el
+// perfil activo (pico de AmplitudeCelsius grados durante DurationSeconds).
+func injectAnomalyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req AnomalyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	if req.DurationSeconds <= 0 {
+		http.Error(w, "DurationSeconds must be positive", http.StatusBadRequest)
+		return
+	}
+
+	duration := time.Duration(req.DurationSeconds * float64(time.Second))
+	sim.InjectAnomaly(time.Now(), req.AmplitudeCelsius, duration)
+	log.Printf("Anomalía inyectada: %.2f°C durante %.2fs", req.AmplitudeCelsius, req.DurationSeconds)
+
+	response := AnomalyResponse{
+		Message:          "Anomaly injected successfully",
+		AmplitudeCelsius: req.AmplitudeCelsius,
+		DurationSeconds:  req.DurationSeconds,
+		Timestamp:        time.Now(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding anomaly response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
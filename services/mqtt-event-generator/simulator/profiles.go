@@ -0,0 +1,128 @@
+package simulator
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// SteadyState reproduce el comportamiento original del generador: una
+// lectura aleatoria uniforme dentro de los límites en cada llamada.
+type SteadyState struct{}
+
+func (SteadyState) Name() string { return "steady_state" }
+
+func (SteadyState) Next(now time.Time, limits Limits) EventData {
+	temp := randomBetween(limits.Min, limits.Max)
+	return EventData{
+		Temperature: temp,
+		Humidity:    humidityFor(now),
+		Status:      statusFor(temp, limits),
+	}
+}
+
+// LinearDrift simula un sensor descalibrándose de forma gradual: la
+// temperatura se aleja del punto medio de los límites a una tasa constante
+// (RatePerMinute, en °C/min) y no vuelve a acercarse por sí sola, así se
+// puede ejercitar la detección de daño por temperatura sostenida fuera de
+// rango en vez de un pico puntual.
+type LinearDrift struct {
+	// RatePerMinute es cuánto se desplaza la temperatura por minuto
+	// transcurrido desde la primera lectura. Positivo deriva hacia
+	// limits.Max, negativo hacia limits.Min.
+	RatePerMinute float64
+
+	mu       sync.Mutex
+	start    time.Time
+	baseline float64
+}
+
+func (d *LinearDrift) Name() string { return "linear_drift" }
+
+func (d *LinearDrift) Next(now time.Time, limits Limits) EventData {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.start.IsZero() {
+		d.start = now
+		d.baseline = (limits.Min + limits.Max) / 2
+	}
+
+	elapsedMinutes := now.Sub(d.start).Minutes()
+	temp := d.baseline + d.RatePerMinute*elapsedMinutes
+
+	return EventData{
+		Temperature: temp,
+		Humidity:    humidityFor(now),
+		Status:      statusFor(temp, limits),
+	}
+}
+
+// SpikeInjector mantiene una lectura de estado estable la mayor parte del
+// tiempo, pero cada Interval introduce un pico de Amplitude grados que dura
+// SpikeDuration, simulando una interferencia transitoria del sensor en vez
+// de una falla sostenida.
+type SpikeInjector struct {
+	Interval      time.Duration
+	SpikeDuration time.Duration
+	Amplitude     float64
+
+	mu        sync.Mutex
+	base      SteadyState
+	lastSpike time.Time
+	nextSpike time.Time
+}
+
+func (s *SpikeInjector) Name() string { return "spike_injector" }
+
+func (s *SpikeInjector) Next(now time.Time, limits Limits) EventData {
+	s.mu.Lock()
+	if s.nextSpike.IsZero() {
+		s.nextSpike = now.Add(s.Interval)
+	}
+	if !now.Before(s.nextSpike) {
+		s.lastSpike = now
+		s.nextSpike = now.Add(s.Interval)
+	}
+	inSpike := now.Sub(s.lastSpike) < s.SpikeDuration
+	s.mu.Unlock()
+
+	data := s.base.Next(now, limits)
+	if inSpike {
+		data.Temperature += s.Amplitude
+		data.Status = statusFor(data.Temperature, limits)
+	}
+	return data
+}
+
+// CorrelatedFailure simula una falla de sensor en la que temperatura y
+// humedad se degradan juntas: a medida que pasa el tiempo desde la primera
+// lectura, la temperatura converge hacia por encima de limits.Max y la
+// humedad cae hacia 0%, como ocurriría si el sensor perdiera contacto
+// térmico con el producto.
+type CorrelatedFailure struct {
+	mu    sync.Mutex
+	start time.Time
+}
+
+func (f *CorrelatedFailure) Name() string { return "correlated_failure" }
+
+func (f *CorrelatedFailure) Next(now time.Time, limits Limits) EventData {
+	f.mu.Lock()
+	if f.start.IsZero() {
+		f.start = now
+	}
+	elapsedMinutes := now.Sub(f.start).Minutes()
+	f.mu.Unlock()
+
+	// Converge exponencialmente en el curso de ~10 minutos.
+	progress := 1 - math.Exp(-elapsedMinutes/10)
+	temp := limits.Max + progress*(limits.Max-limits.Min)*0.5
+	humidity := 50.0 * (1 - progress)
+
+	return EventData{
+		Temperature: temp,
+		Humidity:    humidity,
+		Status:      statusFor(temp, limits),
+	}
+}
@@ -0,0 +1,110 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+)
+
+var testLimits = Limits{Min: 10, Max: 30}
+
+func TestNewProfile_ReturnsKnownProfilesByName(t *testing.T) {
+	names := []string{"steady_state", "linear_drift", "spike_injector", "correlated_failure"}
+	for _, name := range names {
+		profile, ok := NewProfile(name)
+		if !ok {
+			t.Errorf("NewProfile(%q) reported unknown, expected a match", name)
+			continue
+		}
+		if profile.Name() != name {
+			t.Errorf("NewProfile(%q).Name() = %q", name, profile.Name())
+		}
+	}
+}
+
+func TestNewProfile_UnknownNameReturnsFalse(t *testing.T) {
+	if _, ok := NewProfile("does-not-exist"); ok {
+		t.Error("expected NewProfile to report false for an unknown profile name")
+	}
+}
+
+func TestLinearDrift_MovesAwayFromBaselineOverTime(t *testing.T) {
+	drift := &LinearDrift{RatePerMinute: 5}
+	start := time.Now()
+
+	first := drift.Next(start, testLimits)
+	baseline := (testLimits.Min + testLimits.Max) / 2
+	if first.Temperature != baseline {
+		t.Errorf("expected the first reading to equal the baseline %v, got %v", baseline, first.Temperature)
+	}
+
+	later := drift.Next(start.Add(5*time.Minute), testLimits)
+	if later.Temperature != baseline+25 {
+		t.Errorf("expected temperature to drift to %v after 5 minutes, got %v", baseline+25, later.Temperature)
+	}
+	if later.Status != "out_of_range" {
+		t.Errorf("expected status out_of_range once drifted past limits.Max, got %q", later.Status)
+	}
+}
+
+func TestSpikeInjector_AddsAmplitudeOnlyDuringSpikeWindow(t *testing.T) {
+	spike := &SpikeInjector{Interval: time.Minute, SpikeDuration: 10 * time.Second, Amplitude: 50}
+	start := time.Now()
+
+	before := spike.Next(start, testLimits)
+	if before.Temperature > testLimits.Max {
+		t.Errorf("expected no spike on the first reading, got temperature %v", before.Temperature)
+	}
+
+	duringSpike := spike.Next(start.Add(time.Minute), testLimits)
+	if duringSpike.Temperature < testLimits.Max {
+		t.Errorf("expected a spike once the interval elapses, got temperature %v", duringSpike.Temperature)
+	}
+	if duringSpike.Status != "out_of_range" {
+		t.Errorf("expected status out_of_range during a spike, got %q", duringSpike.Status)
+	}
+
+	afterSpike := spike.Next(start.Add(time.Minute+20*time.Second), testLimits)
+	if afterSpike.Temperature > testLimits.Max {
+		t.Errorf("expected the spike to have ended, got temperature %v", afterSpike.Temperature)
+	}
+}
+
+func TestCorrelatedFailure_ConvergesTemperatureUpAndHumidityDownOverTime(t *testing.T) {
+	failure := &CorrelatedFailure{}
+	start := time.Now()
+
+	first := failure.Next(start, testLimits)
+	if first.Temperature != testLimits.Max {
+		t.Errorf("expected the first reading to start at limits.Max, got %v", first.Temperature)
+	}
+	if first.Humidity != 50 {
+		t.Errorf("expected the first reading's humidity to start at 50, got %v", first.Humidity)
+	}
+
+	later := failure.Next(start.Add(30*time.Minute), testLimits)
+	if later.Temperature <= first.Temperature {
+		t.Errorf("expected temperature to keep rising over time, got %v then %v", first.Temperature, later.Temperature)
+	}
+	if later.Humidity >= first.Humidity {
+		t.Errorf("expected humidity to keep falling over time, got %v then %v", first.Humidity, later.Humidity)
+	}
+	if later.Status != "out_of_range" {
+		t.Errorf("expected status out_of_range once temperature exceeds limits.Max, got %q", later.Status)
+	}
+}
+
+func TestStatusFor_ReportsOutOfRangeOutsideLimits(t *testing.T) {
+	cases := []struct {
+		temperature float64
+		want        string
+	}{
+		{temperature: 20, want: "active"},
+		{temperature: 9, want: "out_of_range"},
+		{temperature: 31, want: "out_of_range"},
+	}
+	for _, c := range cases {
+		if got := statusFor(c.temperature, testLimits); got != c.want {
+			t.Errorf("statusFor(%v, %v) = %q, want %q", c.temperature, testLimits, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Package simulator genera lecturas de sensor simuladas para el
+// mqtt-event-generator. En vez de un único número aleatorio uniforme,
+// soporta varios perfiles de comportamiento (estado estable, deriva lineal,
+// picos periódicos, falla correlacionada) para poder ejercitar las rutas de
+// detección de daño del pipeline de batch sin depender solo de la suerte.
+package simulator
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// EventData es la lectura de sensor que produce un Profile.
+type EventData struct {
+	Temperature float64 `json:"temperature"`
+	Humidity    float64 `json:"humidity"`
+	Status      string  `json:"status"`
+}
+
+// Limits son los límites de temperatura vigentes al momento de generar una
+// lectura. Un Profile decide cómo comportarse respecto a ellos (mantenerse
+// dentro, derivar fuera de rango, etc.).
+type Limits struct {
+	Min float64
+	Max float64
+}
+
+// Profile genera la siguiente lectura de sensor para un instante dado. Las
+// implementaciones con estado (deriva, fallas) lo guardan entre llamadas, así
+// que deben protegerlo si se invocan concurrentemente.
+type Profile interface {
+	// Name identifica el perfil; es el valor que POST /profile espera recibir
+	// para seleccionarlo.
+	Name() string
+	Next(now time.Time, limits Limits) EventData
+}
+
+// NewProfile crea una instancia nueva del perfil identificado por name,
+// empezando sin ningún estado de deriva/falla previo. El segundo valor de
+// retorno es false si name no coincide con ningún perfil conocido.
+func NewProfile(name string) (Profile, bool) {
+	switch name {
+	case (SteadyState{}).Name():
+		return SteadyState{}, true
+	case (&LinearDrift{}).Name():
+		return &LinearDrift{RatePerMinute: 0.1}, true
+	case (&SpikeInjector{}).Name():
+		return &SpikeInjector{Interval: 2 * time.Minute, SpikeDuration: 10 * time.Second, Amplitude: 5}, true
+	case (&CorrelatedFailure{}).Name():
+		return &CorrelatedFailure{}, true
+	default:
+		return nil, false
+	}
+}
+
+// Simulator mantiene el perfil activo y una eventual anomalía manual
+// inyectada vía InjectAnomaly, protegidos por mutex porque se leen/escriben
+// tanto desde el ticker de publicación como desde los handlers HTTP.
+type Simulator struct {
+	mu      sync.Mutex
+	profile Profile
+
+	anomalyUntil     time.Time
+	anomalyAmplitude float64
+}
+
+// NewSimulator arranca un Simulator con initial como perfil activo.
+func NewSimulator(initial Profile) *Simulator {
+	return &Simulator{profile: initial}
+}
+
+// SetProfile reemplaza el perfil activo, descartando el estado del anterior.
+func (s *Simulator) SetProfile(p Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profile = p
+}
+
+// ProfileName devuelve el nombre del perfil activo.
+func (s *Simulator) ProfileName() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.profile.Name()
+}
+
+// InjectAnomaly suma amplitude grados a la temperatura de cada lectura
+// generada durante duration a partir de now, sin cambiar el perfil activo ni
+// su estado interno. Una nueva llamada reemplaza cualquier anomalía todavía
+// en curso.
+func (s *Simulator) InjectAnomaly(now time.Time, amplitude float64, duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.anomalyAmplitude = amplitude
+	s.anomalyUntil = now.Add(duration)
+}
+
+// Next delega en el perfil activo y, si hay una anomalía manual en curso, le
+// suma su amplitud a la temperatura resultante.
+func (s *Simulator) Next(now time.Time, limits Limits) EventData {
+	s.mu.Lock()
+	profile := s.profile
+	amplitude := 0.0
+	if now.Before(s.anomalyUntil) {
+		amplitude = s.anomalyAmplitude
+	}
+	s.mu.Unlock()
+
+	data := profile.Next(now, limits)
+	if amplitude != 0 {
+		data.Temperature += amplitude
+		data.Status = statusFor(data.Temperature, limits)
+	}
+	return data
+}
+
+func randomBetween(min, max float64) float64 {
+	return min + rand.Float64()*(max-min)
+}
+
+// humidityFor reproduce la simulación de humedad original del generador:
+// entre 35% y 65%, derivada del segundo actual para que varíe lectura a
+// lectura sin requerir estado propio.
+func humidityFor(now time.Time) float64 {
+	return 50.0 + (float64(now.Unix()%30) - 15)
+}
+
+func statusFor(temperature float64, limits Limits) string {
+	if temperature < limits.Min || temperature > limits.Max {
+		return "out_of_range"
+	}
+	return "active"
+}
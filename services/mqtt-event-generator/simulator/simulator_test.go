@@ -0,0 +1,62 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+)
+
+type fixedProfile struct {
+	name string
+	data EventData
+}
+
+func (p fixedProfile) Name() string                     { return p.name }
+func (p fixedProfile) Next(time.Time, Limits) EventData { return p.data }
+
+func TestSimulator_SetProfile_SwitchesActiveProfile(t *testing.T) {
+	sim := NewSimulator(fixedProfile{name: "a", data: EventData{Temperature: 1}})
+	if got := sim.ProfileName(); got != "a" {
+		t.Fatalf("ProfileName() = %q, want %q", got, "a")
+	}
+
+	sim.SetProfile(fixedProfile{name: "b", data: EventData{Temperature: 2}})
+	if got := sim.ProfileName(); got != "b" {
+		t.Fatalf("ProfileName() = %q, want %q", got, "b")
+	}
+	if got := sim.Next(time.Now(), testLimits).Temperature; got != 2 {
+		t.Errorf("expected Next() to delegate to the new profile, got temperature %v", got)
+	}
+}
+
+func TestSimulator_InjectAnomaly_AppliesAmplitudeOnlyDuringWindow(t *testing.T) {
+	sim := NewSimulator(fixedProfile{name: "steady", data: EventData{Temperature: 20, Status: "active"}})
+	now := time.Now()
+
+	sim.InjectAnomaly(now, 100, 10*time.Second)
+
+	during := sim.Next(now.Add(5*time.Second), testLimits)
+	if during.Temperature != 120 {
+		t.Errorf("expected the anomaly amplitude to be added during the window, got %v", during.Temperature)
+	}
+	if during.Status != "out_of_range" {
+		t.Errorf("expected status out_of_range while the injected anomaly is active, got %q", during.Status)
+	}
+
+	after := sim.Next(now.Add(11*time.Second), testLimits)
+	if after.Temperature != 20 {
+		t.Errorf("expected the anomaly to have expired, got temperature %v", after.Temperature)
+	}
+}
+
+func TestSimulator_InjectAnomaly_NewCallReplacesPreviousWindow(t *testing.T) {
+	sim := NewSimulator(fixedProfile{name: "steady", data: EventData{Temperature: 20}})
+	now := time.Now()
+
+	sim.InjectAnomaly(now, 100, time.Minute)
+	sim.InjectAnomaly(now, 5, time.Second)
+
+	soonAfterSecondCall := sim.Next(now.Add(2*time.Second), testLimits)
+	if soonAfterSecondCall.Temperature != 20 {
+		t.Errorf("expected the second InjectAnomaly call to replace the first's longer window, got %v", soonAfterSecondCall.Temperature)
+	}
+}
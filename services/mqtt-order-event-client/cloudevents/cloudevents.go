@@ -0,0 +1,152 @@
+// Package cloudevents implements a minimal CloudEvents 1.0 envelope for
+// events this service publishes to Kafka, in both binary mode (the context
+// attributes as "ce_*" message headers, alongside a plain-JSON value) and
+// structured mode (a single JSON envelope carrying the attributes and the
+// payload together), so subscribers can decode either representation
+// uniformly regardless of which broker/bridge they're reading from.
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// SpecVersion is the CloudEvents spec version this package implements.
+const SpecVersion = "1.0"
+
+// Type maps a module-internal event type like "order.damage" to the
+// CloudEvents "type" attribute convention used across medisupply services:
+// "com.medisupply.order.damage.v1".
+func Type(domainType string) string {
+	return fmt.Sprintf("com.medisupply.%s.v1", domainType)
+}
+
+// Attributes holds the CloudEvents 1.0 context attributes carried as Kafka
+// binary-mode headers alongside the event payload.
+type Attributes struct {
+	ID              string
+	Source          string
+	SpecVersion     string
+	Type            string
+	Subject         string
+	DataSchema      string
+	Time            time.Time
+	DataContentType string
+	// TraceParent is the W3C traceparent of the span that produced this
+	// event, so a subscriber can continue the same trace. May be empty.
+	TraceParent string
+}
+
+// NewAttributes builds the CloudEvents attributes for an event about to be
+// published. ID is freshly generated per emission, as the spec requires.
+// traceParent is the W3C traceparent of the span that produced this event
+// (see the tracing package), and may be empty.
+func NewAttributes(source, ceType, subject, traceParent string) Attributes {
+	return Attributes{
+		ID:              uuid.New().String(),
+		Source:          source,
+		SpecVersion:     SpecVersion,
+		Type:            ceType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		TraceParent:     traceParent,
+	}
+}
+
+const (
+	headerID              = "ce_id"
+	headerSource          = "ce_source"
+	headerSpecVersion     = "ce_specversion"
+	headerType            = "ce_type"
+	headerSubject         = "ce_subject"
+	headerDataSchema      = "ce_dataschema"
+	headerTime            = "ce_time"
+	headerDataContentType = "content-type"
+	headerTraceParent     = "traceparent"
+)
+
+// Headers renders attrs as Kafka binary-mode CloudEvents headers.
+func (a Attributes) Headers() []kafka.Header {
+	headers := []kafka.Header{
+		{Key: headerID, Value: []byte(a.ID)},
+		{Key: headerSource, Value: []byte(a.Source)},
+		{Key: headerSpecVersion, Value: []byte(a.SpecVersion)},
+		{Key: headerType, Value: []byte(a.Type)},
+		{Key: headerSubject, Value: []byte(a.Subject)},
+		{Key: headerTime, Value: []byte(a.Time.Format(time.RFC3339Nano))},
+		{Key: headerDataContentType, Value: []byte(a.DataContentType)},
+	}
+	if a.DataSchema != "" {
+		headers = append(headers, kafka.Header{Key: headerDataSchema, Value: []byte(a.DataSchema)})
+	}
+	if a.TraceParent != "" {
+		headers = append(headers, kafka.Header{Key: headerTraceParent, Value: []byte(a.TraceParent)})
+	}
+	return headers
+}
+
+// Envelope is a CloudEvents 1.0 structured-mode envelope: the context
+// attributes and the event payload travel together in one JSON document.
+type Envelope struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+	// TraceParent carries the W3C traceparent of the span that produced
+	// this event, for brokers/bridges (like MQTT 3.1.1 here) with no
+	// transport-level property to carry it in instead. Omitted when empty.
+	TraceParent string `json:"traceparent,omitempty"`
+}
+
+// Wrap builds a structured-mode envelope carrying attrs and data.
+func Wrap(attrs Attributes, data any) (*Envelope, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("cloudevents: failed to marshal event data: %w", err)
+	}
+
+	return &Envelope{
+		SpecVersion:     attrs.SpecVersion,
+		ID:              attrs.ID,
+		Source:          attrs.Source,
+		Type:            attrs.Type,
+		Subject:         attrs.Subject,
+		DataSchema:      attrs.DataSchema,
+		Time:            attrs.Time,
+		DataContentType: attrs.DataContentType,
+		Data:            payload,
+		TraceParent:     attrs.TraceParent,
+	}, nil
+}
+
+// Unwrap decodes envelope.Data into out.
+func (e *Envelope) Unwrap(out any) error {
+	return json.Unmarshal(e.Data, out)
+}
+
+// Attributes returns the CloudEvents context attributes carried by the
+// envelope, e.g. to compare a structured-mode payload against the
+// binary-mode headers derived from the same Attributes value.
+func (e *Envelope) Attributes() Attributes {
+	return Attributes{
+		ID:              e.ID,
+		Source:          e.Source,
+		SpecVersion:     e.SpecVersion,
+		Type:            e.Type,
+		Subject:         e.Subject,
+		DataSchema:      e.DataSchema,
+		Time:            e.Time,
+		DataContentType: e.DataContentType,
+		TraceParent:     e.TraceParent,
+	}
+}
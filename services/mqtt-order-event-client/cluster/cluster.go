@@ -0,0 +1,345 @@
+// Package cluster replaces the single-pod, in-memory EventStore with a
+// replicated, pluggable-backend store (see eventstore), so a pod restart
+// doesn't lose recent history and reads scale horizontally across
+// replicas. It follows the comqtt agent's clustering pattern: memberlist
+// (gossip) finds the other nodes, and Hashicorp Raft elects a leader and
+// replicates writes as log entries applied to a local fsm; GetEvents/
+// GetLatestEvent/GetEventCount/GetEventsByTimeRange/GetEventsBySource are
+// served from that local fsm directly, never through Raft, so a read
+// never waits on the leader or the network.
+//
+// Only the leader can commit a write (AddEvent/DeleteEvent/CompactBefore):
+// Raft's Apply returns raft.ErrNotLeader on every other node. This package
+// does not forward a follower's write to the leader over the network -
+// ensuring only one replica ever ingests a given MQTT message (see the
+// package's intended use with a shared subscription, e.g.
+// "$share/order-clients/...") bounds how often that matters, but a message
+// delivered to a follower today is logged and dropped rather than
+// retried. Building leader-forwarding RPC is a reasonable next step but
+// out of scope here.
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb"
+
+	"mqtt-order-event-client/eventstore"
+)
+
+// compactionInterval is how often a leader checks whether EVENT_RETENTION
+// has any events to compact. Not configurable: operators tune what gets
+// compacted via EVENT_RETENTION, not how often this package checks.
+const compactionInterval = 5 * time.Minute
+
+// Config configures a Cluster.
+type Config struct {
+	// NodeID uniquely identifies this node to both memberlist and Raft.
+	// Defaults to BindAddr when empty.
+	NodeID string
+	// BindAddr is this node's gossip (memberlist) and Raft transport
+	// address, e.g. "10.0.0.12:7946". Read from CLUSTER_BIND_ADDR.
+	BindAddr string
+	// Peers lists other nodes' gossip addresses to join at startup,
+	// read from CLUSTER_PEERS (comma-separated). Empty means this node
+	// bootstraps a brand-new single-node cluster.
+	Peers []string
+	// RaftDir is where Raft persists its log, stable store, and
+	// snapshots, read from RAFT_DIR.
+	RaftDir string
+	// Retention is how long a compacting leader keeps an event before
+	// removing it, read from EVENT_RETENTION. Zero disables compaction.
+	Retention time.Duration
+}
+
+// ConfigFromEnv reads Config from CLUSTER_BIND_ADDR, CLUSTER_PEERS (comma
+// separated), RAFT_DIR, and EVENT_RETENTION. CLUSTER_BIND_ADDR defaults to
+// "127.0.0.1:7946", so an install that sets none of these still gets a
+// working single-node cluster functionally equivalent to the old in-memory
+// EventStore.
+func ConfigFromEnv() Config {
+	bindAddr := getEnv("CLUSTER_BIND_ADDR", "127.0.0.1:7946")
+
+	var retention time.Duration
+	if raw := os.Getenv("EVENT_RETENTION"); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil {
+			retention = parsed
+		}
+	}
+
+	return Config{
+		NodeID:    bindAddr,
+		BindAddr:  bindAddr,
+		Peers:     getEnvList("CLUSTER_PEERS"),
+		RaftDir:   getEnv("RAFT_DIR", "/data/raft"),
+		Retention: retention,
+	}
+}
+
+// Cluster is a clustered, Raft-replicated EventStore.
+type Cluster struct {
+	raft       *raft.Raft
+	fsm        *fsm
+	memberlist *memberlist.Memberlist
+
+	compactionDone chan struct{}
+}
+
+// New starts gossip membership and a Raft node per cfg, joining cfg.Peers
+// if any are given, and returns the resulting Cluster. A node started with
+// no peers bootstraps a new single-node cluster and is immediately its own
+// leader. Every replica replicates writes into store (see the eventstore
+// package for the available backends).
+func New(cfg Config, store eventstore.EventStore) (*Cluster, error) {
+	if cfg.NodeID == "" {
+		cfg.NodeID = cfg.BindAddr
+	}
+
+	host, portStr, err := net.SplitHostPort(cfg.BindAddr)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: invalid CLUSTER_BIND_ADDR %q: %w", cfg.BindAddr, err)
+	}
+
+	ml, err := startMemberlist(cfg, host, portStr)
+	if err != nil {
+		return nil, err
+	}
+
+	r, fsmStore, err := startRaft(cfg, store)
+	if err != nil {
+		ml.Shutdown()
+		return nil, err
+	}
+
+	c := &Cluster{raft: r, fsm: fsmStore, memberlist: ml, compactionDone: make(chan struct{})}
+	if cfg.Retention > 0 {
+		go c.runCompaction(cfg.Retention)
+	}
+	return c, nil
+}
+
+func startMemberlist(cfg Config, host, portStr string) (*memberlist.Memberlist, error) {
+	port, err := net.LookupPort("tcp", portStr)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: invalid CLUSTER_BIND_ADDR port %q: %w", portStr, err)
+	}
+
+	mlConfig := memberlist.DefaultLANConfig()
+	mlConfig.Name = cfg.NodeID
+	mlConfig.BindAddr = host
+	mlConfig.BindPort = port
+	mlConfig.AdvertisePort = port
+
+	ml, err := memberlist.Create(mlConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: failed to start memberlist: %w", err)
+	}
+
+	if len(cfg.Peers) > 0 {
+		if _, err := ml.Join(cfg.Peers); err != nil {
+			// A peer being unreachable at startup shouldn't be fatal - the
+			// gossip protocol keeps retrying discovery in the background.
+			fmt.Fprintf(os.Stderr, "cluster: failed to join some peers at startup, will keep retrying via gossip: %v\n", err)
+		}
+	}
+
+	return ml, nil
+}
+
+func startRaft(cfg Config, store eventstore.EventStore) (*raft.Raft, *fsm, error) {
+	if err := os.MkdirAll(cfg.RaftDir, 0o755); err != nil {
+		return nil, nil, fmt.Errorf("cluster: failed to create RAFT_DIR %q: %w", cfg.RaftDir, err)
+	}
+
+	raftConfig := raft.DefaultConfig()
+	raftConfig.LocalID = raft.ServerID(cfg.NodeID)
+
+	addr, err := net.ResolveTCPAddr("tcp", cfg.BindAddr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cluster: invalid CLUSTER_BIND_ADDR %q: %w", cfg.BindAddr, err)
+	}
+	transport, err := raft.NewTCPTransport(cfg.BindAddr, addr, 3, 10*time.Second, os.Stderr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cluster: failed to start Raft transport: %w", err)
+	}
+
+	snapshots, err := raft.NewFileSnapshotStore(cfg.RaftDir, 2, os.Stderr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cluster: failed to create Raft snapshot store: %w", err)
+	}
+
+	boltStore, err := raftboltdb.NewBoltStore(filepath.Join(cfg.RaftDir, "raft.db"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cluster: failed to create Raft log/stable store: %w", err)
+	}
+
+	fsmStore := newFSM(store)
+
+	r, err := raft.NewRaft(raftConfig, fsmStore, boltStore, boltStore, snapshots, transport)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cluster: failed to start Raft: %w", err)
+	}
+
+	if len(cfg.Peers) == 0 {
+		bootstrapFuture := r.BootstrapCluster(raft.Configuration{
+			Servers: []raft.Server{{ID: raftConfig.LocalID, Address: transport.LocalAddr()}},
+		})
+		if err := bootstrapFuture.Error(); err != nil && err != raft.ErrCantBootstrap {
+			return nil, nil, fmt.Errorf("cluster: failed to bootstrap single-node Raft cluster: %w", err)
+		}
+	}
+
+	return r, fsmStore, nil
+}
+
+// applyCommand replicates cmd as a Raft log entry. Only the current leader
+// can succeed; see the package doc comment for what happens on a follower.
+func (c *Cluster) applyCommand(cmd command) error {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("cluster: failed to marshal command: %w", err)
+	}
+
+	future := c.raft.Apply(data, 5*time.Second)
+	if err := future.Error(); err != nil {
+		return fmt.Errorf("cluster: failed to replicate command: %w", err)
+	}
+	if applyErr, ok := future.Response().(error); ok && applyErr != nil {
+		return fmt.Errorf("cluster: fsm rejected command: %w", applyErr)
+	}
+	return nil
+}
+
+// AddEvent replicates event as a Raft log entry.
+func (c *Cluster) AddEvent(event Event) error {
+	return c.applyCommand(command{Type: commandAdd, Event: &event})
+}
+
+// DeleteEvent replicates the removal of the event with the given id.
+func (c *Cluster) DeleteEvent(id string) error {
+	return c.applyCommand(command{Type: commandDelete, ID: id})
+}
+
+// CompactBefore replicates the removal of every event with a Timestamp
+// before cutoff.
+func (c *Cluster) CompactBefore(cutoff time.Time) error {
+	return c.applyCommand(command{Type: commandCompact, Cutoff: cutoff})
+}
+
+// runCompaction periodically applies a CompactBefore command for events
+// older than retention. Only the current leader's tick actually replicates
+// (see applyCommand); a follower's tick is skipped via IsLeader before any
+// Apply is attempted, so followers neither fail with ErrNotLeader nor log
+// the same non-error on every tick forever.
+func (c *Cluster) runCompaction(retention time.Duration) {
+	ticker := time.NewTicker(compactionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !c.IsLeader() {
+				continue
+			}
+			if err := c.CompactBefore(time.Now().Add(-retention)); err != nil {
+				fmt.Fprintf(os.Stderr, "cluster: compaction failed: %v\n", err)
+			}
+		case <-c.compactionDone:
+			return
+		}
+	}
+}
+
+// GetEvents returns every event in the local store, read directly with no
+// Raft round trip.
+func (c *Cluster) GetEvents() []Event {
+	return c.fsm.getEvents()
+}
+
+// GetLatestEvent returns the most recently replicated event, or nil if
+// none have arrived yet.
+func (c *Cluster) GetLatestEvent() *Event {
+	return c.fsm.getLatestEvent()
+}
+
+// GetEventCount returns how many events the local store holds.
+func (c *Cluster) GetEventCount() int {
+	return c.fsm.getEventCount()
+}
+
+// GetEventsByTimeRange returns events with from <= Timestamp <= to, read
+// directly from the local store.
+func (c *Cluster) GetEventsByTimeRange(from, to time.Time) ([]Event, error) {
+	return c.fsm.getEventsByTimeRange(from, to)
+}
+
+// GetEventsBySource returns up to limit events from source, most recent
+// first, read directly from the local store.
+func (c *Cluster) GetEventsBySource(source string, limit int) ([]Event, error) {
+	return c.fsm.getEventsBySource(source, limit)
+}
+
+// Members returns the gossip-visible cluster membership, by node name.
+func (c *Cluster) Members() []string {
+	nodes := c.memberlist.Members()
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
+
+// Leader returns the current Raft leader's address, or "" if none is
+// elected right now.
+func (c *Cluster) Leader() string {
+	return string(c.raft.Leader())
+}
+
+// IsLeader reports whether this node is the current Raft leader.
+func (c *Cluster) IsLeader() bool {
+	return c.raft.State() == raft.Leader
+}
+
+// Close leaves the gossip pool, shuts down this node's Raft instance, and
+// stops its background compaction goroutine if one was started.
+func (c *Cluster) Close() error {
+	close(c.compactionDone)
+	if err := c.memberlist.Leave(5 * time.Second); err != nil {
+		return fmt.Errorf("cluster: failed to leave memberlist: %w", err)
+	}
+	if err := c.raft.Shutdown().Error(); err != nil {
+		return fmt.Errorf("cluster: failed to shut down Raft: %w", err)
+	}
+	return nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvList splits a comma-separated env var into a trimmed, non-empty
+// slice, or nil if it's unset.
+func getEnvList(key string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,11 @@
+package cluster
+
+import "mqtt-order-event-client/eventstore"
+
+// Event is an alias for eventstore.Event, so the rest of this package (and
+// main.go, via its own alias) shares one definition with every
+// eventstore.EventStore backend.
+type Event = eventstore.Event
+
+// EventData is an alias for eventstore.EventData.
+type EventData = eventstore.EventData
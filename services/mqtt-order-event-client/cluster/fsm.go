@@ -0,0 +1,133 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"mqtt-order-event-client/eventstore"
+)
+
+// commandType distinguishes the mutating operations fsm.Apply replicates.
+// AddEvent, DeleteEvent, and CompactBefore all go through Raft rather than
+// touching the local store directly, so every backend (memory, BoltDB,
+// Redis - see the eventstore package) stays consistent across replicas.
+type commandType string
+
+const (
+	commandAdd     commandType = "add"
+	commandDelete  commandType = "delete"
+	commandCompact commandType = "compact"
+)
+
+// command is the Raft log entry payload. Exactly one of Event/ID/Cutoff is
+// populated, depending on Type.
+type command struct {
+	Type   commandType `json:"type"`
+	Event  *Event      `json:"event,omitempty"`
+	ID     string      `json:"id,omitempty"`
+	Cutoff time.Time   `json:"cutoff,omitempty"`
+}
+
+// fsm is the Raft finite state machine replicating writes into an
+// eventstore.EventStore. fsm.Apply is the only thing that ever mutates the
+// store, so every replica converges on the same contents regardless of
+// which node ingested the original MQTT message.
+type fsm struct {
+	store eventstore.EventStore
+}
+
+func newFSM(store eventstore.EventStore) *fsm {
+	return &fsm{store: store}
+}
+
+// Apply decodes a replicated command and applies it to the local store. It
+// never returns an error for a malformed entry - that would stall the Raft
+// log for every replica over one bad message - it just surfaces the
+// decode/apply failure back to the caller via the returned value, matching
+// raft.FSM's "return value surfaces to the ApplyFuture" contract.
+func (f *fsm) Apply(entry *raft.Log) interface{} {
+	var cmd command
+	if err := json.Unmarshal(entry.Data, &cmd); err != nil {
+		return fmt.Errorf("cluster: failed to decode replicated command: %w", err)
+	}
+
+	switch cmd.Type {
+	case commandAdd:
+		if cmd.Event == nil {
+			return fmt.Errorf("cluster: add command missing event")
+		}
+		return f.store.Add(*cmd.Event)
+	case commandDelete:
+		return f.store.Delete(cmd.ID)
+	case commandCompact:
+		return f.store.CompactBefore(cmd.Cutoff)
+	default:
+		return fmt.Errorf("cluster: unknown command type %q", cmd.Type)
+	}
+}
+
+// Snapshot captures the current store contents so Raft can compact its log
+// and a newly-joined node can catch up without replaying full history. For
+// the boltdb/redis backends, which are already durable on their own, this
+// mainly exists to seed a brand-new node's local store rather than to
+// survive this node's own restart.
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	return &fsmSnapshot{events: f.store.GetEvents()}, nil
+}
+
+// Restore replaces the store's contents from a snapshot by replaying every
+// event through Add. It doesn't first clear whatever the local store
+// already has - a non-issue for the common case of a fresh node joining an
+// existing cluster, but a node restoring into a store that already holds
+// unrelated data could end up with duplicates. Accepted as a known gap
+// rather than plumbing a Clear() method through every backend for it.
+func (f *fsm) Restore(snapshot io.ReadCloser) error {
+	defer snapshot.Close()
+
+	var events []Event
+	if err := json.NewDecoder(snapshot).Decode(&events); err != nil {
+		return fmt.Errorf("cluster: failed to decode snapshot: %w", err)
+	}
+
+	for _, event := range events {
+		if err := f.store.Add(event); err != nil {
+			return fmt.Errorf("cluster: failed to restore event: %w", err)
+		}
+	}
+	return nil
+}
+
+func (f *fsm) getEvents() []Event     { return f.store.GetEvents() }
+func (f *fsm) getLatestEvent() *Event { return f.store.GetLatestEvent() }
+func (f *fsm) getEventCount() int     { return f.store.GetEventCount() }
+
+func (f *fsm) getEventsByTimeRange(from, to time.Time) ([]Event, error) {
+	return f.store.GetEventsByTimeRange(from, to)
+}
+
+func (f *fsm) getEventsBySource(source string, limit int) ([]Event, error) {
+	return f.store.GetEventsBySource(source, limit)
+}
+
+// fsmSnapshot is the raft.FSMSnapshot persisted by fsm.Snapshot.
+type fsmSnapshot struct {
+	events []Event
+}
+
+func (s *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
+	err := func() error {
+		encoder := json.NewEncoder(sink)
+		return encoder.Encode(s.events)
+	}()
+	if err != nil {
+		sink.Cancel()
+		return fmt.Errorf("cluster: failed to persist snapshot: %w", err)
+	}
+	return sink.Close()
+}
+
+func (s *fsmSnapshot) Release() {}
@@ -0,0 +1,209 @@
+package eventstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	// eventsBucket keys are the UTC timestamp in keyTimeFormat + "_" + id, so
+	// BoltDB's natural lexicographic key order is also timestamp order: a
+	// range scan over this bucket is GetEventsByTimeRange.
+	eventsBucket = []byte("events")
+	// idIndexBucket maps an event's id to its eventsBucket key, so Delete
+	// doesn't need a full scan to find what to remove.
+	idIndexBucket = []byte("ids")
+)
+
+// BoltStore persists events to a local BoltDB file. Unlike MemoryStore, it
+// retains everything it's given until EVENT_RETENTION-based compaction (see
+// the cluster package) removes it - there's no maxSize ring-buffer trim
+// here. GetEventsBySource has no secondary index, so it still scans every
+// key; fine at this service's volumes, but worth knowing before pointing it
+// at a very long retention.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltStore opens (creating if needed) a BoltDB file at path with the
+// buckets BoltStore needs.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("eventstore: failed to open BoltDB file %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(eventsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(idIndexBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("eventstore: failed to initialize BoltDB buckets: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// keyTimeFormat is RFC3339 with a fixed nine-digit fraction. Unlike
+// time.RFC3339Nano, which drops trailing zeros, every key has the same
+// width, so bytewise key order matches time order.
+const keyTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+func eventKey(event Event) []byte {
+	return []byte(event.Timestamp.UTC().Format(keyTimeFormat) + "_" + event.ID)
+}
+
+func (s *BoltStore) Add(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("eventstore: failed to marshal event: %w", err)
+	}
+	key := eventKey(event)
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(eventsBucket).Put(key, payload); err != nil {
+			return err
+		}
+		return tx.Bucket(idIndexBucket).Put([]byte(event.ID), key)
+	})
+}
+
+func (s *BoltStore) GetEvents() []Event {
+	var events []Event
+	_ = s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(eventsBucket).ForEach(func(_, v []byte) error {
+			var e Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				return nil // skip a corrupt record rather than failing the whole scan
+			}
+			events = append(events, e)
+			return nil
+		})
+	})
+	return events
+}
+
+func (s *BoltStore) GetLatestEvent() *Event {
+	var latest *Event
+	_ = s.db.View(func(tx *bbolt.Tx) error {
+		k, v := tx.Bucket(eventsBucket).Cursor().Last()
+		if k == nil {
+			return nil
+		}
+		var e Event
+		if err := json.Unmarshal(v, &e); err != nil {
+			return nil
+		}
+		latest = &e
+		return nil
+	})
+	return latest
+}
+
+func (s *BoltStore) GetEventCount() int {
+	count := 0
+	_ = s.db.View(func(tx *bbolt.Tx) error {
+		count = tx.Bucket(eventsBucket).Stats().KeyN
+		return nil
+	})
+	return count
+}
+
+func (s *BoltStore) GetEventsByTimeRange(from, to time.Time) ([]Event, error) {
+	min := []byte(from.UTC().Format(keyTimeFormat))
+	max := []byte(to.UTC().Format(keyTimeFormat) + "\xff") // past any "_id" suffix for the same instant
+
+	var events []Event
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(eventsBucket).Cursor()
+		for k, v := c.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, v = c.Next() {
+			var e Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				continue
+			}
+			events = append(events, e)
+		}
+		return nil
+	})
+	return events, err
+}
+
+func (s *BoltStore) GetEventsBySource(source string, limit int) ([]Event, error) {
+	var events []Event
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(eventsBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				continue
+			}
+			if e.Source != source {
+				continue
+			}
+			events = append(events, e)
+			if limit > 0 && len(events) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	return events, err
+}
+
+func (s *BoltStore) Delete(id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		idBucket := tx.Bucket(idIndexBucket)
+		key := idBucket.Get([]byte(id))
+		if key == nil {
+			return nil
+		}
+		if err := tx.Bucket(eventsBucket).Delete(key); err != nil {
+			return err
+		}
+		return idBucket.Delete([]byte(id))
+	})
+}
+
+func (s *BoltStore) CompactBefore(cutoff time.Time) error {
+	max := []byte(cutoff.UTC().Format(keyTimeFormat))
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		eventsB := tx.Bucket(eventsBucket)
+		idB := tx.Bucket(idIndexBucket)
+
+		var staleKeys [][]byte
+		var staleIDs []string
+		c := eventsB.Cursor()
+		for k, v := c.First(); k != nil && bytes.Compare(k, max) < 0; k, v = c.Next() {
+			staleKeys = append(staleKeys, append([]byte(nil), k...))
+			var e Event
+			if err := json.Unmarshal(v, &e); err == nil {
+				staleIDs = append(staleIDs, e.ID)
+			}
+		}
+
+		for _, k := range staleKeys {
+			if err := eventsB.Delete(k); err != nil {
+				return err
+			}
+		}
+		for _, id := range staleIDs {
+			if err := idB.Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,53 @@
+package eventstore
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// NewFromEnv builds the EventStore selected by EVENT_STORE_BACKEND
+// ("memory" (default), "boltdb", or "redis"). maxSize bounds the in-memory
+// backend's ring buffer; the boltdb and redis backends ignore it and
+// instead retain everything until EVENT_RETENTION-based compaction (see
+// the cluster package) removes it.
+//
+// Env vars:
+//   - EVENT_STORE_BACKEND (default: memory)
+//   - EVENT_STORE_BOLT_PATH (default: /data/events.db), boltdb only
+//   - REDIS_ADDR (default: localhost:6379), REDIS_PASSWORD,
+//     REDIS_DB (default: 0), REDIS_KEY_PREFIX (default:
+//     mqtt-order-event-client), redis only
+func NewFromEnv(maxSize int) (EventStore, error) {
+	switch backend := getEnv("EVENT_STORE_BACKEND", "memory"); backend {
+	case "memory":
+		return NewMemoryStore(maxSize), nil
+	case "boltdb":
+		return NewBoltStore(getEnv("EVENT_STORE_BOLT_PATH", "/data/events.db"))
+	case "redis":
+		return NewRedisStore(
+			getEnv("REDIS_ADDR", "localhost:6379"),
+			getEnv("REDIS_PASSWORD", ""),
+			getEnvInt("REDIS_DB", 0),
+			getEnv("REDIS_KEY_PREFIX", "mqtt-order-event-client"),
+		)
+	default:
+		return nil, fmt.Errorf("eventstore: unknown EVENT_STORE_BACKEND %q, expected \"memory\", \"boltdb\", or \"redis\"", backend)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
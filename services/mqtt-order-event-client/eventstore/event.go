@@ -0,0 +1,22 @@
+package eventstore
+
+import "time"
+
+// Event represents the structure of events received from mqtt-event-generator.
+// Lives here, rather than in cluster or main, so every EventStore backend
+// and cluster's Raft fsm share one definition with no import cycle between
+// cluster and eventstore.
+type Event struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Source    string    `json:"source"`
+	Data      EventData `json:"data"`
+}
+
+// EventData is the data payload of an Event.
+type EventData struct {
+	Temperature float64 `json:"temperature"`
+	Humidity    float64 `json:"humidity"`
+	Status      string  `json:"status"`
+}
@@ -0,0 +1,118 @@
+package eventstore
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore is the original in-memory ring buffer: the last maxSize
+// events, lost on process restart. It's the default EVENT_STORE_BACKEND, so
+// a deployment that sets nothing behaves exactly as it did before this
+// package existed.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	events  []Event
+	maxSize int
+}
+
+// NewMemoryStore returns a MemoryStore retaining at most maxSize events. A
+// maxSize <= 0 means unbounded.
+func NewMemoryStore(maxSize int) *MemoryStore {
+	return &MemoryStore{events: make([]Event, 0), maxSize: maxSize}
+}
+
+func (s *MemoryStore) Add(event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, event)
+	if s.maxSize > 0 && len(s.events) > s.maxSize {
+		s.events = s.events[len(s.events)-s.maxSize:]
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetEvents() []Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]Event, len(s.events))
+	copy(events, s.events)
+	return events
+}
+
+func (s *MemoryStore) GetLatestEvent() *Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.events) == 0 {
+		return nil
+	}
+	latest := s.events[len(s.events)-1]
+	return &latest
+}
+
+func (s *MemoryStore) GetEventCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.events)
+}
+
+func (s *MemoryStore) GetEventsByTimeRange(from, to time.Time) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []Event
+	for _, e := range s.events {
+		if !e.Timestamp.Before(from) && !e.Timestamp.After(to) {
+			matches = append(matches, e)
+		}
+	}
+	return matches, nil
+}
+
+func (s *MemoryStore) GetEventsBySource(source string, limit int) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []Event
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if s.events[i].Source != source {
+			continue
+		}
+		matches = append(matches, s.events[i])
+		if limit > 0 && len(matches) >= limit {
+			break
+		}
+	}
+	return matches, nil
+}
+
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, e := range s.events {
+		if e.ID == id {
+			s.events = append(s.events[:i], s.events[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) CompactBefore(cutoff time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.events[:0]
+	for _, e := range s.events {
+		if !e.Timestamp.Before(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	s.events = kept
+	return nil
+}
+
+func (s *MemoryStore) Close() error { return nil }
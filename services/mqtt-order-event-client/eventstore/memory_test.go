@@ -0,0 +1,116 @@
+package eventstore
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestEvent(id, source string, at time.Time) Event {
+	return Event{ID: id, Timestamp: at, Type: "temperature", Source: source}
+}
+
+func TestMemoryStore_Add_EvictsOldestPastMaxSize(t *testing.T) {
+	store := NewMemoryStore(2)
+	base := time.Now()
+
+	store.Add(newTestEvent("1", "sensor-a", base))
+	store.Add(newTestEvent("2", "sensor-a", base.Add(time.Second)))
+	store.Add(newTestEvent("3", "sensor-a", base.Add(2*time.Second)))
+
+	events := store.GetEvents()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events retained, got %d", len(events))
+	}
+	if events[0].ID != "2" || events[1].ID != "3" {
+		t.Errorf("expected the oldest event to be evicted, got ids %s, %s", events[0].ID, events[1].ID)
+	}
+}
+
+func TestMemoryStore_Add_UnboundedWhenMaxSizeNotPositive(t *testing.T) {
+	store := NewMemoryStore(0)
+	for i := 0; i < 10; i++ {
+		store.Add(newTestEvent(string(rune('a'+i)), "sensor-a", time.Now()))
+	}
+	if got := store.GetEventCount(); got != 10 {
+		t.Errorf("expected all 10 events retained, got %d", got)
+	}
+}
+
+func TestMemoryStore_GetLatestEvent_ReturnsMostRecentlyAdded(t *testing.T) {
+	store := NewMemoryStore(10)
+	if store.GetLatestEvent() != nil {
+		t.Fatal("expected nil latest event on an empty store")
+	}
+
+	store.Add(newTestEvent("1", "sensor-a", time.Now()))
+	store.Add(newTestEvent("2", "sensor-a", time.Now()))
+
+	latest := store.GetLatestEvent()
+	if latest == nil || latest.ID != "2" {
+		t.Errorf("expected latest event to be id 2, got %v", latest)
+	}
+}
+
+func TestMemoryStore_GetEventsByTimeRange_FiltersInclusively(t *testing.T) {
+	store := NewMemoryStore(10)
+	base := time.Now()
+	store.Add(newTestEvent("1", "sensor-a", base))
+	store.Add(newTestEvent("2", "sensor-a", base.Add(time.Minute)))
+	store.Add(newTestEvent("3", "sensor-a", base.Add(2*time.Minute)))
+
+	matches, err := store.GetEventsByTimeRange(base, base.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetEventsByTimeRange() returned error: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 events within range, got %d", len(matches))
+	}
+}
+
+func TestMemoryStore_GetEventsBySource_FiltersAndLimitsNewestFirst(t *testing.T) {
+	store := NewMemoryStore(10)
+	base := time.Now()
+	store.Add(newTestEvent("1", "sensor-a", base))
+	store.Add(newTestEvent("2", "sensor-b", base.Add(time.Second)))
+	store.Add(newTestEvent("3", "sensor-a", base.Add(2*time.Second)))
+
+	matches, err := store.GetEventsBySource("sensor-a", 1)
+	if err != nil {
+		t.Fatalf("GetEventsBySource() returned error: %v", err)
+	}
+	if len(matches) != 1 || matches[0].ID != "3" {
+		t.Errorf("expected the newest sensor-a event (id 3), got %v", matches)
+	}
+}
+
+func TestMemoryStore_Delete_RemovesMatchingEvent(t *testing.T) {
+	store := NewMemoryStore(10)
+	store.Add(newTestEvent("1", "sensor-a", time.Now()))
+	store.Add(newTestEvent("2", "sensor-a", time.Now()))
+
+	if err := store.Delete("1"); err != nil {
+		t.Fatalf("Delete() returned error: %v", err)
+	}
+	if got := store.GetEventCount(); got != 1 {
+		t.Fatalf("expected 1 event remaining, got %d", got)
+	}
+	if store.GetEvents()[0].ID != "2" {
+		t.Errorf("expected remaining event to be id 2, got %s", store.GetEvents()[0].ID)
+	}
+}
+
+func TestMemoryStore_CompactBefore_DropsEventsOlderThanCutoff(t *testing.T) {
+	store := NewMemoryStore(10)
+	base := time.Now()
+	store.Add(newTestEvent("1", "sensor-a", base))
+	store.Add(newTestEvent("2", "sensor-a", base.Add(time.Hour)))
+
+	if err := store.CompactBefore(base.Add(time.Minute)); err != nil {
+		t.Fatalf("CompactBefore() returned error: %v", err)
+	}
+
+	events := store.GetEvents()
+	if len(events) != 1 || events[0].ID != "2" {
+		t.Errorf("expected only id 2 to survive compaction, got %v", events)
+	}
+}
@@ -0,0 +1,191 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore persists events to Redis: a sorted set keyed by event
+// timestamp (as Unix nanoseconds) for range queries, and a hash holding
+// each event's JSON payload by id. Like BoltStore, GetEventsBySource has no
+// secondary index and scans every member.
+type RedisStore struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+// NewRedisStore connects to addr (authenticating with password and
+// selecting db if set) and returns a RedisStore. keyPrefix namespaces its
+// sorted set and hash keys, e.g. "mqtt-order-event-client", so one Redis
+// instance can back more than one deployment without collisions.
+func NewRedisStore(addr, password string, db int, keyPrefix string) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr, Password: password, DB: db})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("eventstore: failed to connect to Redis at %s: %w", addr, err)
+	}
+
+	return &RedisStore{client: client, keyPrefix: keyPrefix}, nil
+}
+
+func (s *RedisStore) timesKey() string { return s.keyPrefix + ":by_time" }
+func (s *RedisStore) dataKey() string  { return s.keyPrefix + ":data" }
+
+func (s *RedisStore) Add(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("eventstore: failed to marshal event: %w", err)
+	}
+
+	ctx := context.Background()
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, s.timesKey(), redis.Z{Score: float64(event.Timestamp.UnixNano()), Member: event.ID})
+	pipe.HSet(ctx, s.dataKey(), event.ID, payload)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("eventstore: failed to store event: %w", err)
+	}
+	return nil
+}
+
+// idsToEvents fetches and decodes every id's payload from the data hash,
+// silently skipping an id whose payload is missing or corrupt (e.g. raced
+// with a concurrent Delete) rather than failing the whole lookup.
+func (s *RedisStore) idsToEvents(ctx context.Context, ids []string) ([]Event, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	payloads, err := s.client.HMGet(ctx, s.dataKey(), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("eventstore: failed to fetch events: %w", err)
+	}
+
+	events := make([]Event, 0, len(payloads))
+	for _, p := range payloads {
+		str, ok := p.(string)
+		if !ok {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(str), &e); err != nil {
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func (s *RedisStore) GetEvents() []Event {
+	ctx := context.Background()
+	ids, err := s.client.ZRange(ctx, s.timesKey(), 0, -1).Result()
+	if err != nil {
+		return nil
+	}
+	events, _ := s.idsToEvents(ctx, ids)
+	return events
+}
+
+func (s *RedisStore) GetLatestEvent() *Event {
+	ctx := context.Background()
+	ids, err := s.client.ZRevRange(ctx, s.timesKey(), 0, 0).Result()
+	if err != nil || len(ids) == 0 {
+		return nil
+	}
+	events, _ := s.idsToEvents(ctx, ids)
+	if len(events) == 0 {
+		return nil
+	}
+	return &events[0]
+}
+
+func (s *RedisStore) GetEventCount() int {
+	count, err := s.client.ZCard(context.Background(), s.timesKey()).Result()
+	if err != nil {
+		return 0
+	}
+	return int(count)
+}
+
+func (s *RedisStore) GetEventsByTimeRange(from, to time.Time) ([]Event, error) {
+	ctx := context.Background()
+	ids, err := s.client.ZRangeByScore(ctx, s.timesKey(), &redis.ZRangeBy{
+		Min: fmt.Sprintf("%d", from.UnixNano()),
+		Max: fmt.Sprintf("%d", to.UnixNano()),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("eventstore: failed to query time range: %w", err)
+	}
+	return s.idsToEvents(ctx, ids)
+}
+
+func (s *RedisStore) GetEventsBySource(source string, limit int) ([]Event, error) {
+	ctx := context.Background()
+	ids, err := s.client.ZRevRange(ctx, s.timesKey(), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("eventstore: failed to list events: %w", err)
+	}
+	events, err := s.idsToEvents(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Event
+	for _, e := range events {
+		if e.Source != source {
+			continue
+		}
+		matches = append(matches, e)
+		if limit > 0 && len(matches) >= limit {
+			break
+		}
+	}
+	return matches, nil
+}
+
+func (s *RedisStore) Delete(id string) error {
+	ctx := context.Background()
+	pipe := s.client.TxPipeline()
+	pipe.ZRem(ctx, s.timesKey(), id)
+	pipe.HDel(ctx, s.dataKey(), id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("eventstore: failed to delete event %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *RedisStore) CompactBefore(cutoff time.Time) error {
+	ctx := context.Background()
+	ids, err := s.client.ZRangeByScore(ctx, s.timesKey(), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprintf("%d", cutoff.UnixNano()),
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("eventstore: failed to query stale events: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, len(ids))
+	for i, id := range ids {
+		members[i] = id
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.ZRem(ctx, s.timesKey(), members...)
+	pipe.HDel(ctx, s.dataKey(), ids...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("eventstore: failed to compact stale events: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
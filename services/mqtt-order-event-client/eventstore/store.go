@@ -0,0 +1,34 @@
+// Package eventstore holds the event storage backends the mqtt-order-event-
+// client can run against, selected at startup via EVENT_STORE_BACKEND (see
+// NewFromEnv). cluster.fsm holds one of these rather than a bare slice, so
+// Raft replication (which command got applied) and storage (what a
+// command does to the data) are independent concerns.
+package eventstore
+
+import "time"
+
+// EventStore is the read/write surface every backend implements: the
+// original in-memory ring buffer (MemoryStore), a BoltDB-backed store
+// (BoltStore), and a Redis-backed store (RedisStore).
+type EventStore interface {
+	// Add appends event to the store.
+	Add(event Event) error
+	// GetEvents returns every event currently retained, oldest first.
+	GetEvents() []Event
+	// GetLatestEvent returns the most recently added event, or nil if the
+	// store is empty.
+	GetLatestEvent() *Event
+	// GetEventCount returns how many events the store currently retains.
+	GetEventCount() int
+	// GetEventsByTimeRange returns events with from <= Timestamp <= to.
+	GetEventsByTimeRange(from, to time.Time) ([]Event, error)
+	// GetEventsBySource returns up to limit events from source, most
+	// recent first. limit <= 0 means no limit.
+	GetEventsBySource(source string, limit int) ([]Event, error)
+	// Delete removes the event with the given id, if present.
+	Delete(id string) error
+	// CompactBefore removes every event with a Timestamp before cutoff.
+	CompactBefore(cutoff time.Time) error
+	// Close releases the store's resources.
+	Close() error
+}
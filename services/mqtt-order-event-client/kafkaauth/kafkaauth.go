@@ -0,0 +1,146 @@
+// Package kafkaauth builds the SASL mechanism and TLS configuration the
+// Kafka publisher authenticates with, read from environment variables. It
+// mirrors the warehouse batch service's own kafkaauth package so both keep
+// the same env var names and broker-auth behavior, even though the two are
+// independent packages (this repo has no shared Go module to hang a single
+// implementation off of).
+package kafkaauth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	kplain "github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// Config holds the Kafka SASL/TLS settings used to build a kafka.Transport.
+type Config struct {
+	SASLEnable    bool
+	SASLMechanism string // PLAIN | SCRAM-SHA-256 | SCRAM-SHA-512 | OAUTHBEARER
+	Username      string
+	Password      string
+
+	TLSEnable             bool
+	TLSCAFile             string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSInsecureSkipVerify bool
+}
+
+// ConfigFromEnv reads Config from the KAFKA_SASL_* and KAFKA_TLS_* env vars:
+//   - KAFKA_SASL_ENABLE (true/false, default: false)
+//   - KAFKA_SASL_MECHANISM (PLAIN|SCRAM-SHA-256|SCRAM-SHA-512|OAUTHBEARER, default: PLAIN);
+//     OAUTHBEARER is recognized, but BuildTransport returns an error for it
+//     since no OAUTHBEARER mechanism is built here
+//   - KAFKA_USERNAME, KAFKA_PASSWORD
+//   - KAFKA_TLS_ENABLE (true/false, default: false)
+//   - KAFKA_TLS_CA_FILE, KAFKA_TLS_CERT_FILE, KAFKA_TLS_KEY_FILE
+//   - KAFKA_TLS_INSECURE_SKIP_VERIFY (true/false, default: false)
+func ConfigFromEnv() Config {
+	return Config{
+		SASLEnable:    getEnvBool("KAFKA_SASL_ENABLE", false),
+		SASLMechanism: getEnv("KAFKA_SASL_MECHANISM", "PLAIN"),
+		Username:      getEnv("KAFKA_USERNAME", ""),
+		Password:      getEnv("KAFKA_PASSWORD", ""),
+
+		TLSEnable:             getEnvBool("KAFKA_TLS_ENABLE", false),
+		TLSCAFile:             getEnv("KAFKA_TLS_CA_FILE", ""),
+		TLSCertFile:           getEnv("KAFKA_TLS_CERT_FILE", ""),
+		TLSKeyFile:            getEnv("KAFKA_TLS_KEY_FILE", ""),
+		TLSInsecureSkipVerify: getEnvBool("KAFKA_TLS_INSECURE_SKIP_VERIFY", false),
+	}
+}
+
+// BuildTransport builds the kafka.RoundTripper described by cfg, or nil if
+// neither SASL nor TLS is enabled, in which case callers should leave
+// kafka.Writer's Transport field unset and let kafka-go use its default.
+func BuildTransport(cfg Config) (kafka.RoundTripper, error) {
+	var mechanism sasl.Mechanism
+	if cfg.SASLEnable {
+		var err error
+		mechanism, err = buildMechanism(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var tlsConfig *tls.Config
+	if cfg.TLSEnable {
+		var err error
+		tlsConfig, err = buildTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if mechanism == nil && tlsConfig == nil {
+		return nil, nil
+	}
+
+	return &kafka.Transport{SASL: mechanism, TLS: tlsConfig}, nil
+}
+
+func buildMechanism(cfg Config) (sasl.Mechanism, error) {
+	switch strings.ToUpper(cfg.SASLMechanism) {
+	case "", "PLAIN":
+		return kplain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	case "OAUTHBEARER":
+		return nil, fmt.Errorf("kafkaauth: SASL mechanism OAUTHBEARER is not yet implemented")
+	default:
+		return nil, fmt.Errorf("kafkaauth: unknown SASL mechanism %q", cfg.SASLMechanism)
+	}
+}
+
+func buildTLSConfig(cfg Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.TLSInsecureSkipVerify}
+
+	if cfg.TLSCAFile != "" {
+		caCert, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafkaauth: failed to read TLS CA file %s: %w", cfg.TLSCAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("kafkaauth: failed to parse TLS CA file %s", cfg.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafkaauth: failed to load TLS client cert/key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
@@ -8,103 +8,77 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
+	"strconv"
 	"syscall"
 	"time"
 
+	"mqtt-order-event-client/cluster"
+	"mqtt-order-event-client/eventstore"
 	publisher "mqtt-order-event-client/publisher"
+	"mqtt-order-event-client/rules"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/gin-gonic/gin"
 )
 
-var minTemperature float64 = 10.0
-
-// Event represents the structure of events received from mqtt-event-generator
-type Event struct {
-	ID        string    `json:"id"`
-	Timestamp time.Time `json:"timestamp"`
-	Type      string    `json:"type"`
-	Source    string    `json:"source"`
-	Data      EventData `json:"data"`
-}
-
-// EventData represents the data payload of an event
-type EventData struct {
-	Temperature float64 `json:"temperature"`
-	Humidity    float64 `json:"humidity"`
-	Status      string  `json:"status"`
-}
-
-// EventStore manages received events in memory
-type EventStore struct {
-	mu      sync.RWMutex
-	events  []Event
-	maxSize int
-}
+// Event is an alias for cluster.Event, so the rest of main.go (and the
+// JSON it decodes off the wire) is unaffected by the event store now
+// living in its own package.
+type Event = cluster.Event
+
+// EventData is an alias for cluster.EventData.
+type EventData = cluster.EventData
+
+// sharedSubscriptionGroup names the MQTT shared subscription group
+// ("$share/<group>/<topic>") so that, when clustered, only one replica
+// receives a given message even though every replica is subscribed -
+// broker-side fan-out picks one subscriber per group per message. This is
+// a broker extension on top of MQTT 3.1.1 (the protocol version
+// github.com/eclipse/paho.mqtt.golang speaks here), not a core 3.1.1
+// feature, but it's widely implemented (EMQX, VerneMQ, Mosquitto 2.x) as a
+// SUBSCRIBE-time topic filter prefix, unlike binary-mode CloudEvents
+// headers which need MQTT 5 properties the protocol itself doesn't have
+// before v5.
+const sharedSubscriptionGroup = "order-clients"
+
+var eventStore *cluster.Cluster
+var orderPublisher *publisher.MqttPublisher
+var ruleEngine *rules.Engine
 
-// NewEventStore creates a new event store with a maximum size
-func NewEventStore(maxSize int) *EventStore {
-	return &EventStore{
-		events:  make([]Event, 0),
-		maxSize: maxSize,
+func main() {
+	// Build the event storage backend selected by EVENT_STORE_BACKEND (see
+	// the eventstore package: in-memory ring buffer, BoltDB, or Redis),
+	// then replicate writes into it via Raft. With no CLUSTER_PEERS
+	// configured the cluster bootstraps as single-node, behaving like the
+	// old in-memory-only EventStore did when EVENT_STORE_BACKEND is also
+	// left at its "memory" default.
+	store, err := eventstore.NewFromEnv(1000)
+	if err != nil {
+		log.Fatalf("Failed to initialize event store backend: %v", err)
 	}
-}
 
-// AddEvent adds a new event to the store
-func (es *EventStore) AddEvent(event Event) {
-	es.mu.Lock()
-	defer es.mu.Unlock()
-
-	es.events = append(es.events, event)
-
-	// Keep only the last maxSize events
-	if len(es.events) > es.maxSize {
-		es.events = es.events[len(es.events)-es.maxSize:]
+	eventStore, err = cluster.New(cluster.ConfigFromEnv(), store)
+	if err != nil {
+		log.Fatalf("Failed to start clustered event store: %v", err)
 	}
-}
-
-// GetEvents returns all stored events
-func (es *EventStore) GetEvents() []Event {
-	es.mu.RLock()
-	defer es.mu.RUnlock()
 
-	// Return a copy to avoid race conditions
-	events := make([]Event, len(es.events))
-	copy(events, es.events)
-	return events
-}
-
-// GetLatestEvent returns the most recent event
-func (es *EventStore) GetLatestEvent() *Event {
-	es.mu.RLock()
-	defer es.mu.RUnlock()
-
-	if len(es.events) == 0 {
-		return nil
+	// Load the damage-detection rule engine from RULES_FILE, replacing the
+	// old hard-coded minTemperature gate. A missing/unset RULES_FILE loads
+	// zero rules, so a deployment that hasn't adopted rules yet fires no
+	// damage events at all rather than falling back to a guessed default.
+	ruleEngine, err = rules.NewEngine(getEnv("RULES_FILE", ""))
+	if err != nil {
+		log.Fatalf("Failed to load rules file: %v", err)
 	}
 
-	return &es.events[len(es.events)-1]
-}
-
-// GetEventCount returns the number of stored events
-func (es *EventStore) GetEventCount() int {
-	es.mu.RLock()
-	defer es.mu.RUnlock()
-	return len(es.events)
-}
-
-var eventStore *EventStore
-var orderPublisher *publisher.MqttPublisher
-
-func main() {
-	// Initialize event store with max 1000 events
-	eventStore = NewEventStore(1000)
-
 	// MQTT Configuration
 	broker := getEnv("MQTT_BROKER", "tcp://localhost:1883")
 	clientID := getEnv("MQTT_CLIENT_ID", "order-event-client")
 	topic := getEnv("MQTT_TOPIC", "events/sensor")
+	// Subscribing via a shared subscription means every replica can
+	// subscribe to the same topic while the broker still only delivers
+	// each message to one of them; see sharedSubscriptionGroup.
+	subscribeTopic := fmt.Sprintf("$share/%s/%s", sharedSubscriptionGroup, topic)
 	username := getEnv("MQTT_USERNAME", "")
 	password := getEnv("MQTT_PASSWORD", "")
 
@@ -137,14 +111,13 @@ func main() {
 	log.Printf("Connected to MQTT broker: %s", broker)
 
 	// Subscribe to the topic
-	if token := client.Subscribe(topic, 1, nil); token.Wait() && token.Error() != nil {
-		log.Fatalf("Error subscribing to topic %s: %v", topic, token.Error())
+	if token := client.Subscribe(subscribeTopic, 1, nil); token.Wait() && token.Error() != nil {
+		log.Fatalf("Error subscribing to topic %s: %v", subscribeTopic, token.Error())
 	}
 
-	log.Printf("Subscribed to topic: %s", topic)
+	log.Printf("Subscribed to topic: %s", subscribeTopic)
 
 	// Initialize MQTT Order Publisher from environment
-	var err error
 	orderPublisher, err = publisher.NewMqttPublisherFromEnv()
 	if err != nil {
 		log.Printf("Warning: could not initialize MQTT publisher: %v", err)
@@ -171,12 +144,16 @@ func main() {
 	log.Println("Received termination signal, shutting down...")
 
 	// Unsubscribe and disconnect
-	if token := client.Unsubscribe(topic); token.Wait() && token.Error() != nil {
+	if token := client.Unsubscribe(subscribeTopic); token.Wait() && token.Error() != nil {
 		log.Printf("Error unsubscribing: %v", token.Error())
 	}
 
 	client.Disconnect(250)
 	log.Println("MQTT client disconnected")
+
+	if err := eventStore.Close(); err != nil {
+		log.Printf("Error closing clustered event store: %v", err)
+	}
 }
 
 // startHTTPServer starts the HTTP server with REST endpoints
@@ -209,15 +186,65 @@ func startHTTPServer(port string) {
 		})
 	})
 
-	// Get all events endpoint
+	// Get events endpoint, optionally filtered by a time range (from/to,
+	// RFC3339) and/or source, and capped at limit, keeping the most recent
+	// events. A source-only query lists them newest first; the others list
+	// them oldest first. With no query params this is the original "get
+	// everything" behavior.
 	router.GET("/events", func(c *gin.Context) {
-		events := eventStore.GetEvents()
+		fromParam := c.Query("from")
+		toParam := c.Query("to")
+		source := c.Query("source")
+
+		var events []cluster.Event
+		var err error
+
+		switch {
+		case fromParam != "" || toParam != "":
+			from, parseErr := parseTimeParam(fromParam, time.Time{})
+			if parseErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid from: %v", parseErr)})
+				return
+			}
+			to, parseErr := parseTimeParam(toParam, time.Now())
+			if parseErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid to: %v", parseErr)})
+				return
+			}
+			events, err = eventStore.GetEventsByTimeRange(from, to)
+		case source != "":
+			events, err = eventStore.GetEventsBySource(source, queryInt(c, "limit", 0))
+		default:
+			events = eventStore.GetEvents()
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if source != "" && (fromParam != "" || toParam != "") {
+			events = filterBySource(events, source)
+		}
+		if limit := queryInt(c, "limit", 0); limit > 0 && len(events) > limit {
+			events = events[len(events)-limit:]
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"events": events,
 			"count":  len(events),
 		})
 	})
 
+	// Delete a single event by id.
+	router.DELETE("/events/:id", func(c *gin.Context) {
+		if err := eventStore.DeleteEvent(c.Param("id")); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"deleted": c.Param("id")})
+	})
+
 	// Get latest event endpoint
 	router.GET("/events/latest", func(c *gin.Context) {
 		event := eventStore.GetLatestEvent()
@@ -271,6 +298,43 @@ func startHTTPServer(port string) {
 			"average_humidity":    fmt.Sprintf("%.2f", avgHumidity),
 			"active_sensors":      activeCount,
 			"latest_event":        events[len(events)-1],
+			"rules":               ruleEngine.Rules(),
+		})
+	})
+
+	// List the currently loaded damage-detection rules and their hit
+	// counters, so operators can see what's active without reading
+	// RULES_FILE directly.
+	router.GET("/rules", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"rules": ruleEngine.Rules(),
+		})
+	})
+
+	// Re-read RULES_FILE and swap in the freshly compiled rules, so
+	// operators can tune thresholds without redeploying.
+	router.POST("/rules/reload", func(c *gin.Context) {
+		if err := ruleEngine.Reload(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"rules": ruleEngine.Rules()})
+	})
+
+	// Cluster membership endpoint - the gossip-visible node set, for
+	// observability independent of Raft's own view.
+	router.GET("/cluster/members", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"members": eventStore.Members(),
+		})
+	})
+
+	// Cluster leader endpoint - which node's Raft instance currently
+	// accepts AddEvent writes.
+	router.GET("/cluster/leader", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"leader":    eventStore.Leader(),
+			"is_leader": eventStore.IsLeader(),
 		})
 	})
 
@@ -291,16 +355,28 @@ var messageHandler mqtt.MessageHandler = func(client mqtt.Client, msg mqtt.Messa
 		return
 	}
 
-	// Store the event
-	eventStore.AddEvent(event)
+	// Replicate the event via Raft. This only succeeds on the current
+	// leader - see the cluster package doc comment for why a follower
+	// logs and drops rather than forwarding the write.
+	if err := eventStore.AddEvent(event); err != nil {
+		log.Printf("Error storing event: %v", err)
+		return
+	}
 
 	log.Printf("Event stored: ID=%s, Type=%s, Source=%s, Temp=%.2f°C, Humidity=%.2f%%",
 		event.ID, event.Type, event.Source, event.Data.Temperature, event.Data.Humidity)
-	// Publish order damage event to Kafka after logging and storing
-	if event.Data.Temperature < minTemperature {
-		log.Printf("Publishing order damage event for sensor/order id=%s", event.ID)
+
+	// Run the event through the rule engine (see the rules package) rather
+	// than a single hard-coded temperature check. A rule that fires
+	// supplies the Severity/Description published below instead of
+	// MqttPublisher's classifier-derived defaults.
+	matches, err := ruleEngine.Evaluate(event)
+	if err != nil {
+		log.Printf("Error evaluating rules for event id=%s: %v", event.ID, err)
+	}
+	for _, match := range matches {
+		log.Printf("Rule %s matched for sensor/order id=%s, severity=%s", match.RuleID, event.ID, match.Severity)
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
 		if err := orderPublisher.PublishOrderDamageFromSensor(
 			ctx,
 			event.ID,
@@ -309,11 +385,14 @@ var messageHandler mqtt.MessageHandler = func(client mqtt.Client, msg mqtt.Messa
 			event.Data.Humidity,
 			event.Data.Status,
 			msg.Topic(),
+			match.Severity,
+			match.Description,
 		); err != nil {
 			log.Printf("Error publishing order damage event: %v", err)
 		} else {
 			log.Printf("Order damage event published for sensor/order id=%s", event.ID)
 		}
+		cancel()
 	}
 }
 
@@ -334,3 +413,38 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// parseTimeParam parses an RFC3339 query param, returning defaultValue
+// for an empty value.
+func parseTimeParam(value string, defaultValue time.Time) (time.Time, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// queryInt parses a query param as an int, returning defaultValue if it's
+// absent or invalid.
+func queryInt(c *gin.Context, key string, defaultValue int) int {
+	value := c.Query(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
+
+// filterBySource narrows events to those from source, e.g. after a
+// combined from/to + source query.
+func filterBySource(events []cluster.Event, source string) []cluster.Event {
+	filtered := make([]cluster.Event, 0, len(events))
+	for _, e := range events {
+		if e.Source == source {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
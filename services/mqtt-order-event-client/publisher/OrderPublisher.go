@@ -9,7 +9,12 @@ import (
 	"time"
 
 	"github.com/segmentio/kafka-go"
-	kplain "github.com/segmentio/kafka-go/sasl/plain"
+
+	"mqtt-order-event-client/cloudevents"
+	"mqtt-order-event-client/eventschema"
+	"mqtt-order-event-client/kafkaauth"
+	"mqtt-order-event-client/kafkaoptions"
+	"mqtt-order-event-client/tracing"
 )
 
 // OrderDamageEvent is the entity published to Kafka for order damage notifications
@@ -34,8 +39,9 @@ type DamageDetails struct {
 
 // Publisher wraps a Kafka writer.
 type Publisher struct {
-	writer *kafka.Writer
-	Topic  string
+	writer  *kafka.Writer
+	Topic   string
+	schemas *eventschema.Registry
 }
 
 // NewPublisherFromEnv creates a Kafka publisher using environment variables.
@@ -43,30 +49,42 @@ type Publisher struct {
 // - KAFKA_BROKERS (comma-separated, default: kafka:9092)
 // - KAFKA_TOPIC (default: order-status-events)
 // - KAFKA_SASL_ENABLE (true/false, default: false)
+// - KAFKA_SASL_MECHANISM (PLAIN|SCRAM-SHA-256|SCRAM-SHA-512|OAUTHBEARER, default: PLAIN)
 // - KAFKA_USERNAME, KAFKA_PASSWORD (when SASL enabled)
+// - KAFKA_TLS_ENABLE (true/false, default: false)
+// - KAFKA_TLS_CA_FILE, KAFKA_TLS_CERT_FILE, KAFKA_TLS_KEY_FILE
+// - KAFKA_TLS_INSECURE_SKIP_VERIFY (true/false, default: false)
+// - KAFKA_COMPRESSION (none|gzip|snappy|lz4|zstd, default: none)
+// - KAFKA_BATCH_SIZE, KAFKA_BATCH_BYTES, KAFKA_BATCH_TIMEOUT
+// - KAFKA_ASYNC (true/false, default: false)
+// See the kafkaauth and kafkaoptions packages for the full auth-config and
+// batching/compression behavior.
 func NewPublisherFromEnv() (*Publisher, error) {
 	brokers := getEnv("KAFKA_BROKERS", "kafka:9092")
 	topic := getEnv("KAFKA_TOPIC", "order-status-events")
-	saslEnable := strings.ToLower(getEnv("KAFKA_SASL_ENABLE", "false")) == "true"
-	username := getEnv("KAFKA_USERNAME", "")
-	password := getEnv("KAFKA_PASSWORD", "")
-
-	var transport kafka.RoundTripper
-	if saslEnable && username != "" {
-		mech := kplain.Mechanism{Username: username, Password: password}
-		transport = &kafka.Transport{SASL: mech}
+
+	transport, err := kafkaauth.BuildTransport(kafkaauth.ConfigFromEnv())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Kafka auth transport: %w", err)
 	}
 
+	options := kafkaoptions.OptionsFromEnv(200 * time.Millisecond)
+
 	w := &kafka.Writer{
 		Addr:         kafka.TCP(strings.Split(brokers, ",")...),
 		Topic:        topic,
 		Balancer:     &kafka.LeastBytes{},
 		RequiredAcks: kafka.RequireAll,
-		BatchTimeout: 200 * time.Millisecond,
+		Async:        options.Async,
+		Completion:   options.Completion,
+		Compression:  options.Compression,
+		BatchSize:    options.BatchSize,
+		BatchBytes:   options.BatchBytes,
+		BatchTimeout: options.BatchTimeout,
 		Transport:    transport,
 	}
 
-	return &Publisher{writer: w, Topic: topic}, nil
+	return &Publisher{writer: w, Topic: topic, schemas: eventschema.NewOrderDamageRegistry()}, nil
 }
 
 // Close closes the underlying Kafka writer.
@@ -105,9 +123,18 @@ func (p *Publisher) PublishOrderDamageFromSensor(ctx context.Context, sensorID,
 		return err
 	}
 
+	if p.schemas != nil {
+		if err := p.schemas.Validate(evt.Type, payload); err != nil {
+			return fmt.Errorf("order damage event failed schema validation: %w", err)
+		}
+	}
+
+	attrs := cloudevents.NewAttributes(evt.Source, cloudevents.Type(evt.Type), evt.OrderID, tracing.NewTraceParent())
+
 	return p.writer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte(evt.OrderID),
-		Value: payload,
+		Key:     []byte(evt.OrderID),
+		Value:   payload,
+		Headers: attrs.Headers(),
 	})
 }
 
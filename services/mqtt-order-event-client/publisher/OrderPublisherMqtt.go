@@ -4,30 +4,78 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"mqtt-order-event-client/cloudevents"
+	"mqtt-order-event-client/eventschema"
+	"mqtt-order-event-client/tracing"
 )
 
+// cloudEventsModeStructured wraps the published OrderDamageEvent in a
+// single CloudEvents 1.0 structured-mode JSON envelope (see
+// mqtt-order-event-client/cloudevents.Wrap). This is the only mode
+// MqttPublisher offers: binary mode (attributes as transport-level "ce-*"
+// properties) needs MQTT 5, and github.com/eclipse/paho.mqtt.golang here
+// only speaks MQTT 3.1.1, which has no property mechanism to carry them in.
+const cloudEventsModeStructured = "structured"
+
 // MqttPublisher publishes OrderDamageEvent messages to an MQTT topic.
 type MqttPublisher struct {
-	client mqtt.Client
-	Topic  string
+	client          mqtt.Client
+	Topic           string
+	cloudEventsMode string
+	classifier      SeverityClassifier
+	schemas         *eventschema.Registry
+}
+
+// NewMqttPublisher builds an MqttPublisher around an already-connected
+// client. classifier may be nil, in which case readings are classified by a
+// ThresholdClassifier falling back to defaultThresholds for every sensor.
+func NewMqttPublisher(client mqtt.Client, topic, cloudEventsMode string, classifier SeverityClassifier) *MqttPublisher {
+	if classifier == nil {
+		classifier = NewThresholdClassifier(nil)
+	}
+	return &MqttPublisher{client: client, Topic: topic, cloudEventsMode: cloudEventsMode, classifier: classifier, schemas: eventschema.NewOrderDamageRegistry()}
 }
 
 // NewMqttPublisherFromEnv creates and connects an MQTT publisher using env vars.
 // Env vars:
-// - MQTT_BROKER (default: tcp://localhost:1883)
-// - MQTT_PUB_CLIENT_ID (default: order-event-client-pub)
-// - MQTT_PUB_TOPIC (default: events/order-damage)
-// - MQTT_USERNAME (optional)
-// - MQTT_PASSWORD (optional)
+//   - MQTT_BROKER (default: tcp://localhost:1883)
+//   - MQTT_PUB_CLIENT_ID (default: order-event-client)
+//   - MQTT_PUB_TOPIC (default: events/order-damage)
+//   - MQTT_USERNAME (optional)
+//   - MQTT_PASSWORD (optional)
+//   - CLOUDEVENTS_MODE (default: structured; "binary" logs a warning and
+//     falls back to structured, see cloudEventsModeStructured)
+//   - SEVERITY_THRESHOLDS_FILE (optional path to a YAML ThresholdTable; see
+//     LoadThresholdTable. Unset or missing means every sensor uses
+//     defaultThresholds)
+//   - SEVERITY_ROLLING_WINDOW (default: false; "true" wraps the threshold
+//     classifier in a RollingWindowClassifier so a transient spike doesn't
+//     immediately escalate)
+//   - SEVERITY_WINDOW_SIZE (default: 5; readings kept per sensor, rolling
+//     window mode only)
+//   - SEVERITY_SUSTAIN_SECONDS (default: 30; how long an excursion must
+//     persist before escalating, rolling window mode only)
 func NewMqttPublisherFromEnv() (*MqttPublisher, error) {
 	broker := getEnv("MQTT_BROKER", "tcp://localhost:1883")
 	clientID := getEnv("MQTT_PUB_CLIENT_ID", "order-event-client")
 	topic := getEnv("MQTT_PUB_TOPIC", "events/order-damage")
 	username := getEnv("MQTT_USERNAME", "")
 	password := getEnv("MQTT_PASSWORD", "")
+	cloudEventsMode := getEnv("CLOUDEVENTS_MODE", cloudEventsModeStructured)
+	if cloudEventsMode != cloudEventsModeStructured {
+		log.Printf("MqttPublisher: CLOUDEVENTS_MODE=%q is not supported over MQTT 3.1.1, falling back to %q", cloudEventsMode, cloudEventsModeStructured)
+		cloudEventsMode = cloudEventsModeStructured
+	}
+
+	classifier, err := classifierFromEnv()
+	if err != nil {
+		return nil, err
+	}
 
 	opts := mqtt.NewClientOptions()
 	opts.AddBroker(broker)
@@ -54,16 +102,43 @@ func NewMqttPublisherFromEnv() (*MqttPublisher, error) {
 		return nil, fmt.Errorf("mqtt connect error: %w", token.Error())
 	}
 
-	return &MqttPublisher{client: client, Topic: topic}, nil
+	return NewMqttPublisher(client, topic, cloudEventsMode, classifier), nil
 }
 
-// PublishOrderDamageFromSensor builds an OrderDamageEvent and publishes it as JSON to MQTT.
-func (p *MqttPublisher) PublishOrderDamageFromSensor(ctx context.Context, sensorID, source string, temperature, humidity float64, status, mqttTopic string) error {
+// PublishOrderDamageFromSensor builds an OrderDamageEvent and publishes it to
+// MQTT as a CloudEvents 1.0 structured-mode envelope (see
+// cloudEventsModeStructured). This is where a damage event's trace begins,
+// so it starts a fresh root span (see tracing.NewTraceParent) and carries
+// its traceparent in the envelope for every downstream consumer to
+// continue.
+//
+// severityOverride and descriptionOverride let a caller - namely the rules
+// engine (see mqtt-order-event-client/rules) - supply the Severity and
+// Description a matched rule produced instead of p.classifier's verdict and
+// the default message. An empty string for either means "use the default",
+// so a caller with no rule engine (or no matching rule) can keep passing ""
+// and get the old behavior unchanged.
+func (p *MqttPublisher) PublishOrderDamageFromSensor(ctx context.Context, sensorID, source string, temperature, humidity float64, status, mqttTopic string, severityOverride, descriptionOverride string) (err error) {
 	if p == nil || p.client == nil {
 		return nil
 	}
 
-	severity := deriveSeverity(temperature, humidity)
+	traceParent := tracing.NewTraceParent()
+	endSpan := tracing.StartSpan("mqtt.publish_order_damage", traceParent, map[string]string{
+		"topic":     p.Topic,
+		"sensor_id": sensorID,
+	})
+	defer endSpan(&err)
+
+	severity := severityOverride
+	if severity == "" {
+		severity = p.classifier.Classify(sensorID, temperature, humidity)
+	}
+	description := descriptionOverride
+	if description == "" {
+		description = fmt.Sprintf("Potential damage detected: temp=%.2fC, humidity=%.2f%%", temperature, humidity)
+	}
+
 	evt := OrderDamageEvent{
 		EventID:     sensorID,
 		Type:        "order.damage",
@@ -71,7 +146,7 @@ func (p *MqttPublisher) PublishOrderDamageFromSensor(ctx context.Context, sensor
 		OccurredAt:  time.Now().UTC(),
 		OrderID:     sensorID,
 		Severity:    severity,
-		Description: fmt.Sprintf("Potential damage detected: temp=%.2fC, humidity=%.2f%%", temperature, humidity),
+		Description: description,
 		Details: DamageDetails{
 			Temperature: temperature,
 			Humidity:    humidity,
@@ -80,7 +155,23 @@ func (p *MqttPublisher) PublishOrderDamageFromSensor(ctx context.Context, sensor
 		},
 	}
 
-	payload, err := json.Marshal(evt)
+	if p.schemas != nil {
+		eventData, marshalErr := json.Marshal(evt)
+		if marshalErr != nil {
+			return marshalErr
+		}
+		if err := p.schemas.Validate(evt.Type, eventData); err != nil {
+			return fmt.Errorf("order damage event failed schema validation: %w", err)
+		}
+	}
+
+	attrs := cloudevents.NewAttributes(evt.Source, cloudevents.Type(evt.Type), evt.OrderID, traceParent)
+	envelope, err := cloudevents.Wrap(attrs, evt)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(envelope)
 	if err != nil {
 		return err
 	}
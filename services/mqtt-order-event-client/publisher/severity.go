@@ -0,0 +1,255 @@
+package publisher
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SeverityClassifier decides how severe a sensor reading is. Cold-chain
+// thresholds vary per product (vaccines vs. reagents vs. dry goods), so
+// MqttPublisher takes one of these via constructor injection instead of
+// hard-coding a single temperature/humidity cutoff.
+type SeverityClassifier interface {
+	// Classify returns "minor", "major", or "critical" for a reading from
+	// sensorID.
+	Classify(sensorID string, temperature, humidity float64) string
+}
+
+// ProductThresholds is one row of a ThresholdTable: the cold-chain bounds
+// for a product, and how far a reading has to drift past TempMax/
+// HumidityMax (or below TempMin) before it escalates from minor to major,
+// then critical.
+type ProductThresholds struct {
+	TempMin     float64 `yaml:"temp_min"`
+	TempMax     float64 `yaml:"temp_max"`
+	HumidityMax float64 `yaml:"humidity_max"`
+	MinorDelta  float64 `yaml:"minor_delta"`
+	MajorDelta  float64 `yaml:"major_delta"`
+}
+
+// ThresholdTable maps a product identifier to its ProductThresholds.
+// OrderDamageEvent has no dedicated product_id field, so sensorID doubles
+// as the lookup key - a deployment with one sensor per product line gets
+// per-product thresholds for free.
+type ThresholdTable map[string]ProductThresholds
+
+// defaultThresholds mirrors the previous hard-coded deriveSeverity cutoffs
+// (temp/humidity >= major at 30C/80%, critical at 40C/90%), used for any
+// sensorID with no entry in the table. Unlike deriveSeverity, TempMin also
+// escalates cold readings: major at or below 0C, critical at or below -10C.
+var defaultThresholds = ProductThresholds{
+	TempMin:     0,
+	TempMax:     30,
+	HumidityMax: 80,
+	MinorDelta:  0,
+	MajorDelta:  10,
+}
+
+// LoadThresholdTable reads a YAML file mapping product_id to
+// ProductThresholds, e.g.:
+//
+//	vaccine-fridge-1:
+//	  temp_min: 2
+//	  temp_max: 8
+//	  humidity_max: 60
+//	  minor_delta: 2
+//	  major_delta: 6
+//
+// A missing file is not an error: callers get an empty table and every
+// sensorID falls back to defaultThresholds.
+func LoadThresholdTable(path string) (ThresholdTable, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ThresholdTable{}, nil
+		}
+		return nil, fmt.Errorf("failed to read severity threshold table %s: %w", path, err)
+	}
+
+	var table ThresholdTable
+	if err := yaml.Unmarshal(raw, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse severity threshold table %s: %w", path, err)
+	}
+	return table, nil
+}
+
+// ThresholdClassifier is the default SeverityClassifier: a per-product
+// threshold table, escalating minor -> major -> critical as a reading
+// drifts further past its ProductThresholds.
+type ThresholdClassifier struct {
+	table ThresholdTable
+}
+
+// NewThresholdClassifier builds a ThresholdClassifier over table. A nil
+// table is fine; every sensorID then uses defaultThresholds.
+func NewThresholdClassifier(table ThresholdTable) *ThresholdClassifier {
+	if table == nil {
+		table = ThresholdTable{}
+	}
+	return &ThresholdClassifier{table: table}
+}
+
+// Classify implements SeverityClassifier.
+func (c *ThresholdClassifier) Classify(sensorID string, temperature, humidity float64) string {
+	t, ok := c.table[sensorID]
+	if !ok {
+		t = defaultThresholds
+	}
+
+	highTempExcursion := temperature - t.TempMax
+	lowTempExcursion := t.TempMin - temperature
+	humidityExcursion := humidity - t.HumidityMax
+
+	switch {
+	case highTempExcursion >= t.MajorDelta || lowTempExcursion >= t.MajorDelta || humidityExcursion >= t.MajorDelta:
+		return "critical"
+	case highTempExcursion >= t.MinorDelta || lowTempExcursion >= t.MinorDelta || humidityExcursion >= t.MinorDelta:
+		return "major"
+	default:
+		return "minor"
+	}
+}
+
+// reading is one classified sample recorded by RollingWindowClassifier for
+// a single sensor.
+type reading struct {
+	at       time.Time
+	severity string
+}
+
+// sensorWindow is the bounded history of readings RollingWindowClassifier
+// keeps for one sensorID.
+type sensorWindow struct {
+	readings []reading
+}
+
+// RollingWindowClassifier wraps a base SeverityClassifier (typically a
+// ThresholdClassifier) and only escalates a sensor past "minor" once its
+// excursion has persisted for sustainFor, instead of reacting to a single,
+// possibly transient, spike.
+type RollingWindowClassifier struct {
+	base       SeverityClassifier
+	windowSize int
+	sustainFor time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*sensorWindow
+}
+
+// NewRollingWindowClassifier wraps base, keeping up to windowSize readings
+// per sensor and escalating to a non-minor severity only once that
+// severity (or worse) has been continuously present for at least
+// sustainFor.
+func NewRollingWindowClassifier(base SeverityClassifier, windowSize int, sustainFor time.Duration) *RollingWindowClassifier {
+	return &RollingWindowClassifier{
+		base:       base,
+		windowSize: windowSize,
+		sustainFor: sustainFor,
+		windows:    make(map[string]*sensorWindow),
+	}
+}
+
+// Classify implements SeverityClassifier. It always records the base
+// classifier's verdict for sensorID, but a "major"/"critical" verdict is
+// only returned once readings at or above that severity have been
+// continuously present for sustainFor; a spike that clears before then
+// still reads as "minor" to callers.
+func (c *RollingWindowClassifier) Classify(sensorID string, temperature, humidity float64) string {
+	verdict := c.base.Classify(sensorID, temperature, humidity)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w, ok := c.windows[sensorID]
+	if !ok {
+		w = &sensorWindow{}
+		c.windows[sensorID] = w
+	}
+
+	w.readings = append(w.readings, reading{at: now, severity: verdict})
+	if len(w.readings) > c.windowSize {
+		w.readings = w.readings[len(w.readings)-c.windowSize:]
+	}
+
+	if verdict == "minor" {
+		return verdict
+	}
+
+	since := now
+	for i := len(w.readings) - 1; i >= 0; i-- {
+		r := w.readings[i]
+		if severityRank(r.severity) < severityRank(verdict) {
+			break
+		}
+		since = r.at
+	}
+
+	if now.Sub(since) < c.sustainFor {
+		return "minor"
+	}
+	return verdict
+}
+
+// severityRank orders severities for RollingWindowClassifier's
+// still-elevated check: higher rank means at least as severe.
+func severityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 2
+	case "major":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// classifierFromEnv builds the SeverityClassifier NewMqttPublisherFromEnv
+// wires into MqttPublisher; see its doc comment for the env vars involved.
+func classifierFromEnv() (SeverityClassifier, error) {
+	var classifier SeverityClassifier
+
+	thresholdsFile := getEnv("SEVERITY_THRESHOLDS_FILE", "")
+	if thresholdsFile == "" {
+		classifier = NewThresholdClassifier(nil)
+	} else {
+		table, err := LoadThresholdTable(thresholdsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load severity threshold table: %w", err)
+		}
+		classifier = NewThresholdClassifier(table)
+	}
+
+	if getEnvBool("SEVERITY_ROLLING_WINDOW", false) {
+		windowSize := getEnvInt("SEVERITY_WINDOW_SIZE", 5)
+		sustainFor := time.Duration(getEnvInt("SEVERITY_SUSTAIN_SECONDS", 30)) * time.Second
+		classifier = NewRollingWindowClassifier(classifier, windowSize, sustainFor)
+	}
+
+	return classifier, nil
+}
+
+// getEnvBool returns environment variable value as a bool or default if not set/invalid.
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvInt returns environment variable value as an int or default if not set/invalid.
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,130 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThresholdClassifier_UsesDefaultThresholdsForUnknownSensor(t *testing.T) {
+	classifier := NewThresholdClassifier(nil)
+
+	cases := []struct {
+		name        string
+		temperature float64
+		humidity    float64
+		want        string
+	}{
+		{name: "within range", temperature: 20, humidity: 50, want: "minor"},
+		{name: "just below major delta", temperature: 39.9, humidity: 50, want: "major"},
+		{name: "at major delta boundary", temperature: 40, humidity: 50, want: "critical"},
+		{name: "humidity at major delta boundary", temperature: 20, humidity: 90, want: "critical"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := classifier.Classify("unknown-sensor", c.temperature, c.humidity); got != c.want {
+				t.Errorf("Classify(%v, %v) = %q, want %q", c.temperature, c.humidity, got, c.want)
+			}
+		})
+	}
+}
+
+func TestThresholdClassifier_UsesPerSensorTableEntry(t *testing.T) {
+	classifier := NewThresholdClassifier(ThresholdTable{
+		"vaccine-fridge-1": {
+			TempMin:     2,
+			TempMax:     8,
+			HumidityMax: 60,
+			MinorDelta:  2,
+			MajorDelta:  6,
+		},
+	})
+
+	cases := []struct {
+		name        string
+		temperature float64
+		want        string
+	}{
+		{name: "within range", temperature: 5, want: "minor"},
+		{name: "at minor delta boundary", temperature: 10, want: "major"},
+		{name: "at major delta boundary", temperature: 14, want: "critical"},
+		{name: "below temp min at major delta boundary", temperature: -4, want: "critical"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := classifier.Classify("vaccine-fridge-1", c.temperature, 50); got != c.want {
+				t.Errorf("Classify(%v) = %q, want %q", c.temperature, got, c.want)
+			}
+		})
+	}
+
+	if got := classifier.Classify("other-sensor", 35, 50); got != "major" {
+		t.Errorf("expected an unmapped sensor to fall back to defaultThresholds, got %q", got)
+	}
+}
+
+func TestRollingWindowClassifier_SuppressesTransientExcursion(t *testing.T) {
+	classifier := NewRollingWindowClassifier(NewThresholdClassifier(nil), 5, 50*time.Millisecond)
+
+	if got := classifier.Classify("sensor-1", 45, 50); got != "minor" {
+		t.Errorf("expected a fresh excursion to read as minor before sustainFor elapses, got %q", got)
+	}
+}
+
+func TestRollingWindowClassifier_EscalatesOnceExcursionPersistsForSustainFor(t *testing.T) {
+	classifier := NewRollingWindowClassifier(NewThresholdClassifier(nil), 5, 20*time.Millisecond)
+
+	if got := classifier.Classify("sensor-1", 45, 50); got != "minor" {
+		t.Errorf("expected the first reading to read as minor, got %q", got)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if got := classifier.Classify("sensor-1", 45, 50); got != "critical" {
+		t.Errorf("expected the excursion to escalate once sustained past sustainFor, got %q", got)
+	}
+}
+
+func TestRollingWindowClassifier_ResetsToMinorOnceExcursionClears(t *testing.T) {
+	classifier := NewRollingWindowClassifier(NewThresholdClassifier(nil), 5, 20*time.Millisecond)
+
+	classifier.Classify("sensor-1", 45, 50)
+	time.Sleep(30 * time.Millisecond)
+	if got := classifier.Classify("sensor-1", 45, 50); got != "critical" {
+		t.Fatalf("expected the excursion to have escalated, got %q", got)
+	}
+
+	if got := classifier.Classify("sensor-1", 20, 50); got != "minor" {
+		t.Errorf("expected a reading back within range to read as minor, got %q", got)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if got := classifier.Classify("sensor-1", 45, 50); got != "minor" {
+		t.Errorf("expected the cleared excursion to restart the sustain clock, got %q", got)
+	}
+}
+
+func TestRollingWindowClassifier_TracksEachSensorIndependently(t *testing.T) {
+	classifier := NewRollingWindowClassifier(NewThresholdClassifier(nil), 5, 20*time.Millisecond)
+
+	classifier.Classify("sensor-1", 45, 50)
+	time.Sleep(30 * time.Millisecond)
+	if got := classifier.Classify("sensor-1", 45, 50); got != "critical" {
+		t.Fatalf("expected sensor-1's excursion to have escalated, got %q", got)
+	}
+
+	if got := classifier.Classify("sensor-2", 45, 50); got != "minor" {
+		t.Errorf("expected sensor-2's first reading to read as minor despite sensor-1's state, got %q", got)
+	}
+}
+
+func TestSeverityRank_OrdersBySeverity(t *testing.T) {
+	if severityRank("critical") <= severityRank("major") {
+		t.Error("expected critical to outrank major")
+	}
+	if severityRank("major") <= severityRank("minor") {
+		t.Error("expected major to outrank minor")
+	}
+	if severityRank("minor") != severityRank("unknown") {
+		t.Error("expected an unrecognized severity to rank the same as minor")
+	}
+}
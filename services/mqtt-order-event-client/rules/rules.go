@@ -0,0 +1,398 @@
+// Package rules replaces main.go's single hard-coded minTemperature check
+// with a configurable rule engine loaded from RULES_FILE. Where
+// publisher.SeverityClassifier (see publisher/severity.go) decides how
+// severe an already-triggered reading is, a Rule decides whether a damage
+// event should fire at all - the two compose: a rule that fires can also
+// override the severity/description a classifier would otherwise produce.
+package rules
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/Knetic/govaluate"
+	"gopkg.in/yaml.v3"
+
+	"mqtt-order-event-client/cluster"
+)
+
+// maxTrackedSources bounds how many distinct event sources a Rule's
+// pending/fired windows track at once, evicted FIFO by insertion order once
+// exceeded - mirroring MemoryProcessedEventLedger's maxEntries bound,
+// without which a rule would grow one entry per sensor ID it has ever seen
+// for the lifetime of the process.
+const maxTrackedSources = 10000
+
+// Spec is one rule as loaded from RULES_FILE, e.g.:
+//
+//   - id: freezer-excursion
+//     expression: "temperature < 10 || humidity > 85"
+//     severity: major
+//     description: "Excursion on {{.ID}}: temp={{.Temperature}}C humidity={{.Humidity}}%"
+//     debounce: 30s
+//     cooldown: 5m
+//
+// Expression is evaluated against an event's temperature, humidity, status,
+// type, source and id fields. Debounce and cooldown are optional Go
+// duration strings (e.g. "30s"); see Rule for what they do.
+type Spec struct {
+	ID          string `yaml:"id" json:"id"`
+	Expression  string `yaml:"expression" json:"expression"`
+	Severity    string `yaml:"severity" json:"severity"`
+	Description string `yaml:"description" json:"description"`
+	Debounce    string `yaml:"debounce" json:"debounce"`
+	Cooldown    string `yaml:"cooldown" json:"cooldown"`
+}
+
+// Rule is a Spec compiled once at load time: its expression is parsed and
+// its description parsed as a text/template, so Evaluate never re-parses
+// either per event.
+type Rule struct {
+	ID          string
+	Expression  string
+	Severity    string
+	Description string
+	Debounce    time.Duration
+	Cooldown    time.Duration
+
+	expr *govaluate.EvaluableExpression
+	tmpl *template.Template
+	hits uint64
+
+	mu      sync.Mutex
+	pending *sourceWindow // event.Source -> when the expression first started matching, for Debounce
+	fired   *sourceWindow // event.Source -> when the rule last fired, for Cooldown
+}
+
+// sourceWindow is a FIFO-bounded map[string]time.Time keyed by event.Source,
+// used for both a Rule's pending (Debounce) and fired (Cooldown) state.
+// Bounding it means a rule seeing an unbounded number of distinct sensor IDs
+// over the process's lifetime can't grow its tracked state without limit.
+type sourceWindow struct {
+	entries map[string]*list.Element
+	order   *list.List // front = oldest insertion, back = newest
+	maxSize int
+}
+
+type sourceWindowEntry struct {
+	source string
+	at     time.Time
+}
+
+func newSourceWindow(maxSize int) *sourceWindow {
+	return &sourceWindow{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		maxSize: maxSize,
+	}
+}
+
+// get returns the time last set for source, if any.
+func (w *sourceWindow) get(source string) (time.Time, bool) {
+	elem, ok := w.entries[source]
+	if !ok {
+		return time.Time{}, false
+	}
+	return elem.Value.(*sourceWindowEntry).at, true
+}
+
+// set records at for source, evicting the oldest tracked source if this
+// insertion would push the window past maxSize. Updating an existing
+// source's time does not change its eviction order.
+func (w *sourceWindow) set(source string, at time.Time) {
+	if elem, ok := w.entries[source]; ok {
+		elem.Value.(*sourceWindowEntry).at = at
+		return
+	}
+
+	elem := w.order.PushBack(&sourceWindowEntry{source: source, at: at})
+	w.entries[source] = elem
+
+	for len(w.entries) > w.maxSize {
+		front := w.order.Front()
+		if front == nil {
+			break
+		}
+		w.order.Remove(front)
+		delete(w.entries, front.Value.(*sourceWindowEntry).source)
+	}
+}
+
+// delete removes source's tracked time, if any.
+func (w *sourceWindow) delete(source string) {
+	elem, ok := w.entries[source]
+	if !ok {
+		return
+	}
+	w.order.Remove(elem)
+	delete(w.entries, source)
+}
+
+// Match is what Evaluate returns for a Rule that fired for an event.
+type Match struct {
+	RuleID      string
+	Severity    string
+	Description string
+}
+
+// templateData is what a Description template renders against, e.g.
+// "{{.Temperature}}". Field names are capitalized to match text/template
+// convention, unlike the lowercase identifiers an Expression uses.
+type templateData struct {
+	ID          string
+	Temperature float64
+	Humidity    float64
+	Status      string
+	Type        string
+	Source      string
+}
+
+// compile parses spec's expression and description template once, so a
+// bad rule fails at load time (Engine.Reload) rather than on the first
+// matching event.
+func compile(spec Spec) (*Rule, error) {
+	expr, err := govaluate.NewEvaluableExpression(spec.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: invalid expression %q: %w", spec.ID, spec.Expression, err)
+	}
+
+	tmpl, err := template.New(spec.ID).Parse(spec.Description)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: invalid description template: %w", spec.ID, err)
+	}
+
+	debounce, err := parseDuration(spec.Debounce)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: invalid debounce %q: %w", spec.ID, spec.Debounce, err)
+	}
+	cooldown, err := parseDuration(spec.Cooldown)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: invalid cooldown %q: %w", spec.ID, spec.Cooldown, err)
+	}
+
+	return &Rule{
+		ID:          spec.ID,
+		Expression:  spec.Expression,
+		Severity:    spec.Severity,
+		Description: spec.Description,
+		Debounce:    debounce,
+		Cooldown:    cooldown,
+		expr:        expr,
+		tmpl:        tmpl,
+		pending:     newSourceWindow(maxTrackedSources),
+		fired:       newSourceWindow(maxTrackedSources),
+	}, nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// evaluate checks r's expression against event, applying the Debounce and
+// Cooldown windows keyed by event.Source - the stable per-sensor identifier,
+// unlike event.ID, which mqtt-event-generator mints fresh for every message
+// and so never repeats: Debounce requires the expression to still be
+// matching event.Source the next time it's seen at least Debounce later
+// before the rule fires; Cooldown then suppresses firing again for
+// event.Source until Cooldown has passed since the last time it fired.
+func (r *Rule) evaluate(event cluster.Event, now time.Time) (*Match, error) {
+	params := map[string]interface{}{
+		"temperature": event.Data.Temperature,
+		"humidity":    event.Data.Humidity,
+		"status":      event.Data.Status,
+		"type":        event.Type,
+		"source":      event.Source,
+		"id":          event.ID,
+	}
+
+	result, err := r.expr.Evaluate(params)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: failed to evaluate expression: %w", r.ID, err)
+	}
+	matched, ok := result.(bool)
+	if !ok {
+		return nil, fmt.Errorf("rule %q: expression must evaluate to a bool, got %T", r.ID, result)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !matched {
+		r.pending.delete(event.Source)
+		return nil, nil
+	}
+
+	if r.Debounce > 0 {
+		since, ok := r.pending.get(event.Source)
+		if !ok {
+			r.pending.set(event.Source, now)
+			return nil, nil
+		}
+		if now.Sub(since) < r.Debounce {
+			return nil, nil
+		}
+	}
+
+	if r.Cooldown > 0 {
+		if last, ok := r.fired.get(event.Source); ok && now.Sub(last) < r.Cooldown {
+			return nil, nil
+		}
+	}
+
+	r.fired.set(event.Source, now)
+	r.pending.delete(event.Source)
+	atomic.AddUint64(&r.hits, 1)
+
+	var buf bytes.Buffer
+	if err := r.tmpl.Execute(&buf, templateData{
+		ID:          event.ID,
+		Temperature: event.Data.Temperature,
+		Humidity:    event.Data.Humidity,
+		Status:      event.Data.Status,
+		Type:        event.Type,
+		Source:      event.Source,
+	}); err != nil {
+		return nil, fmt.Errorf("rule %q: failed to render description: %w", r.ID, err)
+	}
+
+	return &Match{RuleID: r.ID, Severity: r.Severity, Description: buf.String()}, nil
+}
+
+// Hits returns how many times r has fired since it was loaded.
+func (r *Rule) Hits() uint64 {
+	return atomic.LoadUint64(&r.hits)
+}
+
+// RuleStatus is a loaded rule's configuration plus its current hit
+// counter, for the GET /rules and /events/stats HTTP endpoints.
+type RuleStatus struct {
+	ID          string `json:"id"`
+	Expression  string `json:"expression"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	Debounce    string `json:"debounce,omitempty"`
+	Cooldown    string `json:"cooldown,omitempty"`
+	Hits        uint64 `json:"hits"`
+}
+
+// Engine evaluates every loaded Rule against incoming events. It's
+// reloadable at runtime via Reload, so operators can tune thresholds by
+// editing RULES_FILE and calling POST /rules/reload instead of
+// redeploying.
+type Engine struct {
+	path string
+
+	mu    sync.RWMutex
+	rules []*Rule
+}
+
+// NewEngine loads rules from path and returns an Engine ready to Evaluate
+// events. An empty or missing path is not an error: the Engine starts with
+// no rules, so Evaluate never matches, same as before this package existed.
+func NewEngine(path string) (*Engine, error) {
+	e := &Engine{path: path}
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload re-reads path from disk and compiles its rules fresh, atomically
+// swapping them in. Hit counters and any in-flight debounce/cooldown state
+// reset, since a reload may have changed what a rule ID even means.
+func (e *Engine) Reload() error {
+	specs, err := loadSpecs(e.path)
+	if err != nil {
+		return err
+	}
+
+	compiled := make([]*Rule, 0, len(specs))
+	for _, spec := range specs {
+		rule, err := compile(spec)
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, rule)
+	}
+
+	e.mu.Lock()
+	e.rules = compiled
+	e.mu.Unlock()
+	return nil
+}
+
+// Evaluate runs every loaded rule against event and returns a Match for
+// each one that fires, in rule order. Most rule files are written to match
+// at most one rule per event, but nothing here enforces that.
+func (e *Engine) Evaluate(event cluster.Event) ([]Match, error) {
+	now := time.Now()
+
+	e.mu.RLock()
+	rules := e.rules
+	e.mu.RUnlock()
+
+	var matches []Match
+	for _, rule := range rules {
+		match, err := rule.evaluate(event, now)
+		if err != nil {
+			return matches, err
+		}
+		if match != nil {
+			matches = append(matches, *match)
+		}
+	}
+	return matches, nil
+}
+
+// Rules returns every currently loaded rule's status, for GET /rules and
+// GET /events/stats.
+func (e *Engine) Rules() []RuleStatus {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	statuses := make([]RuleStatus, 0, len(e.rules))
+	for _, rule := range e.rules {
+		statuses = append(statuses, RuleStatus{
+			ID:          rule.ID,
+			Expression:  rule.Expression,
+			Severity:    rule.Severity,
+			Description: rule.Description,
+			Debounce:    rule.Debounce.String(),
+			Cooldown:    rule.Cooldown.String(),
+			Hits:        rule.Hits(),
+		})
+	}
+	return statuses
+}
+
+// loadSpecs reads path, a YAML (or JSON, which parses fine as YAML) list of
+// Spec. A missing file is not an error: callers get zero rules, same as
+// RULES_FILE being unset.
+func loadSpecs(path string) ([]Spec, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read rules file %s: %w", path, err)
+	}
+
+	var specs []Spec
+	if err := yaml.Unmarshal(raw, &specs); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file %s: %w", path, err)
+	}
+	return specs, nil
+}
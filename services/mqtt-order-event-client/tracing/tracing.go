@@ -0,0 +1,56 @@
+// Package tracing originates and carries a W3C traceparent for events this
+// service publishes. There is no OpenTelemetry SDK (or any tracing library)
+// vendored in this repo, so StartSpan is a logging-based stand-in that
+// records the same span lifecycle (start, attributes, duration, error) a
+// real OTel span would - swapping in a real tracer later only means
+// rewriting this one file. Unlike a downstream consumer, this service is
+// where a damage-event trace begins, so NewTraceParent generates the root
+// traceparent instead of only propagating one from an incoming context.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+)
+
+// NewTraceParent generates a fresh root W3C traceparent
+// ("00-<trace-id>-<parent-id>-01"), for a span that has no inbound trace
+// context to continue - e.g. the first time a sensor reading turns into an
+// OrderDamageEvent.
+func NewTraceParent() string {
+	traceID := randomHex(16)
+	spanID := randomHex(8)
+	return "00-" + traceID + "-" + spanID + "-01"
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand.Read on the standard reader doesn't fail in practice;
+		// an all-zero id is a safe, harmless fallback if it ever does.
+		return hex.EncodeToString(make([]byte, n))
+	}
+	return hex.EncodeToString(b)
+}
+
+// StartSpan logs the start of an operation named name, carrying attrs (e.g.
+// sensor id, topic) and traceParent. It returns a function the caller must
+// invoke with the operation's outcome (nil on success) when it completes;
+// that call logs the duration and status, standing in for a real
+// OpenTelemetry span's attributes and end time.
+func StartSpan(name, traceParent string, attrs map[string]string) func(err *error) {
+	start := time.Now()
+
+	return func(err *error) {
+		duration := time.Since(start)
+		status := "ok"
+		var errMsg any = ""
+		if err != nil && *err != nil {
+			status = "error"
+			errMsg = *err
+		}
+		log.Printf("span: %s traceparent=%q attrs=%v duration=%s status=%s err=%v", name, traceParent, attrs, duration, status, errMsg)
+	}
+}
@@ -1,212 +1,256 @@
-package application
-
-import (
-	"fmt"
-	"log"
-	"time"
-
-	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
-	"github.com/google/uuid"
-)
-
-// OrderService handles business logic for orders
-type OrderService struct {
-	orderRepo      domain.OrderRepository
-	eventPublisher domain.OrderEventPublisher
-}
-
-// NewOrderService creates a new OrderService
-func NewOrderService(orderRepo domain.OrderRepository, eventPublisher domain.OrderEventPublisher) *OrderService {
-	return &OrderService{
-		orderRepo:      orderRepo,
-		eventPublisher: eventPublisher,
-	}
-}
-
-// CreateOrder creates a new order and publishes an event
-func (s *OrderService) CreateOrder(customerID, productID string, quantity int, totalAmount float64) (*domain.Order, error) {
-	// Create new order
-	order := domain.Order{
-		ID:          uuid.New().String(),
-		CustomerID:  customerID,
-		ProductID:   productID,
-		Quantity:    quantity,
-		Status:      "created",
-		TotalAmount: totalAmount,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
-
-	// Save order
-	if err := s.orderRepo.Save(order); err != nil {
-		return nil, fmt.Errorf("failed to save order: %w", err)
-	}
-
-	// Publish order created event
-	event := domain.OrderEvent{
-		EventType: "order.created",
-		OrderID:   order.ID,
-		Order:     order,
-		Timestamp: time.Now(),
-	}
-
-	if err := s.eventPublisher.PublishOrderEvent(event); err != nil {
-		log.Printf("Failed to publish order created event: %v", err)
-		// Note: In a real system, you might want to implement compensation logic
-	}
-
-	log.Printf("Order created successfully: ID=%s, CustomerID=%s, ProductID=%s", 
-		order.ID, order.CustomerID, order.ProductID)
-
-	return &order, nil
-}
-
-// GetOrder retrieves an order by ID
-func (s *OrderService) GetOrder(id string) (*domain.Order, error) {
-	return s.orderRepo.FindByID(id)
-}
-
-// GetAllOrders retrieves all orders
-func (s *OrderService) GetAllOrders() ([]domain.Order, error) {
-	return s.orderRepo.FindAll()
-}
-
-// UpdateOrderStatus updates the status of an order
-func (s *OrderService) UpdateOrderStatus(id, status string) (*domain.Order, error) {
-	order, err := s.orderRepo.FindByID(id)
-	if err != nil {
-		return nil, fmt.Errorf("order not found: %w", err)
-	}
-
-	order.Status = status
-	order.UpdatedAt = time.Now()
-
-	if err := s.orderRepo.Update(*order); err != nil {
-		return nil, fmt.Errorf("failed to update order: %w", err)
-	}
-
-	// Publish order updated event
-	event := domain.OrderEvent{
-		EventType: "order.updated",
-		OrderID:   order.ID,
-		Order:     *order,
-		Timestamp: time.Now(),
-	}
-
-	if err := s.eventPublisher.PublishOrderEvent(event); err != nil {
-		log.Printf("Failed to publish order updated event: %v", err)
-	}
-
-	log.Printf("Order status updated: ID=%s, Status=%s", order.ID, order.Status)
-
-	return order, nil
-}
-
-// HandleOrderEvent processes incoming order events
-func (s *OrderService) HandleOrderEvent(event domain.OrderEvent) error {
-	log.Printf("Processing order event: Type=%s, OrderID=%s, Timestamp=%s", 
-		event.EventType, event.OrderID, event.Timestamp.Format("2006-01-02 15:04:05"))
-	
-	// Business logic for processing different event types
-	switch event.EventType {
-	case "order.created":
-		log.Printf("Order created event processed: %s", event.OrderID)
-	case "order.updated":
-		log.Printf("Order updated event processed: %s", event.OrderID)
-	case "order.cancelled":
-		log.Printf("Order cancelled event processed: %s", event.OrderID)
-	default:
-		log.Printf("Unknown event type: %s", event.EventType)
-	}
-	
-	return nil
-}
-
-// HandleOrderDamageEvent processes incoming order damage events from MQTT
-func (s *OrderService) HandleOrderDamageEvent(event domain.OrderDamageEvent) error {
-	log.Printf("Processing order damage event: EventID=%s, OrderID=%s, Severity=%s, OccurredAt=%s", 
-		event.EventID, event.OrderID, event.Severity, event.OccurredAt.Format("2006-01-02 15:04:05"))
-	
-	log.Printf("Damage details: Temperature=%.2fÂ°C, Humidity=%d%%, Status=%s", 
-		event.Details.Temperature, event.Details.Humidity, event.Details.Status)
-	
-	log.Printf("Damage description: %s", event.Description)
-	
-	// Check if order exists, if not create a new one
-	order, err := s.orderRepo.FindByID(event.OrderID)
-	if err != nil {
-		log.Printf("Order %s not found, creating new order from damage event", event.OrderID)
-		
-		// Create new order with the received order ID
-		newOrder := domain.Order{
-			ID:          event.OrderID,
-			CustomerID:  "unknown", // Default value since not provided in damage event
-			ProductID:   "unknown", // Default value since not provided in damage event
-			Quantity:    1,         // Default value
-			Status:      "created_from_damage_event",
-			TotalAmount: 0.0,       // Default value
-			CreatedAt:   event.OccurredAt,
-			UpdatedAt:   time.Now(),
-		}
-		
-		// Save the new order
-		if err := s.orderRepo.Save(newOrder); err != nil {
-			return fmt.Errorf("failed to create order from damage event: %w", err)
-		}
-		
-		log.Printf("Created new order from damage event: ID=%s", newOrder.ID)
-		order = &newOrder
-	} else {
-		log.Printf("Found existing order %s", event.OrderID)
-	}
-	
-	// Determine the new status based on damage severity
-	var newStatus string
-	switch event.Severity {
-	case "minor":
-		log.Printf("Minor damage detected for order %s - monitoring required", event.OrderID)
-		newStatus = "damage_detected_minor"
-		
-	case "major":
-		log.Printf("Major damage detected for order %s - immediate action required", event.OrderID)
-		newStatus = "damage_detected_major"
-		
-	case "critical":
-		log.Printf("Critical damage detected for order %s - order should be cancelled", event.OrderID)
-		newStatus = "cancelled_damage"
-		
-	default:
-		log.Printf("Unknown damage severity: %s for order %s", event.Severity, event.OrderID)
-		newStatus = "damage_detected_unknown"
-	}
-	
-	// Update order status
-	order.Status = newStatus
-	order.UpdatedAt = time.Now()
-	
-	if err := s.orderRepo.Update(*order); err != nil {
-		return fmt.Errorf("failed to update order status after damage event: %w", err)
-	}
-	
-	// Publish order updated event
-	orderEvent := domain.OrderEvent{
-		EventType: "order.damage_processed",
-		OrderID:   order.ID,
-		Order:     *order,
-		Timestamp: time.Now(),
-	}
-	
-	if err := s.eventPublisher.PublishOrderEvent(orderEvent); err != nil {
-		log.Printf("Failed to publish order damage processed event: %v", err)
-	}
-	
-	log.Printf("Order %s status updated to: %s", order.ID, order.Status)
-	
-	// Additional business logic could include:
-	// - Sending notifications to warehouse staff
-	// - Creating damage reports
-	// - Triggering insurance claims
-	// - Updating inventory status
-	
-	return nil
-}
\ No newline at end of file
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/pkg/logging"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/pkg/retry"
+	"github.com/google/uuid"
+)
+
+// publishRetryPolicy governs retries of PublishOrderEvent: a handful of
+// quick attempts with exponential backoff, since a broker blip that outlasts
+// this is better handled by the outbox/DLQ paths than by blocking the caller.
+var publishRetryPolicy = retry.Policy{
+	MaxAttempts:  3,
+	InitialDelay: 200 * time.Millisecond,
+	MaxDelay:     2 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+// OrderService handles business logic for orders
+type OrderService struct {
+	orderRepo      domain.OrderRepository
+	eventPublisher domain.OrderEventPublisher
+}
+
+// NewOrderService creates a new OrderService
+func NewOrderService(orderRepo domain.OrderRepository, eventPublisher domain.OrderEventPublisher) *OrderService {
+	return &OrderService{
+		orderRepo:      orderRepo,
+		eventPublisher: eventPublisher,
+	}
+}
+
+// publishWithRetry publishes event, retrying transient failures according to
+// publishRetryPolicy before giving up. If event has no TraceParent of its
+// own, ctx's request id (see pkg/logging) is attached so the published
+// event can still be correlated back to whatever triggered it.
+func (s *OrderService) publishWithRetry(ctx context.Context, event domain.OrderEvent) error {
+	if event.TraceParent == "" {
+		event.TraceParent = logging.RequestIDFromContext(ctx)
+	}
+	return retry.Do(ctx, func() error {
+		return s.eventPublisher.PublishOrderEvent(ctx, event)
+	}, publishRetryPolicy)
+}
+
+// CreateOrder creates a new order and publishes an event. ctx carries the
+// triggering HTTP request's correlation id (see pkg/logging), propagated
+// onto the published event's TraceParent and every log line below.
+func (s *OrderService) CreateOrder(ctx context.Context, customerID, productID string, quantity int, totalAmount float64) (*domain.Order, error) {
+	logger := logging.FromContext(ctx)
+
+	// Create new order
+	order := domain.Order{
+		ID:          uuid.New().String(),
+		CustomerID:  customerID,
+		ProductID:   productID,
+		Quantity:    quantity,
+		Status:      "created",
+		TotalAmount: totalAmount,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	// Publish order created event
+	event := domain.OrderEvent{
+		EventType:   "order.created",
+		OrderID:     order.ID,
+		Order:       order,
+		Timestamp:   time.Now(),
+		TraceParent: logging.RequestIDFromContext(ctx),
+	}
+
+	// Prefer the transactional outbox path when the repository supports it,
+	// so the DB write and the pending event can never diverge even if the
+	// publish fails or is delayed; the OutboxDispatcher delivers it later.
+	if txRepo, ok := s.orderRepo.(domain.TransactionalOrderRepository); ok {
+		if err := txRepo.SaveWithOutboxEvent(order, event); err != nil {
+			return nil, fmt.Errorf("failed to save order with outbox event: %w", err)
+		}
+	} else {
+		if err := s.orderRepo.Save(order); err != nil {
+			return nil, fmt.Errorf("failed to save order: %w", err)
+		}
+
+		if err := s.publishWithRetry(ctx, event); err != nil {
+			logger.Error("failed to publish order created event after retries", "error", err)
+			// Note: In a real system, you might want to implement compensation logic
+		}
+	}
+
+	logger.Info("order created successfully", "order_id", order.ID, "customer_id", order.CustomerID, "product_id", order.ProductID)
+
+	return &order, nil
+}
+
+// GetOrder retrieves an order by ID
+func (s *OrderService) GetOrder(id string) (*domain.Order, error) {
+	return s.orderRepo.FindByID(id)
+}
+
+// GetAllOrders retrieves all orders
+func (s *OrderService) GetAllOrders() ([]domain.Order, error) {
+	return s.orderRepo.FindAll()
+}
+
+// UpdateOrderStatus updates the status of an order. ctx carries the
+// triggering HTTP request's correlation id (see pkg/logging).
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, id, status string) (*domain.Order, error) {
+	logger := logging.FromContext(ctx)
+
+	order, err := s.orderRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+
+	order.Status = status
+	order.UpdatedAt = time.Now()
+
+	if err := s.orderRepo.Update(*order); err != nil {
+		return nil, fmt.Errorf("failed to update order: %w", err)
+	}
+
+	// Publish order updated event
+	event := domain.OrderEvent{
+		EventType: "order.updated",
+		OrderID:   order.ID,
+		Order:     *order,
+		Timestamp: time.Now(),
+	}
+
+	if err := s.publishWithRetry(ctx, event); err != nil {
+		logger.Error("failed to publish order updated event after retries", "error", err)
+	}
+
+	logger.Info("order status updated", "order_id", order.ID, "status", order.Status)
+
+	return order, nil
+}
+
+// HandleOrderEvent processes incoming order events. ctx carries the
+// correlation id extracted from the consumed message's traceparent (see
+// pkg/logging), so processing can be traced back to whatever originally
+// published the event.
+func (s *OrderService) HandleOrderEvent(ctx context.Context, event domain.OrderEvent) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("processing order event", "event_type", event.EventType, "order_id", event.OrderID, "timestamp", event.Timestamp)
+
+	// Business logic for processing different event types
+	switch event.EventType {
+	case "order.created":
+		logger.Info("order created event processed", "order_id", event.OrderID)
+	case "order.updated":
+		logger.Info("order updated event processed", "order_id", event.OrderID)
+	case "order.cancelled":
+		logger.Info("order cancelled event processed", "order_id", event.OrderID)
+	default:
+		logger.Warn("unknown event type", "event_type", event.EventType)
+	}
+
+	return nil
+}
+
+// HandleOrderDamageEvent processes incoming order damage events from MQTT.
+// ctx carries the correlation id extracted from the event's traceparent.
+func (s *OrderService) HandleOrderDamageEvent(ctx context.Context, event domain.OrderDamageEvent) error {
+	logger := logging.FromContext(ctx)
+
+	logger.Info("processing order damage event", "event_id", event.EventID, "order_id", event.OrderID, "severity", event.Severity, "occurred_at", event.OccurredAt)
+	logger.Info("damage details", "temperature", event.Details.Temperature, "humidity", event.Details.Humidity, "status", event.Details.Status)
+	logger.Info("damage description", "description", event.Description)
+
+	// Check if order exists, if not create a new one
+	order, err := s.orderRepo.FindByID(event.OrderID)
+	if err != nil {
+		logger.Info("order not found, creating new order from damage event", "order_id", event.OrderID)
+
+		// Create new order with the received order ID
+		newOrder := domain.Order{
+			ID:          event.OrderID,
+			CustomerID:  "unknown", // Default value since not provided in damage event
+			ProductID:   "unknown", // Default value since not provided in damage event
+			Quantity:    1,         // Default value
+			Status:      "created_from_damage_event",
+			TotalAmount: 0.0,       // Default value
+			CreatedAt:   event.OccurredAt,
+			UpdatedAt:   time.Now(),
+		}
+
+		// Save the new order
+		if err := s.orderRepo.Save(newOrder); err != nil {
+			return fmt.Errorf("failed to create order from damage event: %w", err)
+		}
+
+		logger.Info("created new order from damage event", "order_id", newOrder.ID)
+		order = &newOrder
+	} else {
+		logger.Info("found existing order", "order_id", event.OrderID)
+	}
+
+	// Determine the new status based on damage severity
+	var newStatus string
+	switch event.Severity {
+	case "minor":
+		logger.Info("minor damage detected - monitoring required", "order_id", event.OrderID)
+		newStatus = "damage_detected_minor"
+
+	case "major":
+		logger.Warn("major damage detected - immediate action required", "order_id", event.OrderID)
+		newStatus = "damage_detected_major"
+
+	case "critical":
+		logger.Error("critical damage detected - order should be cancelled", "order_id", event.OrderID)
+		newStatus = "cancelled_damage"
+
+	default:
+		logger.Warn("unknown damage severity", "severity", event.Severity, "order_id", event.OrderID)
+		newStatus = "damage_detected_unknown"
+	}
+
+	// Update order status
+	order.Status = newStatus
+	order.UpdatedAt = time.Now()
+
+	if err := s.orderRepo.Update(*order); err != nil {
+		return fmt.Errorf("failed to update order status after damage event: %w", err)
+	}
+
+	// Publish order updated event
+	orderEvent := domain.OrderEvent{
+		EventType:   "order.damage_processed",
+		OrderID:     order.ID,
+		Order:       *order,
+		Timestamp:   time.Now(),
+		TraceParent: event.TraceParent,
+	}
+
+	if err := s.publishWithRetry(ctx, orderEvent); err != nil {
+		logger.Error("failed to publish order damage processed event after retries", "error", err)
+	}
+
+	logger.Info("order status updated", "order_id", order.ID, "status", order.Status)
+
+	// Additional business logic could include:
+	// - Sending notifications to warehouse staff
+	// - Creating damage reports
+	// - Triggering insurance claims
+	// - Updating inventory status
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord is the stored outcome of a request that carried an
+// Idempotency-Key header: its fingerprint (so a retry under the same key
+// with a genuinely different request can be rejected) and the response to
+// replay verbatim on every subsequent retry.
+type IdempotencyRecord struct {
+	Fingerprint  string
+	StatusCode   int
+	ResponseBody []byte
+	CreatedAt    time.Time
+}
+
+// IdempotencyStore records the outcome of a request keyed by its
+// Idempotency-Key header, so ApiServiceAdapter can replay the original
+// response on a retry instead of re-running a handler like CreateOrder a
+// second time. This is what keeps a client retrying POST /api/v1/orders
+// after a network failure from creating a duplicate order.
+type IdempotencyStore interface {
+	// Get returns the record stored for key, if any, and false if it was
+	// never stored or has since expired.
+	Get(key string) (IdempotencyRecord, bool, error)
+	// Put stores record for key, to be returned by Get until it expires.
+	Put(key string, record IdempotencyRecord) error
+	// WithLock runs fn while holding an exclusive lock scoped to key, so
+	// two concurrent requests carrying the same Idempotency-Key can't both
+	// observe a Get miss and run the handler behind fn a second time - the
+	// check-then-act race that would otherwise let a client's retried POST
+	// create two orders instead of replaying the first one's response. A
+	// multi-replica implementation would take this lock via something like
+	// Redis SETNX; MemoryIdempotencyStore uses an in-process per-key mutex.
+	WithLock(key string, fn func() error) error
+}
@@ -0,0 +1,15 @@
+package domain
+
+// ProcessedEventLedger records which inbound events have already been
+// handled, so a broker redelivery (RabbitMQ requeue after a crash, or a
+// consumer restart before the original ack landed) can be detected and the
+// handler short-circuited instead of reapplying its side effects.
+type ProcessedEventLedger interface {
+	// SeenBefore reports whether eventID was already recorded by a prior
+	// MarkProcessed call.
+	SeenBefore(eventID string) (bool, error)
+	// MarkProcessed records eventID as processed, along with a short
+	// human-readable result (e.g. "ok", or an error summary) kept for
+	// diagnostics.
+	MarkProcessed(eventID, result string) error
+}
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"context"
+	"fmt"
 	"time"
 )
 
@@ -22,6 +24,25 @@ type OrderEvent struct {
 	OrderID   string    `json:"order_id"`
 	Order     Order     `json:"order"`
 	Timestamp time.Time `json:"timestamp"`
+	// TraceParent carries the W3C traceparent of the request/event that
+	// triggered this one, so publishers can propagate it onto the
+	// CloudEvents envelope. Empty when no incoming trace context is known.
+	TraceParent string `json:"traceparent,omitempty"`
+	// EventID is an explicit, producer-assigned identifier for this event,
+	// when set. Used as the ProcessedEventLedger key in preference to the
+	// derived key from LedgerKey.
+	EventID string `json:"event_id,omitempty"`
+}
+
+// LedgerKey returns the identifier a ProcessedEventLedger should use to
+// detect a redelivered copy of this event: the explicit EventID when the
+// producer set one, otherwise a key derived from (EventType, OrderID,
+// Timestamp).
+func (oe OrderEvent) LedgerKey() string {
+	if oe.EventID != "" {
+		return oe.EventID
+	}
+	return fmt.Sprintf("%s:%s:%d", oe.EventType, oe.OrderID, oe.Timestamp.UnixNano())
 }
 
 // OrderDamageEvent represents an order damage event from MQTT
@@ -34,6 +55,17 @@ type OrderDamageEvent struct {
 	Severity    string                 `json:"severity"`
 	Description string                 `json:"description"`
 	Details     OrderDamageDetails     `json:"details"`
+	// TraceParent carries the W3C traceparent of the span that produced
+	// this event (see mqtt-order-event-client/tracing), so the order
+	// service can continue the same trace. Empty when none was supplied.
+	TraceParent string `json:"traceparent,omitempty"`
+}
+
+// LedgerKey returns the identifier a ProcessedEventLedger should use to
+// detect a redelivered copy of this event: OrderDamageEvent always carries
+// an explicit EventID from its source.
+func (oe OrderDamageEvent) LedgerKey() string {
+	return oe.EventID
 }
 
 // OrderDamageDetails contains the sensor data that triggered the damage event
@@ -51,10 +83,13 @@ type MQTTOrderEvent struct {
 	Timestamp float64 `json:"timestamp"`
 }
 
-// OrderEventHandler defines the contract for handling order events
+// OrderEventHandler defines the contract for handling order events. ctx
+// carries the triggering request/event's correlation id (see pkg/logging),
+// propagated from the HTTP request that created the order or extracted from
+// the consumed message's traceparent.
 type OrderEventHandler interface {
-	HandleOrderEvent(event OrderEvent) error
-	HandleOrderDamageEvent(event OrderDamageEvent) error
+	HandleOrderEvent(ctx context.Context, event OrderEvent) error
+	HandleOrderDamageEvent(ctx context.Context, event OrderDamageEvent) error
 }
 
 // OrderRepository defines the contract for order persistence
@@ -68,5 +103,14 @@ type OrderRepository interface {
 
 // OrderEventPublisher defines the contract for publishing order events
 type OrderEventPublisher interface {
-	PublishOrderEvent(event OrderEvent) error
+	PublishOrderEvent(ctx context.Context, event OrderEvent) error
+}
+
+// TransactionalOrderRepository is implemented by repositories that can
+// persist an order and its triggering event atomically (e.g. via a
+// transactional outbox table), so a later publish failure can never
+// diverge from what was saved. OrderService prefers this path when the
+// configured repository supports it.
+type TransactionalOrderRepository interface {
+	SaveWithOutboxEvent(order Order, event OrderEvent) error
 }
\ No newline at end of file
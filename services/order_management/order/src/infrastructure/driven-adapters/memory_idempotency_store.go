@@ -0,0 +1,130 @@
+package drivenadapters
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
+)
+
+// MemoryIdempotencyStore is an in-memory implementation of
+// domain.IdempotencyStore, suitable for a single replica. A Redis-backed
+// implementation sharing state across replicas can be swapped in later by
+// implementing the same interface - the same relationship
+// MemoryProcessedEventLedger has to PostgresProcessedEventLedger.
+type MemoryIdempotencyStore struct {
+	mutex   sync.RWMutex
+	entries map[string]domain.IdempotencyRecord
+	ttl     time.Duration
+	// keyLocks holds one *sync.Mutex per Idempotency-Key currently (or
+	// recently) in flight, so WithLock can serialize the Get-then-Put
+	// sequence per key without serializing unrelated keys against each
+	// other. Entries are garbage-collected in sweep once their record has
+	// expired and the lock isn't held.
+	keyLocks sync.Map
+}
+
+// NewMemoryIdempotencyStore creates a MemoryIdempotencyStore whose entries
+// are considered expired ttl after they were stored.
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		entries: make(map[string]domain.IdempotencyRecord),
+		ttl:     ttl,
+	}
+}
+
+// Get implements domain.IdempotencyStore.
+func (s *MemoryIdempotencyStore) Get(key string) (domain.IdempotencyRecord, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	record, exists := s.entries[key]
+	if !exists || time.Since(record.CreatedAt) > s.ttl {
+		return domain.IdempotencyRecord{}, false, nil
+	}
+	return record, true, nil
+}
+
+// Put implements domain.IdempotencyStore.
+func (s *MemoryIdempotencyStore) Put(key string, record domain.IdempotencyRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries[key] = record
+	return nil
+}
+
+// WithLock implements domain.IdempotencyStore. It takes an exclusive,
+// in-process lock scoped to key before running fn, so two concurrent
+// requests carrying the same Idempotency-Key serialize instead of both
+// observing a Get miss and running the handler behind fn.
+func (s *MemoryIdempotencyStore) WithLock(key string, fn func() error) error {
+	lockIface, _ := s.keyLocks.LoadOrStore(key, &sync.Mutex{})
+	lock := lockIface.(*sync.Mutex)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return fn()
+}
+
+// Start runs the background sweeper that removes expired entries, every
+// sweepInterval, until ctx is cancelled. Without it the store would
+// otherwise keep every Idempotency-Key a long-running replica has ever
+// seen, past the point its TTL makes Get stop returning it anyway.
+func (s *MemoryIdempotencyStore) Start(ctx context.Context, sweepInterval time.Duration) {
+	log.Printf("Starting idempotency store sweeper (interval: %s, ttl: %s)", sweepInterval, s.ttl)
+
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Idempotency store sweeper stopping...")
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+// sweep removes every entry past its TTL, along with its key lock once the
+// entry is gone. A lock still held by an in-flight WithLock call is skipped
+// via TryLock, so sweep never deletes a lock a request is actively waiting
+// on - it'll simply be swept on a later pass once that request finishes.
+func (s *MemoryIdempotencyStore) sweep() {
+	expired := s.sweepEntries()
+	for _, key := range expired {
+		s.sweepKeyLock(key)
+	}
+}
+
+func (s *MemoryIdempotencyStore) sweepEntries() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var expired []string
+	now := time.Now()
+	for key, record := range s.entries {
+		if now.Sub(record.CreatedAt) > s.ttl {
+			delete(s.entries, key)
+			expired = append(expired, key)
+		}
+	}
+	return expired
+}
+
+func (s *MemoryIdempotencyStore) sweepKeyLock(key string) {
+	lockIface, ok := s.keyLocks.Load(key)
+	if !ok {
+		return
+	}
+	lock := lockIface.(*sync.Mutex)
+	if !lock.TryLock() {
+		return
+	}
+	defer lock.Unlock()
+	s.keyLocks.Delete(key)
+}
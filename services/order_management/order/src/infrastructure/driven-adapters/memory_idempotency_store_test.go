@@ -0,0 +1,72 @@
+package drivenadapters
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
+)
+
+// TestMemoryIdempotencyStore_WithLock_SerializesConcurrentCallersForSameKey
+// guards against the check-then-act race where two concurrent requests
+// carrying the same Idempotency-Key both observe a Get miss and run the
+// handler behind WithLock's fn a second time - the bug that let a client's
+// retried POST create two orders instead of replaying the first one's
+// response. Run with -race to also catch a regression on the underlying
+// map/mutex.
+func TestMemoryIdempotencyStore_WithLock_SerializesConcurrentCallersForSameKey(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Minute)
+
+	var fnCalls int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = store.WithLock("same-key", func() error {
+				if _, exists, _ := store.Get("same-key"); exists {
+					return nil
+				}
+				atomic.AddInt32(&fnCalls, 1)
+				return store.Put("same-key", domain.IdempotencyRecord{
+					Fingerprint: "fp",
+					StatusCode:  201,
+					CreatedAt:   time.Now(),
+				})
+			})
+		}()
+	}
+	wg.Wait()
+
+	if fnCalls != 1 {
+		t.Errorf("expected fn to run exactly once across 50 concurrent callers, ran %d times", fnCalls)
+	}
+}
+
+// TestMemoryIdempotencyStore_WithLock_DoesNotSerializeDifferentKeys ensures
+// WithLock only scopes its lock to a single key, so unrelated Idempotency-Keys
+// don't contend with each other.
+func TestMemoryIdempotencyStore_WithLock_DoesNotSerializeDifferentKeys(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Minute)
+
+	var wg sync.WaitGroup
+	var ran int32
+	for i := 0; i < 10; i++ {
+		key := string(rune('a' + i))
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			_ = store.WithLock(key, func() error {
+				atomic.AddInt32(&ran, 1)
+				return nil
+			})
+		}(key)
+	}
+	wg.Wait()
+
+	if ran != 10 {
+		t.Errorf("expected all 10 distinct-key calls to run, got %d", ran)
+	}
+}
@@ -0,0 +1,47 @@
+package drivenadapters
+
+import (
+	"sync"
+	"time"
+)
+
+// processedEventEntry records the outcome and time of a processed event,
+// kept for diagnostics.
+type processedEventEntry struct {
+	Result      string
+	ProcessedAt time.Time
+}
+
+// MemoryProcessedEventLedger is an in-memory implementation of
+// domain.ProcessedEventLedger. This is suitable for development and testing
+// purposes; use PostgresProcessedEventLedger when the consumer runs with
+// more than one replica, so the ledger is shared and survives restarts.
+type MemoryProcessedEventLedger struct {
+	mutex   sync.RWMutex
+	entries map[string]processedEventEntry
+}
+
+// NewMemoryProcessedEventLedger creates a new MemoryProcessedEventLedger.
+func NewMemoryProcessedEventLedger() *MemoryProcessedEventLedger {
+	return &MemoryProcessedEventLedger{
+		entries: make(map[string]processedEventEntry),
+	}
+}
+
+// SeenBefore reports whether eventID has already been recorded.
+func (l *MemoryProcessedEventLedger) SeenBefore(eventID string) (bool, error) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	_, exists := l.entries[eventID]
+	return exists, nil
+}
+
+// MarkProcessed records eventID as processed.
+func (l *MemoryProcessedEventLedger) MarkProcessed(eventID, result string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.entries[eventID] = processedEventEntry{Result: result, ProcessedAt: time.Now()}
+	return nil
+}
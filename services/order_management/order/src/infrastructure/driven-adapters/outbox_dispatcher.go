@@ -0,0 +1,126 @@
+package drivenadapters
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
+)
+
+// outboxRow is a pending row in the "outbox" table.
+type outboxRow struct {
+	ID      string
+	Payload []byte
+}
+
+// OutboxDispatcher polls the outbox table for unsent rows and publishes
+// them, retrying with exponential backoff on publish errors and honoring
+// context cancellation so it can be stopped alongside the rest of the
+// service during a graceful shutdown.
+type OutboxDispatcher struct {
+	db             *sql.DB
+	publisher      domain.OrderEventPublisher
+	pollInterval   time.Duration
+	maxAttempts    int
+	initialBackoff time.Duration
+}
+
+// NewOutboxDispatcher creates an OutboxDispatcher polling db every pollInterval.
+func NewOutboxDispatcher(db *sql.DB, publisher domain.OrderEventPublisher, pollInterval time.Duration) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		db:             db,
+		publisher:      publisher,
+		pollInterval:   pollInterval,
+		maxAttempts:    5,
+		initialBackoff: 200 * time.Millisecond,
+	}
+}
+
+// Start polls for unsent outbox rows until ctx is cancelled.
+func (d *OutboxDispatcher) Start(ctx context.Context) {
+	log.Printf("Starting outbox dispatcher (poll interval: %s)", d.pollInterval)
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox dispatcher stopping...")
+			return
+		case <-ticker.C:
+			d.dispatchPending(ctx)
+		}
+	}
+}
+
+// dispatchPending publishes every unsent outbox row, marking each as sent
+// once its publish succeeds.
+func (d *OutboxDispatcher) dispatchPending(ctx context.Context) {
+	rows, err := d.db.QueryContext(ctx,
+		`SELECT id, payload FROM outbox WHERE sent = false ORDER BY created_at ASC LIMIT 100`)
+	if err != nil {
+		log.Printf("Outbox dispatcher: failed to query pending rows: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	var pending []outboxRow
+	for rows.Next() {
+		var row outboxRow
+		if err := rows.Scan(&row.ID, &row.Payload); err != nil {
+			log.Printf("Outbox dispatcher: failed to scan row: %v", err)
+			continue
+		}
+		pending = append(pending, row)
+	}
+
+	for _, row := range pending {
+		var event domain.OrderEvent
+		if err := json.Unmarshal(row.Payload, &event); err != nil {
+			log.Printf("Outbox dispatcher: failed to unmarshal event for row %s: %v", row.ID, err)
+			continue
+		}
+
+		if err := d.publishWithBackoff(ctx, event); err != nil {
+			log.Printf("Outbox dispatcher: giving up on row %s for now: %v", row.ID, err)
+			continue
+		}
+
+		if _, err := d.db.ExecContext(ctx, `UPDATE outbox SET sent = true, sent_at = $1 WHERE id = $2`, time.Now(), row.ID); err != nil {
+			log.Printf("Outbox dispatcher: failed to mark row %s as sent: %v", row.ID, err)
+		}
+	}
+}
+
+// publishWithBackoff retries PublishOrderEvent with exponential backoff,
+// giving up after maxAttempts or when ctx is cancelled.
+func (d *OutboxDispatcher) publishWithBackoff(ctx context.Context, event domain.OrderEvent) error {
+	backoff := d.initialBackoff
+
+	var lastErr error
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := d.publisher.PublishOrderEvent(ctx, event); err == nil {
+			return nil
+		} else {
+			lastErr = err
+			log.Printf("Outbox dispatcher: publish attempt %d/%d failed for order %s: %v", attempt, d.maxAttempts, event.OrderID, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	return lastErr
+}
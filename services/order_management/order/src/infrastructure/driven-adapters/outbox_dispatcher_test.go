@@ -0,0 +1,93 @@
+package drivenadapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
+)
+
+// fakeOrderEventPublisher lets tests script PublishOrderEvent's outcome
+// across successive calls without a real RabbitMQPublisher/broker.
+type fakeOrderEventPublisher struct {
+	failuresBeforeSuccess int
+	calls                 int
+	published             []domain.OrderEvent
+}
+
+func (p *fakeOrderEventPublisher) PublishOrderEvent(ctx context.Context, event domain.OrderEvent) error {
+	p.calls++
+	if p.calls <= p.failuresBeforeSuccess {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, event)
+	return nil
+}
+
+// TestOutboxDispatcher_PublishWithBackoff_RetriesThenSucceeds guards the
+// outbox relay's resilience to a transient publish failure (e.g. Kafka/
+// RabbitMQ briefly unavailable): it must keep retrying with backoff and
+// eventually publish rather than dropping the event on the first error.
+func TestOutboxDispatcher_PublishWithBackoff_RetriesThenSucceeds(t *testing.T) {
+	publisher := &fakeOrderEventPublisher{failuresBeforeSuccess: 2}
+	d := &OutboxDispatcher{
+		publisher:      publisher,
+		maxAttempts:    5,
+		initialBackoff: time.Millisecond,
+	}
+
+	event := domain.OrderEvent{OrderID: "order-1", EventType: "order.created"}
+	if err := d.publishWithBackoff(context.Background(), event); err != nil {
+		t.Fatalf("publishWithBackoff() returned error: %v", err)
+	}
+	if publisher.calls != 3 {
+		t.Errorf("expected 3 attempts before success, got %d", publisher.calls)
+	}
+	if len(publisher.published) != 1 || publisher.published[0].OrderID != "order-1" {
+		t.Errorf("expected order-1 to be published exactly once, got %v", publisher.published)
+	}
+}
+
+// TestOutboxDispatcher_PublishWithBackoff_GivesUpAfterMaxAttempts ensures a
+// persistently failing publish doesn't retry forever and blow past
+// dispatchPending's poll interval.
+func TestOutboxDispatcher_PublishWithBackoff_GivesUpAfterMaxAttempts(t *testing.T) {
+	publisher := &fakeOrderEventPublisher{failuresBeforeSuccess: 100}
+	d := &OutboxDispatcher{
+		publisher:      publisher,
+		maxAttempts:    3,
+		initialBackoff: time.Millisecond,
+	}
+
+	err := d.publishWithBackoff(context.Background(), domain.OrderEvent{OrderID: "order-2"})
+	if err == nil {
+		t.Fatal("expected an error after exhausting maxAttempts")
+	}
+	if publisher.calls != 3 {
+		t.Errorf("expected exactly 3 attempts, got %d", publisher.calls)
+	}
+}
+
+// TestOutboxDispatcher_PublishWithBackoff_StopsOnContextCancellation ensures
+// a cancelled context (e.g. service shutdown) stops the retry loop instead
+// of blocking dispatchPending.
+func TestOutboxDispatcher_PublishWithBackoff_StopsOnContextCancellation(t *testing.T) {
+	publisher := &fakeOrderEventPublisher{failuresBeforeSuccess: 100}
+	d := &OutboxDispatcher{
+		publisher:      publisher,
+		maxAttempts:    100,
+		initialBackoff: 50 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := d.publishWithBackoff(ctx, domain.OrderEvent{OrderID: "order-3"}); err == nil {
+		t.Fatal("expected an error when ctx is already cancelled")
+	}
+	if publisher.calls != 0 {
+		t.Errorf("expected no attempts once ctx is already cancelled, got %d", publisher.calls)
+	}
+}
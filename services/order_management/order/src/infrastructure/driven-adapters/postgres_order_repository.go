@@ -0,0 +1,190 @@
+package drivenadapters
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// PostgresOrderRepository implements domain.OrderRepository and
+// domain.TransactionalOrderRepository backed by Postgres. Orders and their
+// outbox rows are written to the "orders" and "outbox" tables.
+type PostgresOrderRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresOrderRepository opens a Postgres connection pool for dsn and
+// verifies it is reachable.
+func NewPostgresOrderRepository(dsn string) (*PostgresOrderRepository, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres connection: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping postgres: %w", err)
+	}
+
+	return &PostgresOrderRepository{db: db}, nil
+}
+
+// Save stores or updates an order.
+func (r *PostgresOrderRepository) Save(order domain.Order) error {
+	_, err := r.db.Exec(
+		`INSERT INTO orders (id, customer_id, product_id, quantity, status, total_amount, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (id) DO UPDATE SET
+		     customer_id = EXCLUDED.customer_id,
+		     product_id  = EXCLUDED.product_id,
+		     quantity    = EXCLUDED.quantity,
+		     status      = EXCLUDED.status,
+		     total_amount = EXCLUDED.total_amount,
+		     updated_at  = EXCLUDED.updated_at`,
+		order.ID, order.CustomerID, order.ProductID, order.Quantity, order.Status, order.TotalAmount, order.CreatedAt, order.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save order: %w", err)
+	}
+	return nil
+}
+
+// FindByID retrieves an order by its ID.
+func (r *PostgresOrderRepository) FindByID(id string) (*domain.Order, error) {
+	row := r.db.QueryRow(
+		`SELECT id, customer_id, product_id, quantity, status, total_amount, created_at, updated_at
+		 FROM orders WHERE id = $1`, id,
+	)
+
+	var order domain.Order
+	if err := row.Scan(&order.ID, &order.CustomerID, &order.ProductID, &order.Quantity, &order.Status, &order.TotalAmount, &order.CreatedAt, &order.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order with ID %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to find order %s: %w", id, err)
+	}
+
+	return &order, nil
+}
+
+// FindAll retrieves all orders.
+func (r *PostgresOrderRepository) FindAll() ([]domain.Order, error) {
+	rows, err := r.db.Query(
+		`SELECT id, customer_id, product_id, quantity, status, total_amount, created_at, updated_at FROM orders`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []domain.Order
+	for rows.Next() {
+		var order domain.Order
+		if err := rows.Scan(&order.ID, &order.CustomerID, &order.ProductID, &order.Quantity, &order.Status, &order.TotalAmount, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan order row: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+// Update updates an existing order.
+func (r *PostgresOrderRepository) Update(order domain.Order) error {
+	result, err := r.db.Exec(
+		`UPDATE orders SET customer_id=$2, product_id=$3, quantity=$4, status=$5, total_amount=$6, updated_at=$7 WHERE id=$1`,
+		order.ID, order.CustomerID, order.ProductID, order.Quantity, order.Status, order.TotalAmount, order.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update order %s: %w", order.ID, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify update for order %s: %w", order.ID, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("order with ID %s not found", order.ID)
+	}
+
+	return nil
+}
+
+// Delete removes an order by its ID.
+func (r *PostgresOrderRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM orders WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete order %s: %w", id, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify delete for order %s: %w", id, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("order with ID %s not found", id)
+	}
+
+	return nil
+}
+
+// SaveWithOutboxEvent writes the order and its pending event inside a single
+// transaction, so a failed or delayed publish never leaves the DB and the
+// event log out of sync. The OutboxDispatcher picks up unsent rows and
+// publishes them asynchronously.
+func (r *PostgresOrderRepository) SaveWithOutboxEvent(order domain.Order, event domain.OrderEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO orders (id, customer_id, product_id, quantity, status, total_amount, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (id) DO UPDATE SET
+		     customer_id = EXCLUDED.customer_id,
+		     product_id  = EXCLUDED.product_id,
+		     quantity    = EXCLUDED.quantity,
+		     status      = EXCLUDED.status,
+		     total_amount = EXCLUDED.total_amount,
+		     updated_at  = EXCLUDED.updated_at`,
+		order.ID, order.CustomerID, order.ProductID, order.Quantity, order.Status, order.TotalAmount, order.CreatedAt, order.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to save order: %w", err)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox event: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO outbox (id, order_id, event_type, payload, created_at, sent)
+		 VALUES ($1, $2, $3, $4, $5, false)`,
+		uuid.New().String(), event.OrderID, event.EventType, payload, event.Timestamp,
+	); err != nil {
+		return fmt.Errorf("failed to enqueue outbox event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit order + outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying connection pool.
+func (r *PostgresOrderRepository) Close() error {
+	return r.db.Close()
+}
+
+// DB exposes the underlying connection pool for components that need to
+// share it, such as OutboxDispatcher.
+func (r *PostgresOrderRepository) DB() *sql.DB {
+	return r.db
+}
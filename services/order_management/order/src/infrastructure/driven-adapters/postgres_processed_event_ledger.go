@@ -0,0 +1,45 @@
+package drivenadapters
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PostgresProcessedEventLedger implements domain.ProcessedEventLedger
+// backed by Postgres, so the redelivery ledger survives restarts and is
+// shared across replicas of the consumer. It reuses the connection pool
+// opened by PostgresOrderRepository rather than opening its own.
+type PostgresProcessedEventLedger struct {
+	db *sql.DB
+}
+
+// NewPostgresProcessedEventLedger creates a new PostgresProcessedEventLedger
+// over db.
+func NewPostgresProcessedEventLedger(db *sql.DB) *PostgresProcessedEventLedger {
+	return &PostgresProcessedEventLedger{db: db}
+}
+
+// SeenBefore reports whether eventID has already been recorded.
+func (l *PostgresProcessedEventLedger) SeenBefore(eventID string) (bool, error) {
+	var exists bool
+	if err := l.db.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM processed_events WHERE event_id = $1)`, eventID,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check processed event %s: %w", eventID, err)
+	}
+	return exists, nil
+}
+
+// MarkProcessed records eventID as processed.
+func (l *PostgresProcessedEventLedger) MarkProcessed(eventID, result string) error {
+	if _, err := l.db.Exec(
+		`INSERT INTO processed_events (event_id, result, processed_at)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (event_id) DO UPDATE SET result = EXCLUDED.result, processed_at = EXCLUDED.processed_at`,
+		eventID, result, time.Now(),
+	); err != nil {
+		return fmt.Errorf("failed to record processed event %s: %w", eventID, err)
+	}
+	return nil
+}
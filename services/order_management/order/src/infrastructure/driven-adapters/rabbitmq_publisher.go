@@ -1,151 +1,400 @@
-package drivenadapters
-
-import (
-	"context"
-	"encoding/json"
-	"log"
-
-	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
-	amqp "github.com/rabbitmq/amqp091-go"
-)
-
-// RabbitMQPublisher handles message publication to RabbitMQ
-type RabbitMQPublisher struct {
-	conn         *amqp.Connection
-	channel      *amqp.Channel
-	exchangeName string
-	queueName    string
-	routingKey   string
-}
-
-// NewRabbitMQPublisher creates a new RabbitMQPublisher
-func NewRabbitMQPublisher(rabbitMQURL, exchangeName, queueName, routingKey string) (*RabbitMQPublisher, error) {
-	conn, err := amqp.Dial(rabbitMQURL)
-	if err != nil {
-		return nil, err
-	}
-
-	channel, err := conn.Channel()
-	if err != nil {
-		conn.Close()
-		return nil, err
-	}
-
-	// Declare the exchange
-	err = channel.ExchangeDeclare(
-		exchangeName, // name
-		"direct",     // type
-		true,         // durable
-		false,        // auto-deleted
-		false,        // internal
-		false,        // no-wait
-		nil,          // arguments
-	)
-	if err != nil {
-		channel.Close()
-		conn.Close()
-		return nil, err
-	}
-
-	// Declare the queue for order events
-	_, err = channel.QueueDeclare(
-		queueName, // name
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
-	)
-	if err != nil {
-		channel.Close()
-		conn.Close()
-		return nil, err
-	}
-
-	// Bind the queue to the exchange
-	err = channel.QueueBind(
-		queueName,    // queue name
-		routingKey,   // routing key
-		exchangeName, // exchange
-		false,
-		nil,
-	)
-	if err != nil {
-		channel.Close()
-		conn.Close()
-		return nil, err
-	}
-
-	log.Printf("RabbitMQ Publisher initialized - Exchange: %s, Queue: %s, RoutingKey: %s", 
-		exchangeName, queueName, routingKey)
-
-	return &RabbitMQPublisher{
-		conn:         conn,
-		channel:      channel,
-		exchangeName: exchangeName,
-		queueName:    queueName,
-		routingKey:   routingKey,
-	}, nil
-}
-
-// PublishOrderEvent publishes an order event to RabbitMQ
-func (p *RabbitMQPublisher) PublishOrderEvent(event domain.OrderEvent) error {
-	// Marshal the event to JSON
-	body, err := json.Marshal(event)
-	if err != nil {
-		return err
-	}
-
-	// Publish the message
-	err = p.channel.PublishWithContext(
-		context.Background(),
-		p.exchangeName, // exchange
-		p.routingKey,   // routing key
-		false,          // mandatory
-		false,          // immediate
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		},
-	)
-
-	if err != nil {
-		log.Printf("Failed to publish order event: %v", err)
-		return err
-	}
-
-	log.Printf("Order event published successfully: Type=%s, OrderID=%s", event.EventType, event.OrderID)
-	return nil
-}
-
-// PublishMessage publishes a simple message to RabbitMQ (for demo purposes)
-func (p *RabbitMQPublisher) PublishMessage(ctx context.Context, message string) error {
-	err := p.channel.PublishWithContext(ctx,
-		p.exchangeName, // exchange
-		p.routingKey,   // routing key
-		false,          // mandatory
-		false,          // immediate
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(message),
-		})
-
-	if err != nil {
-		log.Printf("Failed to publish message: %v", err)
-		return err
-	}
-
-	log.Printf("Message published successfully: %s", message)
-	return nil
-}
-
-// Close closes the RabbitMQ connection and channel
-func (p *RabbitMQPublisher) Close() error {
-	if p.channel != nil {
-		p.channel.Close()
-	}
-	if p.conn != nil {
-		return p.conn.Close()
-	}
-	return nil
-}
\ No newline at end of file
+package drivenadapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/pkg/eventing"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/pkg/retry"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// eventSource identifies this service as the CloudEvents "source" attribute.
+const eventSource = "order-service"
+
+// CloudEventsModeStructured wraps the whole event in a single
+// "application/cloudevents+json" envelope per pkg/eventing, for
+// brokers/bridges that expect the structured-mode content type.
+const CloudEventsModeStructured = "structured"
+
+// CloudEventsModeBinary carries CloudEvents attributes as "ce-*" AMQP
+// headers alongside a message body that is the plain event JSON.
+const CloudEventsModeBinary = "binary"
+
+// confirmTimeout bounds how long PublishOrderEvent waits for the broker's
+// publisher confirm before treating the publish as failed.
+const confirmTimeout = 5 * time.Second
+
+// redialPolicy governs the supervisor goroutine's backoff between redial
+// attempts: unbounded attempts (MaxAttempts 0, so it keeps going until
+// Close() cancels it), starting at 500ms and capping at 30s with 20% jitter.
+var redialPolicy = retry.Policy{
+	InitialDelay: 500 * time.Millisecond,
+	MaxDelay:     30 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+// retryQueueTTL is how long a message waits on the "<queue>.retry" queue
+// before RabbitMQ dead-letters it back onto the main exchange for
+// redelivery. Following the pattern popularized by bunnify, this - plus the
+// terminal "<queue>.dlq" queue declareTopology also declares - gives the
+// order events this publisher emits a durable home while a downstream
+// consumer works through the batch service's own retry/DLQ handling instead
+// of relying solely on in-memory redelivery.
+const retryQueueTTL = 5 * time.Second
+
+// RabbitMQPublisher handles message publication to RabbitMQ. A background
+// supervisor goroutine watches the connection and channel's NotifyClose and
+// transparently redials with backoff on either one closing, re-declaring the
+// exchange/queue/binding and putting the fresh channel back into confirm
+// mode - so a broker restart or a dropped TCP connection recovers without
+// the caller seeing anything worse than a slow or failed PublishOrderEvent
+// call during the outage. The channel is kept in Confirm(false) mode with
+// NotifyPublish registered, so PublishOrderEvent blocks until the broker
+// acks (or nacks, or confirmTimeout/ctx expires) instead of firing and
+// forgetting.
+type RabbitMQPublisher struct {
+	url             string
+	exchangeName    string
+	queueName       string
+	routingKey      string
+	cloudEventsMode string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.RWMutex
+	conn      *amqp.Connection
+	channel   *amqp.Channel
+	confirmCh chan amqp.Confirmation
+
+	// publishMu serializes PublishOrderEvent calls so each publish's confirm
+	// wait reads the ack/nack for its own message rather than racing another
+	// in-flight publish for the next one off the shared confirmCh.
+	publishMu sync.Mutex
+}
+
+// NewRabbitMQPublisher creates a new RabbitMQPublisher and starts its
+// supervisor goroutine, which runs until ctx is cancelled or Close is
+// called. cloudEventsMode selects CloudEventsModeStructured (default, when
+// empty) or CloudEventsModeBinary.
+func NewRabbitMQPublisher(ctx context.Context, rabbitMQURL, exchangeName, queueName, routingKey, cloudEventsMode string) (*RabbitMQPublisher, error) {
+	superviseCtx, cancel := context.WithCancel(ctx)
+
+	if cloudEventsMode == "" {
+		cloudEventsMode = CloudEventsModeStructured
+	}
+
+	p := &RabbitMQPublisher{
+		url:             rabbitMQURL,
+		exchangeName:    exchangeName,
+		queueName:       queueName,
+		routingKey:      routingKey,
+		cloudEventsMode: cloudEventsMode,
+		ctx:             superviseCtx,
+		cancel:          cancel,
+	}
+
+	if err := p.connect(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go p.supervise()
+
+	return p, nil
+}
+
+// connect dials the broker, opens a channel, declares the exchange/queue/
+// binding, and puts the channel into publisher-confirm mode, swapping it in
+// for whatever connection/channel the publisher previously held. It is used
+// both for the initial connect and for every redial.
+func (p *RabbitMQPublisher) connect() error {
+	conn, err := amqp.Dial(p.url)
+	if err != nil {
+		return err
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	if err := p.declareTopology(channel); err != nil {
+		channel.Close()
+		conn.Close()
+		return err
+	}
+
+	if err := channel.Confirm(false); err != nil {
+		channel.Close()
+		conn.Close()
+		return fmt.Errorf("failed to put channel into confirm mode: %w", err)
+	}
+	confirmCh := channel.NotifyPublish(make(chan amqp.Confirmation, 1))
+
+	p.mu.Lock()
+	oldConn, oldChannel := p.conn, p.channel
+	p.conn = conn
+	p.channel = channel
+	p.confirmCh = confirmCh
+	p.mu.Unlock()
+
+	if oldChannel != nil {
+		oldChannel.Close()
+	}
+	if oldConn != nil {
+		oldConn.Close()
+	}
+
+	log.Printf("RabbitMQ Publisher connected - Exchange: %s, Queue: %s, RoutingKey: %s",
+		p.exchangeName, p.queueName, p.routingKey)
+	return nil
+}
+
+// declareTopology declares the exchange, queue, and binding this publisher
+// depends on against channel, so a redial re-creates them exactly as the
+// initial connect did in case the broker lost them (e.g. a non-durable
+// broker restart). It also declares p.queueName's retry and dead-letter
+// queues, so a consumer routing a failed message to "<queue>.retry" (see
+// the batch service's messaging.AMQPSource.Retry) finds them already in
+// place regardless of which side of the connection starts first.
+func (p *RabbitMQPublisher) declareTopology(channel *amqp.Channel) error {
+	err := channel.ExchangeDeclare(
+		p.exchangeName, // name
+		"direct",       // type
+		true,           // durable
+		false,          // auto-deleted
+		false,          // internal
+		false,          // no-wait
+		nil,            // arguments
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = channel.QueueDeclare(
+		p.queueName, // name
+		true,        // durable
+		false,       // delete when unused
+		false,       // exclusive
+		false,       // no-wait
+		nil,         // arguments
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := channel.QueueBind(
+		p.queueName,    // queue name
+		p.routingKey,   // routing key
+		p.exchangeName, // exchange
+		false,
+		nil,
+	); err != nil {
+		return err
+	}
+
+	retryQueue := p.queueName + ".retry"
+	if _, err := channel.QueueDeclare(retryQueue, true, false, false, false, amqp.Table{
+		"x-message-ttl":             int64(retryQueueTTL / time.Millisecond),
+		"x-dead-letter-exchange":    p.exchangeName,
+		"x-dead-letter-routing-key": p.routingKey,
+	}); err != nil {
+		return fmt.Errorf("failed to declare retry queue: %w", err)
+	}
+
+	dlqQueue := p.queueName + ".dlq"
+	if _, err := channel.QueueDeclare(dlqQueue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+
+	return nil
+}
+
+// supervise watches the current connection and channel's NotifyClose and
+// redials on either firing, until p.ctx is cancelled.
+func (p *RabbitMQPublisher) supervise() {
+	for {
+		p.mu.RLock()
+		conn := p.conn
+		channel := p.channel
+		p.mu.RUnlock()
+
+		connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		channelClosed := channel.NotifyClose(make(chan *amqp.Error, 1))
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case err := <-connClosed:
+			log.Printf("RabbitMQ publisher: connection closed, redialing: %v", err)
+		case err := <-channelClosed:
+			log.Printf("RabbitMQ publisher: channel closed, redialing: %v", err)
+		}
+
+		if err := retry.Do(p.ctx, p.connect, redialPolicy); err != nil {
+			log.Printf("RabbitMQ publisher: redial stopped: %v", err)
+			return
+		}
+	}
+}
+
+// PublishOrderEvent publishes an order event to RabbitMQ as a CloudEvents
+// 1.0 envelope, in the publisher's configured mode (see CloudEventsMode*),
+// so downstream consumers get a stable id, source, type and subject for
+// tracing and schema evolution instead of the bare domain payload. The call
+// blocks until the broker confirms the publish (or ctx/confirmTimeout
+// expires, or the broker nacks it), giving the caller a real at-least-once
+// delivery guarantee instead of fire-and-forget.
+func (p *RabbitMQPublisher) PublishOrderEvent(ctx context.Context, event domain.OrderEvent) error {
+	var publishing amqp.Publishing
+	var eventID string
+	var err error
+	if p.cloudEventsMode == CloudEventsModeBinary {
+		publishing, eventID, err = p.buildBinaryPublishing(event)
+	} else {
+		publishing, eventID, err = p.buildStructuredPublishing(event)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.publishMu.Lock()
+	defer p.publishMu.Unlock()
+
+	p.mu.RLock()
+	channel := p.channel
+	confirmCh := p.confirmCh
+	p.mu.RUnlock()
+
+	publishCtx, cancel := context.WithTimeout(ctx, confirmTimeout)
+	defer cancel()
+
+	err = channel.PublishWithContext(
+		publishCtx,
+		p.exchangeName, // exchange
+		p.routingKey,   // routing key
+		false,          // mandatory
+		false,          // immediate
+		publishing,
+	)
+	if err != nil {
+		log.Printf("Failed to publish order event: %v", err)
+		return fmt.Errorf("failed to publish order event: %w", err)
+	}
+
+	select {
+	case confirm, ok := <-confirmCh:
+		if !ok {
+			return fmt.Errorf("rabbitmq publisher: confirm channel closed before ack for order %s", event.OrderID)
+		}
+		if !confirm.Ack {
+			return fmt.Errorf("rabbitmq publisher: broker nacked order %s event", event.OrderID)
+		}
+	case <-publishCtx.Done():
+		return fmt.Errorf("rabbitmq publisher: timed out waiting for publish confirm for order %s: %w", event.OrderID, publishCtx.Err())
+	}
+
+	log.Printf("Order event published successfully: Type=%s, OrderID=%s, CloudEventID=%s", event.EventType, event.OrderID, eventID)
+	return nil
+}
+
+// buildStructuredPublishing wraps event in a single CloudEvents 1.0
+// "application/cloudevents+json" envelope.
+func (p *RabbitMQPublisher) buildStructuredPublishing(event domain.OrderEvent) (amqp.Publishing, string, error) {
+	envelope, err := eventing.Wrap(eventSource, ceType(event.EventType), event.OrderID, event.TraceParent, event)
+	if err != nil {
+		return amqp.Publishing{}, "", err
+	}
+
+	body, err := eventing.Encode(envelope)
+	if err != nil {
+		return amqp.Publishing{}, "", err
+	}
+
+	return amqp.Publishing{
+		ContentType: "application/cloudevents+json",
+		Body:        body,
+	}, envelope.ID, nil
+}
+
+// buildBinaryPublishing renders event as plain JSON with CloudEvents 1.0
+// attributes carried as "ce-*" AMQP headers alongside the message body, so
+// consumers get a stable id/source/type/subject for tracing without
+// changing how the body itself is decoded.
+func (p *RabbitMQPublisher) buildBinaryPublishing(event domain.OrderEvent) (amqp.Publishing, string, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return amqp.Publishing{}, "", fmt.Errorf("failed to marshal order event: %w", err)
+	}
+
+	attrs := eventing.NewAttributes(eventSource, ceType(event.EventType), event.OrderID, event.TraceParent)
+	headers := amqp.Table{}
+	for key, value := range attrs.Headers() {
+		headers[key] = value
+	}
+
+	return amqp.Publishing{
+		ContentType: "application/json",
+		Headers:     headers,
+		Body:        body,
+	}, attrs.ID, nil
+}
+
+// ceType maps a domain event type (e.g. "order.created") to its CloudEvents
+// reverse-DNS type (e.g. "com.medisupply.order.created").
+func ceType(eventType string) string {
+	return "com.medisupply." + eventType
+}
+
+// PublishMessage publishes a simple message to RabbitMQ (for demo purposes)
+func (p *RabbitMQPublisher) PublishMessage(ctx context.Context, message string) error {
+	p.mu.RLock()
+	channel := p.channel
+	p.mu.RUnlock()
+
+	err := channel.PublishWithContext(ctx,
+		p.exchangeName, // exchange
+		p.routingKey,   // routing key
+		false,          // mandatory
+		false,          // immediate
+		amqp.Publishing{
+			ContentType: "text/plain",
+			Body:        []byte(message),
+		})
+
+	if err != nil {
+		log.Printf("Failed to publish message: %v", err)
+		return err
+	}
+
+	log.Printf("Message published successfully: %s", message)
+	return nil
+}
+
+// Close stops the supervisor goroutine and closes the RabbitMQ connection
+// and channel.
+func (p *RabbitMQPublisher) Close() error {
+	p.cancel()
+
+	p.mu.RLock()
+	channel := p.channel
+	conn := p.conn
+	p.mu.RUnlock()
+
+	if channel != nil {
+		channel.Close()
+	}
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
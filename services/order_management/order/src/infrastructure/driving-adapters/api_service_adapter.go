@@ -1,183 +1,432 @@
-package drivingadapters
-
-import (
-	"context"
-	"log"
-	"net/http"
-	"time"
-
-	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/application"
-	"github.com/gin-gonic/gin"
-)
-
-// ApiServiceAdapter is responsible for exposing the order management capabilities
-// over HTTP protocol through RESTful web service endpoints
-type ApiServiceAdapter struct {
-	server       *http.Server
-	router       *gin.Engine
-	port         string
-	orderService *application.OrderService
-}
-
-// CreateOrderRequest represents the request payload for creating an order
-type CreateOrderRequest struct {
-	CustomerID  string  `json:"customer_id" binding:"required"`
-	ProductID   string  `json:"product_id" binding:"required"`
-	Quantity    int     `json:"quantity" binding:"required,min=1"`
-	TotalAmount float64 `json:"total_amount" binding:"required,min=0"`
-}
-
-// UpdateOrderStatusRequest represents the request payload for updating order status
-type UpdateOrderStatusRequest struct {
-	Status string `json:"status" binding:"required"`
-}
-
-// NewApiServiceAdapter creates a new ApiServiceAdapter
-func NewApiServiceAdapter(port string, orderService *application.OrderService) *ApiServiceAdapter {
-	// Set gin to release mode for production
-	gin.SetMode(gin.ReleaseMode)
-	
-	router := gin.New()
-	
-	// Add middleware
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-	
-	adapter := &ApiServiceAdapter{
-		router:       router,
-		port:         port,
-		orderService: orderService,
-	}
-	
-	// Setup routes
-	adapter.setupRoutes()
-	
-	// Create HTTP server
-	adapter.server = &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
-	}
-	
-	return adapter
-}
-
-// setupRoutes configures all HTTP routes
-func (adapter *ApiServiceAdapter) setupRoutes() {
-	// Health check endpoint
-	adapter.router.GET("/health", adapter.healthHandler)
-	
-	// Order management endpoints
-	v1 := adapter.router.Group("/api/v1")
-	{
-		v1.POST("/orders", adapter.createOrderHandler)
-		v1.GET("/orders", adapter.getAllOrdersHandler)
-		v1.GET("/orders/:id", adapter.getOrderHandler)
-		v1.PUT("/orders/:id/status", adapter.updateOrderStatusHandler)
-	}
-}
-
-// healthHandler handles health check requests
-func (adapter *ApiServiceAdapter) healthHandler(c *gin.Context) {
-	response := gin.H{
-		"status":    "healthy",
-		"service":   "oder-management/order",
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	}
-	
-	c.JSON(http.StatusOK, response)
-}
-
-// createOrderHandler handles order creation requests
-func (adapter *ApiServiceAdapter) createOrderHandler(c *gin.Context) {
-	var req CreateOrderRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	order, err := adapter.orderService.CreateOrder(req.CustomerID, req.ProductID, req.Quantity, req.TotalAmount)
-	if err != nil {
-		log.Printf("Error creating order: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
-		return
-	}
-
-	c.JSON(http.StatusCreated, order)
-}
-
-// getAllOrdersHandler handles requests to get all orders
-func (adapter *ApiServiceAdapter) getAllOrdersHandler(c *gin.Context) {
-	orders, err := adapter.orderService.GetAllOrders()
-	if err != nil {
-		log.Printf("Error getting orders: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
-		return
-	}
-
-	c.JSON(http.StatusOK, orders)
-}
-
-// getOrderHandler handles requests to get a specific order
-func (adapter *ApiServiceAdapter) getOrderHandler(c *gin.Context) {
-	id := c.Param("id")
-	
-	order, err := adapter.orderService.GetOrder(id)
-	if err != nil {
-		log.Printf("Error getting order %s: %v", id, err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
-		return
-	}
-
-	c.JSON(http.StatusOK, order)
-}
-
-// updateOrderStatusHandler handles requests to update order status
-func (adapter *ApiServiceAdapter) updateOrderStatusHandler(c *gin.Context) {
-	id := c.Param("id")
-	
-	var req UpdateOrderStatusRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	order, err := adapter.orderService.UpdateOrderStatus(id, req.Status)
-	if err != nil {
-		log.Printf("Error updating order status %s: %v", id, err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
-		return
-	}
-
-	c.JSON(http.StatusOK, order)
-}
-
-// Start begins the HTTP server
-func (adapter *ApiServiceAdapter) Start(ctx context.Context) {
-	log.Printf("Starting HTTP API service adapter on port %s...", adapter.port)
-	
-	// Start server in a goroutine
-	go func() {
-		if err := adapter.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("HTTP server error: %v", err)
-		}
-	}()
-	
-	// Wait for context cancellation
-	<-ctx.Done()
-	log.Println("HTTP API service adapter stopping...")
-	
-	// Graceful shutdown
-	adapter.Stop()
-}
-
-// Stop gracefully shuts down the HTTP server
-func (adapter *ApiServiceAdapter) Stop() {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	if err := adapter.server.Shutdown(ctx); err != nil {
-		log.Printf("HTTP server shutdown error: %v", err)
-	} else {
-		log.Println("HTTP API service adapter stopped gracefully")
-	}
-}
\ No newline at end of file
+package drivingadapters
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/application"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/pkg/eventing"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// orderCreateEventType is the CloudEvents "type" attribute createOrderHandler
+// routes to orderService.CreateOrder, in both structured mode (Content-Type
+// application/cloudevents+json) and binary mode (ce-* headers).
+const orderCreateEventType = "com.medisupply.order.create.v1"
+
+// ApiServiceAdapter is responsible for exposing the order management capabilities
+// over HTTP protocol through RESTful web service endpoints
+type ApiServiceAdapter struct {
+	server       *http.Server
+	router       *gin.Engine
+	port         string
+	orderService *application.OrderService
+	// ready, when non-nil, is closed once the consumer has caught up with
+	// its startup backlog; /ready returns 503 until then. A nil ready
+	// reports ready immediately, for callers that don't wire one in.
+	ready <-chan struct{}
+	// idempotencyStore, when non-nil, lets createOrderHandler and
+	// updateOrderStatusHandler replay the stored response for a repeated
+	// Idempotency-Key instead of re-running the handler. A nil store
+	// disables idempotency handling entirely.
+	idempotencyStore domain.IdempotencyStore
+}
+
+// CreateOrderRequest represents the request payload for creating an order
+type CreateOrderRequest struct {
+	CustomerID  string  `json:"customer_id" binding:"required"`
+	ProductID   string  `json:"product_id" binding:"required"`
+	Quantity    int     `json:"quantity" binding:"required,min=1"`
+	TotalAmount float64 `json:"total_amount" binding:"required,min=0"`
+}
+
+// UpdateOrderStatusRequest represents the request payload for updating order status
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// NewApiServiceAdapter creates a new ApiServiceAdapter. ready, when
+// non-nil, gates /ready: it must be closed (e.g. by a ReadinessChecker)
+// before /ready returns 200, so Kubernetes won't route traffic to a pod
+// whose consumer is still catching up on its startup backlog. A nil ready
+// makes /ready always report healthy. idempotencyStore may be nil, in
+// which case the Idempotency-Key header is ignored.
+func NewApiServiceAdapter(port string, orderService *application.OrderService, ready <-chan struct{}, idempotencyStore domain.IdempotencyStore) *ApiServiceAdapter {
+	// Set gin to release mode for production
+	gin.SetMode(gin.ReleaseMode)
+
+	router := gin.New()
+
+	// Add middleware
+	router.Use(gin.Logger())
+	router.Use(gin.Recovery())
+	router.Use(requestIDMiddleware)
+
+	adapter := &ApiServiceAdapter{
+		router:           router,
+		port:             port,
+		orderService:     orderService,
+		ready:            ready,
+		idempotencyStore: idempotencyStore,
+	}
+
+	// Setup routes
+	adapter.setupRoutes()
+
+	// Create HTTP server
+	adapter.server = &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+
+	return adapter
+}
+
+// requestIDMiddleware extracts an incoming "traceparent" (W3C Trace
+// Context) or "X-Request-ID" header and attaches it to the request's
+// context (see pkg/logging), generating a fresh traceparent when neither is
+// present. It also echoes the id back as X-Request-ID so a caller that sent
+// neither header can still correlate its own logs with ours. Handlers pick
+// it up via c.Request.Context() and it flows from there into
+// OrderService's published events and log lines.
+func requestIDMiddleware(c *gin.Context) {
+	requestID := c.GetHeader(eventing.HeaderTraceParent)
+	if requestID == "" {
+		requestID = c.GetHeader("X-Request-ID")
+	}
+	if requestID == "" {
+		requestID = logging.NewTraceParent()
+	}
+
+	c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+	c.Header("X-Request-ID", requestID)
+	c.Next()
+}
+
+// setupRoutes configures all HTTP routes
+func (adapter *ApiServiceAdapter) setupRoutes() {
+	// Health check endpoint
+	adapter.router.GET("/health", adapter.healthHandler)
+
+	// Readiness endpoint, gated on the consumer having drained its startup backlog
+	adapter.router.GET("/ready", adapter.readyHandler)
+
+	// Order management endpoints
+	v1 := adapter.router.Group("/api/v1")
+	{
+		v1.POST("/orders", adapter.createOrderHandler)
+		v1.GET("/orders", adapter.getAllOrdersHandler)
+		v1.GET("/orders/:id", adapter.getOrderHandler)
+		v1.PUT("/orders/:id/status", adapter.updateOrderStatusHandler)
+	}
+}
+
+// healthHandler handles health check requests
+func (adapter *ApiServiceAdapter) healthHandler(c *gin.Context) {
+	response := gin.H{
+		"status":    "healthy",
+		"service":   "oder-management/order",
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// readyHandler reports whether the consumer has caught up with the
+// backlog it found waiting on its queue at startup.
+func (adapter *ApiServiceAdapter) readyHandler(c *gin.Context) {
+	if adapter.ready != nil {
+		select {
+		case <-adapter.ready:
+		default:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "starting"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
+}
+
+// createOrderHandler handles order creation requests. It accepts a
+// CreateOrderRequest over three wire formats: a CloudEvents 1.0
+// structured-mode envelope, CloudEvents binary mode (ce-* headers
+// alongside a plain JSON body), or the original plain JSON body for
+// producers not sending CloudEvents at all - see decodeCreateOrderRequest.
+// An Idempotency-Key header (see withIdempotency) lets a client retry this
+// call after a network failure without creating a duplicate order.
+func (adapter *ApiServiceAdapter) createOrderHandler(c *gin.Context) {
+	req, body, err := decodeCreateOrderRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adapter.withIdempotency(c, body, func() (int, any) {
+		order, err := adapter.orderService.CreateOrder(c.Request.Context(), req.CustomerID, req.ProductID, req.Quantity, req.TotalAmount)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("failed to create order", "error", err)
+			return http.StatusInternalServerError, gin.H{"error": "Failed to create order"}
+		}
+		return http.StatusCreated, order
+	})
+}
+
+// decodeCreateOrderRequest reads a CreateOrderRequest and the raw request
+// body (needed by withIdempotency's fingerprint) off c.Request, in
+// whichever of the three supported wire formats it arrives as:
+//
+//   - CloudEvents 1.0 structured mode: Content-Type application/cloudevents+json,
+//     the envelope's "data" unwrapped via pkg/eventing.Envelope.DataAs.
+//   - CloudEvents 1.0 binary mode: the ce-specversion/ce-type transport
+//     headers from pkg/eventing.Attributes.Headers, body is the plain
+//     CreateOrderRequest JSON.
+//   - Legacy: no CloudEvents signal at all, body is the plain
+//     CreateOrderRequest JSON, same as before this adapter spoke CloudEvents.
+//
+// Both CloudEvents paths reject a "type" other than orderCreateEventType,
+// so a misrouted event fails fast instead of being silently accepted.
+func decodeCreateOrderRequest(c *gin.Context) (CreateOrderRequest, []byte, error) {
+	var req CreateOrderRequest
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return req, nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	if c.GetHeader("Content-Type") == "application/cloudevents+json" {
+		envelope, err := eventing.Decode(body)
+		if err != nil {
+			return req, body, fmt.Errorf("invalid cloudevents envelope: %w", err)
+		}
+		if envelope.Type != orderCreateEventType {
+			return req, body, fmt.Errorf("unsupported cloudevents type %q, expected %q", envelope.Type, orderCreateEventType)
+		}
+		if err := envelope.DataAs(&req); err != nil {
+			return req, body, fmt.Errorf("invalid cloudevents data: %w", err)
+		}
+		return req, body, validateCreateOrderRequest(req)
+	}
+
+	if specVersion := c.GetHeader(eventing.HeaderSpecVersion); specVersion != "" {
+		if ceType := c.GetHeader(eventing.HeaderType); ceType != orderCreateEventType {
+			return req, body, fmt.Errorf("unsupported cloudevents type %q, expected %q", ceType, orderCreateEventType)
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			return req, body, fmt.Errorf("invalid cloudevents binary-mode data: %w", err)
+		}
+		return req, body, validateCreateOrderRequest(req)
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return req, body, fmt.Errorf("invalid request body: %w", err)
+	}
+	return req, body, validateCreateOrderRequest(req)
+}
+
+// withIdempotency runs fn - a handler that would otherwise always execute
+// and write its own JSON response - replaying any response already stored
+// for c's Idempotency-Key header instead, or storing fn's result under
+// that key for the next retry. Requests without the header, or an adapter
+// with no idempotencyStore configured, always just run fn and c.JSON the
+// result, unchanged from before idempotency support existed.
+//
+// A fingerprint of method+path+body is stored alongside the response so a
+// key reused with a genuinely different request is rejected with 409
+// Conflict rather than silently replaying the wrong response. Only
+// responses with a status below 500 are stored, so a transient failure
+// (e.g. a database blip) can still be retried under the same key instead
+// of being replayed forever.
+//
+// The Get-then-fn-then-Put sequence runs inside idempotencyStore.WithLock,
+// so two concurrent requests carrying the same Idempotency-Key can't both
+// observe a Get miss and run fn - one waits for the other to finish and
+// then replays its stored response instead. The actual gin response is
+// only written after WithLock returns, so the lock isn't held for the
+// duration of writing to the client.
+func (adapter *ApiServiceAdapter) withIdempotency(c *gin.Context, body []byte, fn func() (int, any)) {
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" || adapter.idempotencyStore == nil {
+		status, response := fn()
+		c.JSON(status, response)
+		return
+	}
+
+	fingerprint := fingerprintRequest(c.Request.Method, c.Request.URL.Path, body)
+
+	var (
+		status     int
+		response   any
+		replayBody []byte
+		conflict   bool
+	)
+
+	err := adapter.idempotencyStore.WithLock(key, func() error {
+		if record, exists, err := adapter.idempotencyStore.Get(key); err != nil {
+			log.Printf("Idempotency store lookup failed for key %s: %v", key, err)
+		} else if exists {
+			if record.Fingerprint != fingerprint {
+				conflict = true
+				return nil
+			}
+			status = record.StatusCode
+			replayBody = record.ResponseBody
+			return nil
+		}
+
+		status, response = fn()
+
+		if status < http.StatusInternalServerError {
+			if responseBody, err := json.Marshal(response); err != nil {
+				log.Printf("Idempotency store: failed to marshal response for key %s: %v", key, err)
+			} else if err := adapter.idempotencyStore.Put(key, domain.IdempotencyRecord{
+				Fingerprint:  fingerprint,
+				StatusCode:   status,
+				ResponseBody: responseBody,
+				CreatedAt:    time.Now(),
+			}); err != nil {
+				log.Printf("Idempotency store: failed to persist response for key %s: %v", key, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Idempotency store: WithLock failed for key %s: %v", key, err)
+	}
+
+	if conflict {
+		c.JSON(http.StatusConflict, gin.H{"error": "Idempotency-Key was already used with a different request"})
+		return
+	}
+	if replayBody != nil {
+		c.Data(status, "application/json; charset=utf-8", replayBody)
+		return
+	}
+	c.JSON(status, response)
+}
+
+// fingerprintRequest hashes method, path and body into the value
+// withIdempotency compares against a stored IdempotencyRecord's
+// Fingerprint.
+func fingerprintRequest(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// validateCreateOrderRequest enforces the same constraints as
+// CreateOrderRequest's `binding` tags, which only gin's own ShouldBindJSON
+// applies automatically; decodeCreateOrderRequest unmarshals the request by
+// hand in all three wire formats and so needs this to reject malformed
+// requests.
+func validateCreateOrderRequest(req CreateOrderRequest) error {
+	switch {
+	case req.CustomerID == "":
+		return fmt.Errorf("customer_id is required")
+	case req.ProductID == "":
+		return fmt.Errorf("product_id is required")
+	case req.Quantity < 1:
+		return fmt.Errorf("quantity must be at least 1")
+	case req.TotalAmount < 0:
+		return fmt.Errorf("total_amount must be at least 0")
+	default:
+		return nil
+	}
+}
+
+// getAllOrdersHandler handles requests to get all orders
+func (adapter *ApiServiceAdapter) getAllOrdersHandler(c *gin.Context) {
+	orders, err := adapter.orderService.GetAllOrders()
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to get orders", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// getOrderHandler handles requests to get a specific order
+func (adapter *ApiServiceAdapter) getOrderHandler(c *gin.Context) {
+	id := c.Param("id")
+
+	order, err := adapter.orderService.GetOrder(id)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to get order", "order_id", id, "error", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// updateOrderStatusHandler handles requests to update order status. An
+// Idempotency-Key header (see withIdempotency) lets a client retry this
+// call after a network failure without re-applying the status change.
+func (adapter *ApiServiceAdapter) updateOrderStatusHandler(c *gin.Context) {
+	id := c.Param("id")
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+
+	var req UpdateOrderStatusRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.Status == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status is required"})
+		return
+	}
+
+	adapter.withIdempotency(c, body, func() (int, any) {
+		order, err := adapter.orderService.UpdateOrderStatus(c.Request.Context(), id, req.Status)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("failed to update order status", "order_id", id, "error", err)
+			return http.StatusNotFound, gin.H{"error": "Order not found"}
+		}
+		return http.StatusOK, order
+	})
+}
+
+// Start begins the HTTP server
+func (adapter *ApiServiceAdapter) Start(ctx context.Context) {
+	log.Printf("Starting HTTP API service adapter on port %s...", adapter.port)
+
+	// Start server in a goroutine
+	go func() {
+		if err := adapter.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("HTTP server error: %v", err)
+		}
+	}()
+
+	// Wait for context cancellation
+	<-ctx.Done()
+	log.Println("HTTP API service adapter stopping...")
+
+	// Graceful shutdown
+	adapter.Stop()
+}
+
+// Stop gracefully shuts down the HTTP server
+func (adapter *ApiServiceAdapter) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := adapter.server.Shutdown(ctx); err != nil {
+		log.Printf("HTTP server shutdown error: %v", err)
+	} else {
+		log.Println("HTTP API service adapter stopped gracefully")
+	}
+}
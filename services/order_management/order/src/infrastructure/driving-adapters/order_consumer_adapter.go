@@ -4,12 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/pkg/eventing"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/pkg/logging"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// ledgerKeyed is implemented by every event type translateMessage can
+// produce, giving each a stable identifier for the ProcessedEventLedger.
+type ledgerKeyed interface {
+	LedgerKey() string
+}
+
 // OrderConsumerAdapter is responsible for consuming order events from RabbitMQ
 // and translating them into domain events for the application layer
 type OrderConsumerAdapter struct {
@@ -19,10 +28,23 @@ type OrderConsumerAdapter struct {
 	exchangeName string
 	routingKey   string
 	eventHandler domain.OrderEventHandler
+	// ledger, when non-nil, suppresses re-dispatching an event that was
+	// already processed, so a RabbitMQ requeue after a crash doesn't re-run
+	// the handler's side effects.
+	ledger domain.ProcessedEventLedger
+	// duplicatesSuppressed counts events short-circuited via the ledger,
+	// logged as the consumer.duplicate_suppressed metric.
+	duplicatesSuppressed int64
+	// processedCount counts every delivery this adapter has acked, whether
+	// dispatched or suppressed as a duplicate. ReadinessChecker treats it as
+	// this consumer's analogue of a Kafka consumer group's committed offset.
+	processedCount int64
 }
 
-// NewOrderConsumerAdapter creates a new OrderConsumerAdapter
-func NewOrderConsumerAdapter(rabbitMQURL, exchangeName, queueName, routingKey string, eventHandler domain.OrderEventHandler) (*OrderConsumerAdapter, error) {
+// NewOrderConsumerAdapter creates a new OrderConsumerAdapter. ledger may be
+// nil, in which case redelivered events are dispatched to eventHandler
+// again on every delivery.
+func NewOrderConsumerAdapter(rabbitMQURL, exchangeName, queueName, routingKey string, eventHandler domain.OrderEventHandler, ledger domain.ProcessedEventLedger) (*OrderConsumerAdapter, error) {
 	conn, err := amqp.Dial(rabbitMQURL)
 	if err != nil {
 		return nil, err
@@ -86,6 +108,7 @@ func NewOrderConsumerAdapter(rabbitMQURL, exchangeName, queueName, routingKey st
 		exchangeName: exchangeName,
 		routingKey:   routingKey,
 		eventHandler: eventHandler,
+		ledger:       ledger,
 	}, nil
 }
 
@@ -128,13 +151,33 @@ func (adapter *OrderConsumerAdapter) Start(ctx context.Context) {
 				continue
 			}
 
-			// Handle the event through the application layer based on event type
+			// Short-circuit redelivered events: a crash between handling and
+			// acking can cause RabbitMQ to requeue a message whose side
+			// effects already landed.
+			if adapter.ledger != nil {
+				if keyed, ok := event.(ledgerKeyed); ok {
+					if seen, err := adapter.ledger.SeenBefore(keyed.LedgerKey()); err != nil {
+						log.Printf("Error checking processed-event ledger for %s: %v", keyed.LedgerKey(), err)
+					} else if seen {
+						atomic.AddInt64(&adapter.duplicatesSuppressed, 1)
+						log.Printf("consumer.duplicate_suppressed: event %s already processed, acking without redispatch", keyed.LedgerKey())
+						delivery.Ack(false)
+						atomic.AddInt64(&adapter.processedCount, 1)
+						continue
+					}
+				}
+			}
+
+			// Handle the event through the application layer based on event
+			// type. Each event's TraceParent (propagated from whatever
+			// published it, or empty if none was supplied) becomes the
+			// correlation id for every log line the handler emits.
 			var handlingErr error
 			switch e := event.(type) {
 			case domain.OrderDamageEvent:
-				handlingErr = adapter.eventHandler.HandleOrderDamageEvent(e)
+				handlingErr = adapter.eventHandler.HandleOrderDamageEvent(logging.WithRequestID(ctx, e.TraceParent), e)
 			case domain.OrderEvent:
-				handlingErr = adapter.eventHandler.HandleOrderEvent(e)
+				handlingErr = adapter.eventHandler.HandleOrderEvent(logging.WithRequestID(ctx, e.TraceParent), e)
 			default:
 				log.Printf("Unknown event type received: %T", e)
 				delivery.Nack(false, false) // Reject unknown event types
@@ -145,7 +188,15 @@ func (adapter *OrderConsumerAdapter) Start(ctx context.Context) {
 				log.Printf("Error handling event: %v", handlingErr)
 				delivery.Nack(false, true) // Reject and requeue for retry
 			} else {
+				if adapter.ledger != nil {
+					if keyed, ok := event.(ledgerKeyed); ok {
+						if err := adapter.ledger.MarkProcessed(keyed.LedgerKey(), "ok"); err != nil {
+							log.Printf("Error recording processed event %s: %v", keyed.LedgerKey(), err)
+						}
+					}
+				}
 				delivery.Ack(false) // Acknowledge successful processing
+				atomic.AddInt64(&adapter.processedCount, 1)
 			}
 		}
 	}
@@ -162,7 +213,18 @@ func (adapter *OrderConsumerAdapter) translateMessage(body []byte) (interface{},
 		}
 	}
 
-	// Try to unmarshal as regular order event
+	// Order events are published as CloudEvents 1.0 structured-mode
+	// envelopes; unwrap the envelope when present. This falls back to the
+	// legacy raw OrderEvent payload below for producers not yet migrated.
+	if envelope, err := eventing.Decode(body); err == nil {
+		var event domain.OrderEvent
+		if err := envelope.DataAs(&event); err == nil {
+			event.TraceParent = envelope.TraceParent
+			return event, nil
+		}
+	}
+
+	// Try to unmarshal as a legacy (pre-CloudEvents) order event
 	var event domain.OrderEvent
 	if err := json.Unmarshal(body, &event); err == nil {
 		return event, nil
@@ -179,21 +241,59 @@ func (adapter *OrderConsumerAdapter) translateMessage(body []byte) (interface{},
 	return event, nil
 }
 
-// handleOrderDamageEvent processes order damage events from MQTT
+// handleOrderDamageEvent processes order damage events from MQTT. The MQTT
+// publisher wraps these as CloudEvents 1.0 structured-mode envelopes (see
+// mqtt-order-event-client/cloudevents.Wrap), so the nested payload is
+// unwrapped the same way as CloudEvents OrderEvents above before falling
+// back to the legacy raw payload.
 func (adapter *OrderConsumerAdapter) handleOrderDamageEvent(mqttEvent domain.MQTTOrderEvent) (domain.OrderDamageEvent, error) {
 	var damageEvent domain.OrderDamageEvent
-	
-	// Parse the nested JSON payload
-	if err := json.Unmarshal([]byte(mqttEvent.Payload), &damageEvent); err != nil {
+
+	payload := []byte(mqttEvent.Payload)
+	if envelope, err := eventing.Decode(payload); err == nil {
+		if err := envelope.DataAs(&damageEvent); err == nil {
+			damageEvent.TraceParent = envelope.TraceParent
+			log.Printf("Received order damage event: OrderID=%s, Severity=%s, Description=%s",
+				damageEvent.OrderID, damageEvent.Severity, damageEvent.Description)
+			return damageEvent, nil
+		}
+	}
+
+	// Parse the legacy (pre-CloudEvents) nested JSON payload
+	if err := json.Unmarshal(payload, &damageEvent); err != nil {
 		return damageEvent, err
 	}
 
-	log.Printf("Received order damage event: OrderID=%s, Severity=%s, Description=%s", 
+	log.Printf("Received order damage event: OrderID=%s, Severity=%s, Description=%s",
 		damageEvent.OrderID, damageEvent.Severity, damageEvent.Description)
-	
+
 	return damageEvent, nil
 }
 
+// DuplicatesSuppressed returns the running count of redelivered events
+// short-circuited via the ledger, i.e. the consumer.duplicate_suppressed
+// metric.
+func (adapter *OrderConsumerAdapter) DuplicatesSuppressed() int64 {
+	return atomic.LoadInt64(&adapter.duplicatesSuppressed)
+}
+
+// ProcessedCount returns the running count of deliveries this adapter has
+// acked, whether dispatched or suppressed as a duplicate. It's this
+// consumer's analogue of a Kafka consumer group's committed offset, used by
+// ReadinessChecker to tell whether the startup backlog has drained.
+func (adapter *OrderConsumerAdapter) ProcessedCount() int64 {
+	return atomic.LoadInt64(&adapter.processedCount)
+}
+
+// NewReadinessChecker returns a ReadinessChecker that blocks until this
+// adapter has processed at least as many deliveries as were queued on
+// queueName when it started, or timeout elapses; see ReadinessChecker's
+// doc comment for how this adapts the Knative eventing-kafka
+// OffsetsChecker pattern to RabbitMQ.
+func (adapter *OrderConsumerAdapter) NewReadinessChecker(timeout time.Duration) *ReadinessChecker {
+	return NewReadinessChecker(adapter.channel, adapter.queueName, adapter.ProcessedCount, timeout)
+}
+
 // Close closes the RabbitMQ connection and channel
 func (adapter *OrderConsumerAdapter) Close() error {
 	if adapter.channel != nil {
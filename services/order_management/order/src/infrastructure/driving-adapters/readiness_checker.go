@@ -0,0 +1,99 @@
+package drivingadapters
+
+import (
+	"context"
+	"log"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ReadinessChecker blocks a startup readiness gate until OrderConsumerAdapter
+// has worked through the backlog that was waiting on its queue when it
+// started, or a configurable timeout elapses. It adapts the Knative
+// eventing-kafka OffsetsChecker pattern - compare a Kafka consumer group's
+// committed offsets against each partition's end-offset fetched at startup,
+// and don't route traffic until they match - to RabbitMQ: OrderConsumerAdapter
+// consumes over AMQP, which has no consumer-group partition offsets, so the
+// backlog baseline here is the queue's message count at startup and
+// "committed" is the number of deliveries the adapter has acked since.
+type ReadinessChecker struct {
+	channel   *amqp.Channel
+	queueName string
+	processed func() int64
+	timeout   time.Duration
+	pollEvery time.Duration
+	readyCh   chan struct{}
+}
+
+// NewReadinessChecker creates a ReadinessChecker for queueName. processed
+// should return the consumer's running acked-delivery count (e.g.
+// OrderConsumerAdapter.ProcessedCount). timeout bounds how long Start waits
+// before reporting ready anyway, so a pod isn't held out of rotation
+// forever by a backlog that keeps being replenished.
+func NewReadinessChecker(channel *amqp.Channel, queueName string, processed func() int64, timeout time.Duration) *ReadinessChecker {
+	return &ReadinessChecker{
+		channel:   channel,
+		queueName: queueName,
+		processed: processed,
+		timeout:   timeout,
+		pollEvery: 500 * time.Millisecond,
+		readyCh:   make(chan struct{}),
+	}
+}
+
+// Start launches the readiness probe in a background goroutine. It reads
+// queueName's message count as the startup backlog, then polls processed
+// until it has advanced by at least that much or timeout elapses, closing
+// Ready() either way so callers never block forever.
+func (r *ReadinessChecker) Start(ctx context.Context) {
+	go func() {
+		defer close(r.readyCh)
+
+		backlog, err := r.queueDepth()
+		if err != nil {
+			log.Printf("Readiness checker: failed to read initial depth of queue %s, reporting ready immediately: %v", r.queueName, err)
+			return
+		}
+		if backlog == 0 {
+			return
+		}
+
+		target := r.processed() + backlog
+		log.Printf("Readiness checker: waiting for consumer to process %d backlog message(s) from queue %s before reporting ready", backlog, r.queueName)
+
+		deadline := time.NewTimer(r.timeout)
+		defer deadline.Stop()
+		ticker := time.NewTicker(r.pollEvery)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-deadline.C:
+				log.Printf("Readiness checker: timed out after %s waiting for queue %s backlog to drain, reporting ready anyway", r.timeout, r.queueName)
+				return
+			case <-ticker.C:
+				if r.processed() >= target {
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (r *ReadinessChecker) queueDepth() (int64, error) {
+	q, err := r.channel.QueueInspect(r.queueName)
+	if err != nil {
+		return 0, err
+	}
+	return int64(q.Messages), nil
+}
+
+// Ready returns a channel that's closed once the readiness probe has either
+// observed the startup backlog drain or timed out. The HTTP /ready handler
+// selects on it to decide whether to return 200.
+func (r *ReadinessChecker) Ready() <-chan struct{} {
+	return r.readyCh
+}
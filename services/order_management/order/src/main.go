@@ -11,6 +11,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/application"
 	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/config"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/domain"
 	drivingadapters "github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/infrastructure/driving-adapters"
 	drivenadapters "github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/oder/src/infrastructure/driven-adapters"
 )
@@ -34,21 +35,59 @@ func main() {
 	defer cancel()
 
 	// Initialize driven adapters (infrastructure)
-	// Order repository for data persistence
-	orderRepo := drivenadapters.NewMemoryOrderRepository()
-	
+	// Order repository for data persistence; REPO_DRIVER selects memory
+	// (default, used in tests) or postgres (transactional outbox).
+	var orderRepo domain.OrderRepository
+	var postgresRepo *drivenadapters.PostgresOrderRepository
+	var err error
+	switch cfg.Postgres.RepoDriver {
+	case "postgres":
+		postgresRepo, err = drivenadapters.NewPostgresOrderRepository(cfg.Postgres.DSN)
+		if err != nil {
+			log.Fatalf("Failed to create Postgres order repository: %v", err)
+		}
+		defer postgresRepo.Close()
+		orderRepo = postgresRepo
+	default:
+		orderRepo = drivenadapters.NewMemoryOrderRepository()
+	}
+
 	// RabbitMQ publisher for event publishing
 	eventPublisher, err := drivenadapters.NewRabbitMQPublisher(
+		ctx,
 		cfg.RabbitMQ.URL,
 		cfg.RabbitMQ.ExchangeName,
 		cfg.RabbitMQ.PublisherQueueName,
 		cfg.RabbitMQ.PublisherRoutingKey,
+		cfg.RabbitMQ.CloudEventsMode,
 	)
 	if err != nil {
 		log.Fatalf("Failed to create RabbitMQ publisher: %v", err)
 	}
 	defer eventPublisher.Close()
 
+	// When using Postgres, the outbox dispatcher delivers events written by
+	// SaveWithOutboxEvent instead of OrderService publishing them inline.
+	if postgresRepo != nil {
+		outboxDispatcher := drivenadapters.NewOutboxDispatcher(postgresRepo.DB(), eventPublisher, cfg.Postgres.OutboxPollInterval)
+		go outboxDispatcher.Start(ctx)
+	}
+
+	// Processed-event ledger for idempotent consumption, shared across
+	// replicas when backed by Postgres; same REPO_DRIVER toggle as orderRepo.
+	var processedEventLedger domain.ProcessedEventLedger
+	if postgresRepo != nil {
+		processedEventLedger = drivenadapters.NewPostgresProcessedEventLedger(postgresRepo.DB())
+	} else {
+		processedEventLedger = drivenadapters.NewMemoryProcessedEventLedger()
+	}
+
+	// Idempotency store backing ApiServiceAdapter's Idempotency-Key
+	// handling, so a client retrying a request after a network failure
+	// replays the original response instead of re-running the handler.
+	idempotencyStore := drivenadapters.NewMemoryIdempotencyStore(cfg.Idempotency.TTL)
+	go idempotencyStore.Start(ctx, cfg.Idempotency.SweepInterval)
+
 	// Initialize application layer (business logic)
 	orderService := application.NewOrderService(orderRepo, eventPublisher)
 
@@ -60,14 +99,21 @@ func main() {
 		cfg.RabbitMQ.ConsumerQueueName,
 		cfg.RabbitMQ.ConsumerRoutingKey,
 		orderService,
+		processedEventLedger,
 	)
 	if err != nil {
 		log.Fatalf("Failed to create order consumer adapter: %v", err)
 	}
 	defer orderConsumerAdapter.Close()
-	
+
+	// Readiness probe: blocks /ready until orderConsumerAdapter has worked
+	// through the backlog waiting on its queue at startup, so Kubernetes
+	// doesn't route traffic to a pod whose consumer is still catching up.
+	readinessChecker := orderConsumerAdapter.NewReadinessChecker(cfg.Readiness.Timeout)
+	readinessChecker.Start(ctx)
+
 	// API service adapter for synchronous HTTP requests
-	apiServiceAdapter := drivingadapters.NewApiServiceAdapter(cfg.HTTP.Port, orderService)
+	apiServiceAdapter := drivingadapters.NewApiServiceAdapter(cfg.HTTP.Port, orderService, readinessChecker.Ready(), idempotencyStore)
 
 	// Start the order consumer adapter in a goroutine
 	go orderConsumerAdapter.Start(ctx)
@@ -0,0 +1,146 @@
+// Package eventing implements a minimal CloudEvents 1.0 envelope, in both
+// structured mode (the context attributes and event payload travel together
+// as one JSON body) and binary mode (the attributes as "ce-*" transport
+// headers alongside a value that is the event's own JSON payload), used to
+// give order events a stable id, source, type and subject for cross-service
+// tracing and schema evolution instead of ad-hoc JSON.
+package eventing
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SpecVersion is the CloudEvents spec version this package implements.
+const SpecVersion = "1.0"
+
+// Envelope is the CloudEvents 1.0 structured-mode JSON representation: the
+// context attributes and the event data travel together as one JSON body.
+type Envelope struct {
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	SpecVersion     string          `json:"specversion"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	TraceParent     string          `json:"traceparent,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// Wrap builds a structured-mode CloudEvents envelope around data: data is
+// marshaled to JSON and a fresh event id is generated, as the spec requires
+// one id per emission. traceParent is the W3C traceparent propagated from
+// the context that triggered this event, and may be empty.
+func Wrap(source, ceType, subject, traceParent string, data any) (Envelope, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	return Envelope{
+		ID:              uuid.New().String(),
+		Source:          source,
+		SpecVersion:     SpecVersion,
+		Type:            ceType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		TraceParent:     traceParent,
+		Data:            payload,
+	}, nil
+}
+
+// Encode serializes env as its on-wire structured-mode JSON body.
+func Encode(env Envelope) ([]byte, error) {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cloudevents envelope: %w", err)
+	}
+	return body, nil
+}
+
+// Decode parses raw as a structured-mode CloudEvents envelope. It returns an
+// error if the mandatory "specversion" attribute is missing or empty, which
+// callers use to detect a legacy, non-CloudEvents payload and fall back to
+// parsing it directly.
+func Decode(raw []byte) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return Envelope{}, fmt.Errorf("failed to unmarshal cloudevents envelope: %w", err)
+	}
+	if env.SpecVersion == "" {
+		return Envelope{}, fmt.Errorf("not a cloudevents envelope: missing specversion")
+	}
+	return env, nil
+}
+
+// DataAs unmarshals the envelope's data payload into out.
+func (env Envelope) DataAs(out any) error {
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal cloudevents data: %w", err)
+	}
+	return nil
+}
+
+// Attributes holds the CloudEvents 1.0 context attributes carried as
+// binary-mode transport headers alongside the event payload, rather than
+// wrapped together in an Envelope.
+type Attributes struct {
+	ID              string
+	Source          string
+	SpecVersion     string
+	Type            string
+	Subject         string
+	Time            time.Time
+	DataContentType string
+	TraceParent     string
+}
+
+// NewAttributes builds the CloudEvents attributes for an event about to be
+// published. ID is freshly generated per emission, as the spec requires one
+// id per emission.
+func NewAttributes(source, ceType, subject, traceParent string) Attributes {
+	return Attributes{
+		ID:              uuid.New().String(),
+		Source:          source,
+		SpecVersion:     SpecVersion,
+		Type:            ceType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		TraceParent:     traceParent,
+	}
+}
+
+const (
+	HeaderID              = "ce-id"
+	HeaderSource          = "ce-source"
+	HeaderSpecVersion     = "ce-specversion"
+	HeaderType            = "ce-type"
+	HeaderSubject         = "ce-subject"
+	HeaderTime            = "ce-time"
+	HeaderDataContentType = "content-type"
+	HeaderTraceParent     = "traceparent"
+)
+
+// Headers renders attrs as AMQP binary-mode CloudEvents headers. An
+// amqp.Table is a map[string]any, so callers copy each entry into one.
+func (a Attributes) Headers() map[string]string {
+	headers := map[string]string{
+		HeaderID:              a.ID,
+		HeaderSource:          a.Source,
+		HeaderSpecVersion:     a.SpecVersion,
+		HeaderType:            a.Type,
+		HeaderSubject:         a.Subject,
+		HeaderTime:            a.Time.Format(time.RFC3339Nano),
+		HeaderDataContentType: a.DataContentType,
+	}
+	if a.TraceParent != "" {
+		headers[HeaderTraceParent] = a.TraceParent
+	}
+	return headers
+}
@@ -0,0 +1,116 @@
+// Package retry provides a small backoff-and-retry helper shared by the
+// adapters that publish or consume events, so transient errors don't
+// require bespoke sleep loops at each call site.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Classification tells Do whether an error is worth retrying or should be
+// surfaced immediately.
+type Classification int
+
+const (
+	// Retryable errors are transient and eligible for another attempt.
+	Retryable Classification = iota
+	// Terminal errors will never succeed on retry and should be returned
+	// to the caller right away.
+	Terminal
+)
+
+// Classifier decides how an error returned by the wrapped operation should
+// be treated. A nil Classifier defaults to treating every error as
+// Retryable.
+type Classifier func(err error) Classification
+
+// Policy configures the backoff schedule used by Do.
+type Policy struct {
+	// MaxAttempts caps the number of calls to op, including the first one.
+	// A value <= 0 means retry until ctx is cancelled or a Terminal error
+	// is returned.
+	MaxAttempts int
+	// InitialDelay is the wait before the second attempt.
+	InitialDelay time.Duration
+	// MaxDelay caps the backoff delay. Zero means uncapped.
+	MaxDelay time.Duration
+	// Multiplier grows the delay after each attempt (e.g. 2.0 doubles it).
+	Multiplier float64
+	// Jitter randomizes each delay by +/- this fraction (e.g. 0.2 = 20%).
+	Jitter float64
+	// Classify decides whether a failed attempt should be retried.
+	Classify Classifier
+}
+
+// Do runs op, retrying on Retryable errors according to policy until it
+// succeeds, a Terminal error is returned, attempts are exhausted, or ctx is
+// cancelled. It returns the last error encountered, or nil on success.
+func Do(ctx context.Context, op func() error, policy Policy) error {
+	classify := policy.Classify
+	if classify == nil {
+		classify = func(error) Classification { return Retryable }
+	}
+
+	delay := policy.InitialDelay
+	var lastErr error
+	for attempt := 1; policy.MaxAttempts <= 0 || attempt <= policy.MaxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := op()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if classify(err) == Terminal {
+			return err
+		}
+
+		if policy.MaxAttempts > 0 && attempt == policy.MaxAttempts {
+			break
+		}
+
+		if err := sleep(ctx, jittered(delay, policy.Jitter)); err != nil {
+			return err
+		}
+
+		delay = time.Duration(float64(delay) * policy.Multiplier)
+		if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+
+	return lastErr
+}
+
+// jittered randomizes delay by +/- fraction.
+func jittered(delay time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || delay <= 0 {
+		return delay
+	}
+	offset := float64(delay) * fraction * (rand.Float64()*2 - 1)
+	jittered := time.Duration(float64(delay) + offset)
+	if jittered < 0 {
+		return 0
+	}
+	return jittered
+}
+
+// sleep waits for d, returning early with ctx.Err() if ctx is cancelled first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
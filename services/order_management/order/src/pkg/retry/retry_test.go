@@ -0,0 +1,116 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errBoom = errors.New("boom")
+
+// TestDo_SucceedsWithoutRetrying covers the happy path RabbitMQPublisher's
+// redial supervisor relies on: a successful op returns immediately with no
+// retries.
+func TestDo_SucceedsWithoutRetrying(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), func() error {
+		calls++
+		return nil
+	}, Policy{InitialDelay: time.Millisecond, MaxAttempts: 5})
+
+	if err != nil {
+		t.Fatalf("Do() returned error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected exactly 1 call, got %d", calls)
+	}
+}
+
+// TestDo_RetriesUntilSuccess mirrors RabbitMQPublisher redialing after a
+// connection drop: op fails a few times, then succeeds, and Do returns nil.
+func TestDo_RetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return errBoom
+		}
+		return nil
+	}, Policy{InitialDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, Multiplier: 2})
+
+	if err != nil {
+		t.Fatalf("Do() returned error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls before success, got %d", calls)
+	}
+}
+
+// TestDo_GivesUpAfterMaxAttempts ensures a bounded policy (as used by
+// OrderService's publishRetryPolicy) stops retrying and surfaces the last
+// error instead of retrying forever.
+func TestDo_GivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), func() error {
+		calls++
+		return errBoom
+	}, Policy{InitialDelay: time.Millisecond, MaxAttempts: 3})
+
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected errBoom, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected exactly 3 attempts, got %d", calls)
+	}
+}
+
+// TestDo_TerminalErrorStopsImmediately ensures a Classifier marking an error
+// Terminal short-circuits further attempts, e.g. an auth failure that no
+// amount of redialing will fix.
+func TestDo_TerminalErrorStopsImmediately(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), func() error {
+		calls++
+		return errBoom
+	}, Policy{
+		InitialDelay: time.Millisecond,
+		MaxAttempts:  5,
+		Classify:     func(error) Classification { return Terminal },
+	})
+
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected errBoom, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected exactly 1 attempt before stopping on a terminal error, got %d", calls)
+	}
+}
+
+// TestDo_StopsWhenContextCancelled ensures an unbounded policy (MaxAttempts
+// 0, as RabbitMQPublisher's redialPolicy uses) still stops once ctx is
+// cancelled, rather than retrying forever.
+func TestDo_StopsWhenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- Do(ctx, func() error {
+			calls++
+			if calls == 1 {
+				cancel()
+			}
+			return errBoom
+		}, Policy{InitialDelay: 50 * time.Millisecond})
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Do() did not return after context cancellation")
+	}
+}
@@ -0,0 +1,322 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/cron"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/tracing"
+)
+
+// BatchAggregatorConfig holds the cut-batch triggers for BatchAggregator.
+type BatchAggregatorConfig struct {
+	// MaxItemsPerBatch cuts the batch once TotalItems reaches this value.
+	MaxItemsPerBatch int
+	// MaxQuantityPerBatch cuts the batch once GetTotalQuantity() reaches this value.
+	MaxQuantityPerBatch int
+	// BatchTimeout cuts the batch once this long has elapsed since CreatedAt.
+	BatchTimeout time.Duration
+	// CheckInterval is how often the timeout ticker re-evaluates open batches.
+	CheckInterval time.Duration
+}
+
+// productLine tracks the open batch state for a single product so item
+// additions and timeout-driven cuts for that product never race each other.
+type productLine struct {
+	mu     sync.Mutex
+	itemCh chan struct{}
+	stopCh chan struct{}
+}
+
+// BatchAggregator wraps BatchService with policy-driven cutting: an open
+// batch is cut (StartProcessing + Complete) as soon as it crosses a
+// BatchPolicy threshold, modeled on the Fabric orderer's broadcaster: a
+// goroutine per product watches item signals and a ticker, cuts that
+// product's open batch, and lets the next AddOrderToBatch call start a
+// fresh one.
+type BatchAggregator struct {
+	*BatchService
+	cfg        BatchAggregatorConfig
+	policyRepo domain.PolicyRepository
+
+	linesMu sync.Mutex
+	lines   map[string]*productLine
+}
+
+// NewBatchAggregator creates a new BatchAggregator on top of an existing
+// BatchService. policyRepo may be nil, in which case every product is
+// governed solely by cfg's fixed thresholds.
+func NewBatchAggregator(batchService *BatchService, cfg BatchAggregatorConfig, policyRepo domain.PolicyRepository) *BatchAggregator {
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = time.Second
+	}
+	return &BatchAggregator{
+		BatchService: batchService,
+		cfg:          cfg,
+		policyRepo:   policyRepo,
+		lines:        make(map[string]*productLine),
+	}
+}
+
+// AddOrderToBatch adds the order to the appropriate batch and evaluates the
+// product's BatchPolicy cut conditions atomically with the add, so items
+// added while a cut is in-flight always land in the freshly started batch.
+func (a *BatchAggregator) AddOrderToBatch(ctx context.Context, orderID, productID string, quantity int, status string) (batch *domain.Batch, err error) {
+	endSpan := tracing.StartSpan(ctx, "batch_aggregator.add_order_to_batch", map[string]string{
+		"order_id":   orderID,
+		"product_id": productID,
+		"status":     status,
+	})
+	defer endSpan(&err)
+
+	line := a.lineFor(productID)
+
+	line.mu.Lock()
+	defer line.mu.Unlock()
+
+	batch, err = a.BatchService.AddOrderToBatch(ctx, orderID, productID, quantity, status)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := a.effectivePolicy(productID)
+	if rule := trippedRule(batch, policy); rule != "" {
+		a.cutBatchLocked(ctx, productID, batch, rule)
+	}
+
+	select {
+	case line.itemCh <- struct{}{}:
+	default:
+	}
+
+	return batch, nil
+}
+
+// effectivePolicy merges the policy configured for productID (if any) over
+// the aggregator's cfg defaults, so a product with no explicit policy keeps
+// behaving exactly as it did before BatchPolicy existed.
+func (a *BatchAggregator) effectivePolicy(productID string) domain.BatchPolicy {
+	policy := domain.BatchPolicy{
+		ProductID:   productID,
+		MaxItems:    a.cfg.MaxItemsPerBatch,
+		MaxQuantity: a.cfg.MaxQuantityPerBatch,
+		MaxAge:      a.cfg.BatchTimeout,
+	}
+
+	if a.policyRepo == nil {
+		return policy
+	}
+
+	stored, err := a.policyRepo.FindByProductID(productID)
+	if err != nil {
+		return policy
+	}
+
+	if stored.MaxItems > 0 {
+		policy.MaxItems = stored.MaxItems
+	}
+	if stored.MaxQuantity > 0 {
+		policy.MaxQuantity = stored.MaxQuantity
+	}
+	if stored.MaxAge > 0 {
+		policy.MaxAge = stored.MaxAge
+	}
+	policy.MaxWeightKg = stored.MaxWeightKg
+	policy.CutoffCron = stored.CutoffCron
+	return policy
+}
+
+// trippedRule returns the name of the first policy trigger batch has
+// crossed, or "" if none has.
+func trippedRule(batch *domain.Batch, policy domain.BatchPolicy) string {
+	switch {
+	case policy.MaxItems > 0 && batch.TotalItems >= policy.MaxItems:
+		return "max_items"
+	case policy.MaxQuantity > 0 && batch.GetTotalQuantity() >= policy.MaxQuantity:
+		return "max_quantity"
+	case policy.MaxWeightKg > 0 && batch.GetTotalWeight() >= policy.MaxWeightKg:
+		return "max_weight_kg"
+	default:
+		return ""
+	}
+}
+
+// lineFor returns the productLine for productID, starting its watch
+// goroutine the first time the product is seen.
+func (a *BatchAggregator) lineFor(productID string) *productLine {
+	a.linesMu.Lock()
+	defer a.linesMu.Unlock()
+
+	line, exists := a.lines[productID]
+	if !exists {
+		line = &productLine{
+			itemCh: make(chan struct{}, 1),
+			stopCh: make(chan struct{}),
+		}
+		a.lines[productID] = line
+		go a.watch(productID, line)
+	}
+	return line
+}
+
+// watch periodically checks whether the open batch for productID has
+// exceeded its policy's MaxAge or CutoffCron, cutting it if so.
+func (a *BatchAggregator) watch(productID string, line *productLine) {
+	ticker := time.NewTicker(a.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-line.stopCh:
+			return
+		case <-line.itemCh:
+			a.checkAgeAndCutoff(productID, line)
+		case <-ticker.C:
+			a.checkAgeAndCutoff(productID, line)
+		}
+	}
+}
+
+// checkAgeAndCutoff cuts productID's open batch if it has exceeded its
+// policy's MaxAge, or if CutoffCron's schedule matches now.
+func (a *BatchAggregator) checkAgeAndCutoff(productID string, line *productLine) {
+	line.mu.Lock()
+	defer line.mu.Unlock()
+
+	batch, err := a.batchRepo.FindPendingBatchForProduct(productID)
+	if err != nil {
+		return
+	}
+
+	policy := a.effectivePolicy(productID)
+
+	// No inbound request/event drives this check - it's evaluated off
+	// watch's internal ticker - so there's no traceparent to carry and a
+	// plain background context is used for the resulting cut.
+	ctx := context.Background()
+
+	if policy.MaxAge > 0 && time.Since(batch.CreatedAt) >= policy.MaxAge {
+		a.cutBatchLocked(ctx, productID, batch, "max_age")
+		return
+	}
+
+	if policy.CutoffCron == "" {
+		return
+	}
+
+	schedule, err := cron.Parse(policy.CutoffCron)
+	if err != nil {
+		log.Printf("BatchAggregator: invalid cutoff_cron %q for product %s: %v", policy.CutoffCron, productID, err)
+		return
+	}
+	if schedule.Matches(time.Now()) {
+		a.cutBatchLocked(ctx, productID, batch, "cutoff_cron")
+	}
+}
+
+// ScanPolicies runs until ctx is cancelled, periodically re-evaluating every
+// pending batch's MaxAge/CutoffCron triggers regardless of whether its
+// product has an active watch goroutine yet - e.g. right after a restart, a
+// product's open batch has no in-memory productLine until its next
+// AddOrderToBatch call, so this scan is the backstop that still honors a
+// CutoffCron in the meantime.
+func (a *BatchAggregator) ScanPolicies(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.scanPendingBatches()
+		}
+	}
+}
+
+func (a *BatchAggregator) scanPendingBatches() {
+	batches, err := a.batchRepo.FindByStatus(domain.BatchStatusPending)
+	if err != nil {
+		log.Printf("BatchAggregator: policy scan failed to list pending batches: %v", err)
+		return
+	}
+
+	for _, batch := range batches {
+		line := a.lineFor(batch.ProductID)
+		a.checkAgeAndCutoff(batch.ProductID, line)
+	}
+}
+
+// cutBatchLocked cuts the batch via StartProcessing+Complete and publishes
+// the completed event (with an idempotency key hashed from the item list)
+// plus a batch.sealed_by_policy event naming the rule that fired. Callers
+// must hold the productLine's mutex.
+func (a *BatchAggregator) cutBatchLocked(ctx context.Context, productID string, batch *domain.Batch, rule string) {
+	if err := batch.StartProcessing(); err != nil {
+		log.Printf("BatchAggregator: failed to start processing batch %s: %v", batch.ID, err)
+		return
+	}
+	if err := batch.Complete(); err != nil {
+		log.Printf("BatchAggregator: failed to complete batch %s: %v", batch.ID, err)
+		return
+	}
+
+	if err := a.batchRepo.Save(ctx, batch); err != nil {
+		log.Printf("BatchAggregator: failed to save cut batch %s: %v", batch.ID, err)
+		return
+	}
+
+	idempotencyKey := idempotencyKeyFor(batch)
+
+	event := domain.NewBatchCompletedEvent(ctx, batch)
+	event.IdempotencyKey = idempotencyKey
+	if err := a.eventPublisher.PublishBatchEvent(ctx, event); err != nil {
+		log.Printf("BatchAggregator: failed to publish cut-batch event for %s: %v", batch.ID, err)
+	}
+
+	sealedEvent := domain.NewBatchSealedByPolicyEvent(ctx, batch, rule)
+	sealedEvent.IdempotencyKey = idempotencyKey
+	if err := a.eventPublisher.PublishBatchEvent(ctx, sealedEvent); err != nil {
+		log.Printf("BatchAggregator: failed to publish sealed-by-policy event for %s: %v", batch.ID, err)
+	}
+
+	log.Printf("BatchAggregator: cut batch %s for product %s (rule=%s, items=%d, quantity=%d)",
+		batch.ID, productID, rule, batch.TotalItems, batch.GetTotalQuantity())
+}
+
+// idempotencyKeyFor hashes the sorted order IDs in the batch so that a
+// retried publish of the same cut batch can be deduplicated downstream.
+func idempotencyKeyFor(batch *domain.Batch) string {
+	orderIDs := make([]string, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		orderIDs = append(orderIDs, item.OrderID)
+	}
+	sort.Strings(orderIDs)
+
+	h := sha256.New()
+	h.Write([]byte(batch.ID))
+	for _, id := range orderIDs {
+		h.Write([]byte{0})
+		h.Write([]byte(id))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Stop shuts down all per-product watch goroutines.
+func (a *BatchAggregator) Stop() {
+	a.linesMu.Lock()
+	defer a.linesMu.Unlock()
+	for _, line := range a.lines {
+		close(line.stopCh)
+	}
+}
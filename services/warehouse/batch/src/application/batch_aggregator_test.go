@@ -0,0 +1,181 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	drivenadapters "github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/driven-adapters"
+)
+
+func newTestAggregator(cfg BatchAggregatorConfig) (*BatchAggregator, *drivenadapters.BatchMemoryRepository, *domain.MockBatchEventPublisher) {
+	repo := drivenadapters.NewBatchMemoryRepository()
+	publisher := domain.NewMockBatchEventPublisher()
+	service := NewBatchService(repo, publisher, nil)
+	return NewBatchAggregator(service, cfg, nil), repo, publisher
+}
+
+func newTestAggregatorWithPolicies(cfg BatchAggregatorConfig) (*BatchAggregator, *drivenadapters.BatchMemoryRepository, *domain.MockBatchEventPublisher, *drivenadapters.PolicyMemoryRepository) {
+	repo := drivenadapters.NewBatchMemoryRepository()
+	publisher := domain.NewMockBatchEventPublisher()
+	policyRepo := drivenadapters.NewPolicyMemoryRepository()
+	service := NewBatchService(repo, publisher, nil)
+	return NewBatchAggregator(service, cfg, policyRepo), repo, publisher, policyRepo
+}
+
+func TestBatchAggregator_CutsOnMaxItems(t *testing.T) {
+	aggregator, repo, publisher := newTestAggregator(BatchAggregatorConfig{MaxItemsPerBatch: 2})
+	defer aggregator.Stop()
+
+	productID := "product-1"
+	if _, err := aggregator.AddOrderToBatch(context.Background(), "order-1", productID, 1, "allocated"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	batch, err := aggregator.AddOrderToBatch(context.Background(), "order-2", productID, 1, "allocated")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	completed, err := repo.FindByID(batch.ID)
+	if err != nil {
+		t.Fatalf("expected batch to still exist: %v", err)
+	}
+	if completed.Status != domain.BatchStatusCompleted {
+		t.Errorf("expected batch to be completed after hitting MaxItemsPerBatch, got %s", completed.Status)
+	}
+
+	events := publisher.GetEventsByType(domain.BatchEventCompleted)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 completed event, got %d", len(events))
+	}
+	if events[0].IdempotencyKey == "" {
+		t.Error("expected idempotency key to be set on the completed event")
+	}
+
+	// The next order for this product should start a brand new batch.
+	next, err := aggregator.AddOrderToBatch(context.Background(), "order-3", productID, 1, "allocated")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next.ID == batch.ID {
+		t.Error("expected order added after cut to land in a new batch")
+	}
+}
+
+func TestBatchAggregator_CutsOnMaxQuantity(t *testing.T) {
+	aggregator, repo, _ := newTestAggregator(BatchAggregatorConfig{MaxQuantityPerBatch: 10})
+	defer aggregator.Stop()
+
+	productID := "product-2"
+	batch, err := aggregator.AddOrderToBatch(context.Background(), "order-1", productID, 12, "allocated")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	completed, err := repo.FindByID(batch.ID)
+	if err != nil {
+		t.Fatalf("expected batch to still exist: %v", err)
+	}
+	if completed.Status != domain.BatchStatusCompleted {
+		t.Errorf("expected batch to be completed after hitting MaxQuantityPerBatch, got %s", completed.Status)
+	}
+}
+
+func TestBatchAggregator_CutsOnTimeout(t *testing.T) {
+	aggregator, repo, _ := newTestAggregator(BatchAggregatorConfig{
+		BatchTimeout:  20 * time.Millisecond,
+		CheckInterval: 5 * time.Millisecond,
+	})
+	defer aggregator.Stop()
+
+	productID := "product-3"
+	batch, err := aggregator.AddOrderToBatch(context.Background(), "order-1", productID, 1, "allocated")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.After(500 * time.Millisecond)
+	for {
+		completed, err := repo.FindByID(batch.ID)
+		if err == nil && completed.Status == domain.BatchStatusCompleted {
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatal("expected batch to be cut by timeout")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestBatchAggregator_PerProductPolicyOverridesGlobalConfig(t *testing.T) {
+	aggregator, repo, publisher, policyRepo := newTestAggregatorWithPolicies(BatchAggregatorConfig{MaxItemsPerBatch: 100})
+	defer aggregator.Stop()
+
+	productID := "product-5"
+	if err := policyRepo.Save(domain.BatchPolicy{ProductID: productID, MaxItems: 1}); err != nil {
+		t.Fatalf("unexpected error saving policy: %v", err)
+	}
+
+	batch, err := aggregator.AddOrderToBatch(context.Background(), "order-1", productID, 1, "allocated")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	completed, err := repo.FindByID(batch.ID)
+	if err != nil {
+		t.Fatalf("expected batch to still exist: %v", err)
+	}
+	if completed.Status != domain.BatchStatusCompleted {
+		t.Errorf("expected the product's own MaxItems=1 policy to cut the batch, got status %s", completed.Status)
+	}
+
+	sealedEvents := publisher.GetEventsByType(domain.BatchEventSealedByPolicy)
+	if len(sealedEvents) != 1 {
+		t.Fatalf("expected 1 sealed-by-policy event, got %d", len(sealedEvents))
+	}
+	if sealedEvents[0].Rule != "max_items" {
+		t.Errorf("expected rule %q, got %q", "max_items", sealedEvents[0].Rule)
+	}
+}
+
+// TestBatchAggregator_RaceAddDuringCut ensures items added while a cut is
+// in-flight land in the new batch, not the one being closed.
+func TestBatchAggregator_RaceAddDuringCut(t *testing.T) {
+	aggregator, _, _ := newTestAggregator(BatchAggregatorConfig{MaxItemsPerBatch: 1})
+	defer aggregator.Stop()
+
+	productID := "product-4"
+	var wg sync.WaitGroup
+	batchIDs := make([]string, 20)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			batch, err := aggregator.AddOrderToBatch(context.Background(), fmt.Sprintf("order-%d", i), productID, 1, "allocated")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			batchIDs[i] = batch.ID
+		}(i)
+	}
+	wg.Wait()
+
+	// With MaxItemsPerBatch=1 every order cuts its own batch immediately, so
+	// each order must have landed in a distinct batch, never a closed one.
+	seen := make(map[string]bool)
+	for _, id := range batchIDs {
+		if id == "" {
+			continue
+		}
+		if seen[id] {
+			t.Errorf("batch ID %s was reused across orders, indicating an item landed in a closed batch", id)
+		}
+		seen[id] = true
+	}
+}
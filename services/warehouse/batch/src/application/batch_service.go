@@ -1,10 +1,13 @@
 package application
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
 )
 
@@ -12,18 +15,49 @@ import (
 type BatchService struct {
 	batchRepo      domain.BatchRepository
 	eventPublisher domain.BatchEventPublisher
+	outboxRepo     domain.OutboxRepository
 }
 
-// NewBatchService creates a new BatchService
-func NewBatchService(batchRepo domain.BatchRepository, eventPublisher domain.BatchEventPublisher) *BatchService {
+// NewBatchService creates a new BatchService. outboxRepo may be nil, in
+// which case every mutating method falls back to its previous behavior:
+// save the batch, then publish each event best-effort and log on failure.
+// When outboxRepo is set (e.g. the same BatchMemoryRepository passed as
+// batchRepo), the batch save and its outgoing events are persisted
+// atomically via outboxRepo.SaveBatchAndEvents, and an infrastructure/outbox
+// relay is responsible for actually publishing them - see chunk1-4.
+func NewBatchService(batchRepo domain.BatchRepository, eventPublisher domain.BatchEventPublisher, outboxRepo domain.OutboxRepository) *BatchService {
 	return &BatchService{
 		batchRepo:      batchRepo,
 		eventPublisher: eventPublisher,
+		outboxRepo:     outboxRepo,
 	}
 }
 
+// saveAndPublish persists batch and its outgoing events as a unit: via the
+// outbox when configured, or directly against batchRepo/eventPublisher
+// otherwise. Each failed best-effort publish is only logged, matching the
+// pre-outbox behavior for services that don't wire an OutboxRepository.
+func (s *BatchService) saveAndPublish(ctx context.Context, batch *domain.Batch, events []*domain.BatchEvent) error {
+	if s.outboxRepo != nil {
+		if err := s.outboxRepo.SaveBatchAndEvents(batch, events); err != nil {
+			return fmt.Errorf("failed to save batch and outbox events: %w", err)
+		}
+		return nil
+	}
+
+	if err := s.batchRepo.Save(ctx, batch); err != nil {
+		return fmt.Errorf("failed to save batch: %w", err)
+	}
+	for _, event := range events {
+		if err := s.eventPublisher.PublishBatchEvent(ctx, event); err != nil {
+			log.Printf("Failed to publish %s event: %v", event.EventType, err)
+		}
+	}
+	return nil
+}
+
 // AddOrderToBatch adds an order to an appropriate batch
-func (s *BatchService) AddOrderToBatch(orderID, productID string, quantity int, status string) (*domain.Batch, error) {
+func (s *BatchService) AddOrderToBatch(ctx context.Context, orderID, productID string, quantity int, status string) (*domain.Batch, error) {
 	log.Printf("Adding order %s to batch for product %s (quantity: %d, status: %s)", 
 		orderID, productID, quantity, status)
 
@@ -45,17 +79,9 @@ func (s *BatchService) AddOrderToBatch(orderID, productID string, quantity int,
 		return nil, fmt.Errorf("failed to add order to batch: %w", err)
 	}
 
-	// Save the batch
-	if err := s.batchRepo.Save(batch); err != nil {
-		return nil, fmt.Errorf("failed to save batch: %w", err)
-	}
-
-	// Publish events
+	var events []*domain.BatchEvent
 	if isNewBatch {
-		// Publish batch created event
-		if err := s.eventPublisher.PublishBatchEvent(domain.NewBatchCreatedEvent(batch)); err != nil {
-			log.Printf("Failed to publish batch created event: %v", err)
-		}
+		events = append(events, domain.NewBatchCreatedEvent(ctx, batch))
 	}
 
 	// Get the added item for the event
@@ -63,10 +89,11 @@ func (s *BatchService) AddOrderToBatch(orderID, productID string, quantity int,
 	if err != nil {
 		log.Printf("Failed to get item for event publishing: %v", err)
 	} else {
-		// Publish item added event
-		if err := s.eventPublisher.PublishBatchEvent(domain.NewBatchItemAddedEvent(batch, orderID, item)); err != nil {
-			log.Printf("Failed to publish batch item added event: %v", err)
-		}
+		events = append(events, domain.NewBatchItemAddedEvent(ctx, batch, orderID, item))
+	}
+
+	if err := s.saveAndPublish(ctx, batch, events); err != nil {
+		return nil, err
 	}
 
 	log.Printf("Successfully added order %s to batch %s", orderID, batch.ID)
@@ -74,7 +101,7 @@ func (s *BatchService) AddOrderToBatch(orderID, productID string, quantity int,
 }
 
 // RemoveOrderFromBatch removes an order from its batch
-func (s *BatchService) RemoveOrderFromBatch(orderID string) error {
+func (s *BatchService) RemoveOrderFromBatch(ctx context.Context, orderID string) error {
 	log.Printf("Removing order %s from batch", orderID)
 
 	// Find the batch containing this order
@@ -88,20 +115,20 @@ func (s *BatchService) RemoveOrderFromBatch(orderID string) error {
 		return fmt.Errorf("failed to remove order from batch: %w", err)
 	}
 
-	// Publish item removed event
-	if err := s.eventPublisher.PublishBatchEvent(domain.NewBatchItemRemovedEvent(batch, orderID)); err != nil {
-		log.Printf("Failed to publish batch item removed event: %v", err)
-	}
-
-	// If batch is empty, delete it; otherwise save the updated batch
+	// If batch is empty, delete it; otherwise save the updated batch and its
+	// event via the outbox. Deletion has no outbox row to save against, so
+	// the removed event is always published best-effort in that case.
 	if batch.IsEmpty() {
 		log.Printf("Batch %s is now empty, deleting it", batch.ID)
+		if err := s.eventPublisher.PublishBatchEvent(ctx, domain.NewBatchItemRemovedEvent(ctx, batch, orderID)); err != nil {
+			log.Printf("Failed to publish batch item removed event: %v", err)
+		}
 		if err := s.batchRepo.Delete(batch.ID); err != nil {
 			return fmt.Errorf("failed to delete empty batch: %w", err)
 		}
 	} else {
-		if err := s.batchRepo.Save(batch); err != nil {
-			return fmt.Errorf("failed to save updated batch: %w", err)
+		if err := s.saveAndPublish(ctx, batch, []*domain.BatchEvent{domain.NewBatchItemRemovedEvent(ctx, batch, orderID)}); err != nil {
+			return err
 		}
 	}
 
@@ -110,7 +137,7 @@ func (s *BatchService) RemoveOrderFromBatch(orderID string) error {
 }
 
 // UpdateOrderStatus updates the status of an order within its batch
-func (s *BatchService) UpdateOrderStatus(orderID, status string) error {
+func (s *BatchService) UpdateOrderStatus(ctx context.Context, orderID, status string) error {
 	log.Printf("Updating order %s status to %s", orderID, status)
 
 	// Find the batch containing this order
@@ -124,20 +151,17 @@ func (s *BatchService) UpdateOrderStatus(orderID, status string) error {
 		return fmt.Errorf("failed to update order status: %w", err)
 	}
 
-	// Save the updated batch
-	if err := s.batchRepo.Save(batch); err != nil {
-		return fmt.Errorf("failed to save updated batch: %w", err)
-	}
-
 	// Get the updated item for the event
+	var events []*domain.BatchEvent
 	item, err := batch.GetItemByOrderID(orderID)
 	if err != nil {
 		log.Printf("Failed to get updated item for event publishing: %v", err)
 	} else {
-		// Publish item updated event
-		if err := s.eventPublisher.PublishBatchEvent(domain.NewBatchItemUpdatedEvent(batch, orderID, item)); err != nil {
-			log.Printf("Failed to publish batch item updated event: %v", err)
-		}
+		events = append(events, domain.NewBatchItemUpdatedEvent(ctx, batch, orderID, item))
+	}
+
+	if err := s.saveAndPublish(ctx, batch, events); err != nil {
+		return err
 	}
 
 	log.Printf("Successfully updated order %s status to %s in batch %s", orderID, status, batch.ID)
@@ -145,7 +169,7 @@ func (s *BatchService) UpdateOrderStatus(orderID, status string) error {
 }
 
 // ProcessBatch starts processing a batch
-func (s *BatchService) ProcessBatch(batchID string) error {
+func (s *BatchService) ProcessBatch(ctx context.Context, batchID string) error {
 	log.Printf("Starting to process batch %s", batchID)
 
 	batch, err := s.batchRepo.FindByID(batchID)
@@ -157,13 +181,8 @@ func (s *BatchService) ProcessBatch(batchID string) error {
 		return fmt.Errorf("failed to start processing batch: %w", err)
 	}
 
-	if err := s.batchRepo.Save(batch); err != nil {
-		return fmt.Errorf("failed to save batch: %w", err)
-	}
-
-	// Publish processing started event
-	if err := s.eventPublisher.PublishBatchEvent(domain.NewBatchProcessingStartedEvent(batch)); err != nil {
-		log.Printf("Failed to publish batch processing started event: %v", err)
+	if err := s.saveAndPublish(ctx, batch, []*domain.BatchEvent{domain.NewBatchProcessingStartedEvent(ctx, batch)}); err != nil {
+		return err
 	}
 
 	log.Printf("Successfully started processing batch %s", batchID)
@@ -171,7 +190,7 @@ func (s *BatchService) ProcessBatch(batchID string) error {
 }
 
 // CompleteBatch marks a batch as completed
-func (s *BatchService) CompleteBatch(batchID string) error {
+func (s *BatchService) CompleteBatch(ctx context.Context, batchID string) error {
 	log.Printf("Completing batch %s", batchID)
 
 	batch, err := s.batchRepo.FindByID(batchID)
@@ -183,13 +202,8 @@ func (s *BatchService) CompleteBatch(batchID string) error {
 		return fmt.Errorf("failed to complete batch: %w", err)
 	}
 
-	if err := s.batchRepo.Save(batch); err != nil {
-		return fmt.Errorf("failed to save batch: %w", err)
-	}
-
-	// Publish batch completed event
-	if err := s.eventPublisher.PublishBatchEvent(domain.NewBatchCompletedEvent(batch)); err != nil {
-		log.Printf("Failed to publish batch completed event: %v", err)
+	if err := s.saveAndPublish(ctx, batch, []*domain.BatchEvent{domain.NewBatchCompletedEvent(ctx, batch)}); err != nil {
+		return err
 	}
 
 	log.Printf("Successfully completed batch %s", batchID)
@@ -197,7 +211,7 @@ func (s *BatchService) CompleteBatch(batchID string) error {
 }
 
 // CancelBatch cancels a batch
-func (s *BatchService) CancelBatch(batchID string) error {
+func (s *BatchService) CancelBatch(ctx context.Context, batchID string) error {
 	log.Printf("Cancelling batch %s", batchID)
 
 	batch, err := s.batchRepo.FindByID(batchID)
@@ -209,13 +223,8 @@ func (s *BatchService) CancelBatch(batchID string) error {
 		return fmt.Errorf("failed to cancel batch: %w", err)
 	}
 
-	if err := s.batchRepo.Save(batch); err != nil {
-		return fmt.Errorf("failed to save batch: %w", err)
-	}
-
-	// Publish batch cancelled event
-	if err := s.eventPublisher.PublishBatchEvent(domain.NewBatchCancelledEvent(batch)); err != nil {
-		log.Printf("Failed to publish batch cancelled event: %v", err)
+	if err := s.saveAndPublish(ctx, batch, []*domain.BatchEvent{domain.NewBatchCancelledEvent(ctx, batch)}); err != nil {
+		return err
 	}
 
 	log.Printf("Successfully cancelled batch %s", batchID)
@@ -223,7 +232,7 @@ func (s *BatchService) CancelBatch(batchID string) error {
 }
 
 // MarkBatchAsDamaged marks a batch as damaged
-func (s *BatchService) MarkBatchAsDamaged(batchID string) error {
+func (s *BatchService) MarkBatchAsDamaged(ctx context.Context, batchID string) error {
 	log.Printf("Marking batch %s as damaged", batchID)
 
 	batch, err := s.batchRepo.FindByID(batchID)
@@ -235,13 +244,8 @@ func (s *BatchService) MarkBatchAsDamaged(batchID string) error {
 		return fmt.Errorf("failed to mark batch as damaged: %w", err)
 	}
 
-	if err := s.batchRepo.Save(batch); err != nil {
-		return fmt.Errorf("failed to save batch: %w", err)
-	}
-
-	// Publish batch damaged event
-	if err := s.eventPublisher.PublishBatchEvent(domain.NewBatchDamagedEvent(batch)); err != nil {
-		log.Printf("Failed to publish batch damaged event: %v", err)
+	if err := s.saveAndPublish(ctx, batch, []*domain.BatchEvent{domain.NewBatchDamagedEvent(ctx, batch)}); err != nil {
+		return err
 	}
 
 	log.Printf("Successfully marked batch %s as damaged", batchID)
@@ -268,8 +272,12 @@ func (s *BatchService) GetAllBatches() ([]*domain.Batch, error) {
 	return s.batchRepo.GetAll()
 }
 
-// generateBatchID generates a unique batch ID
+// generateBatchID generates a unique batch ID. The timestamp is kept for
+// readability/sortability, but alone only has second resolution, so two
+// batches for the same product created within the same second - e.g. two
+// AddOrderToBatch calls racing to open a new batch - would otherwise collide;
+// a UUID suffix guarantees uniqueness regardless of timing.
 func (s *BatchService) generateBatchID(productID string) string {
 	timestamp := time.Now().Format("20060102150405")
-	return fmt.Sprintf("BATCH-%s-%s", productID, timestamp)
+	return fmt.Sprintf("BATCH-%s-%s-%s", productID, timestamp, uuid.New().String()[:8])
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package application
 
 import (
+	"context"
 	"time"
 
 	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
@@ -8,13 +9,13 @@ import (
 
 // BatchServiceInterface defines the contract for batch operations
 type BatchServiceInterface interface {
-	AddOrderToBatch(orderID, productID string, quantity int, status string) (*domain.Batch, error)
-	RemoveOrderFromBatch(orderID string) error
-	UpdateOrderStatus(orderID, status string) error
-	ProcessBatch(batchID string) error
-	CompleteBatch(batchID string) error
-	CancelBatch(batchID string) error
-	MarkBatchAsDamaged(batchID string) error
+	AddOrderToBatch(ctx context.Context, orderID, productID string, quantity int, status string) (*domain.Batch, error)
+	RemoveOrderFromBatch(ctx context.Context, orderID string) error
+	UpdateOrderStatus(ctx context.Context, orderID, status string) error
+	ProcessBatch(ctx context.Context, batchID string) error
+	CompleteBatch(ctx context.Context, batchID string) error
+	CancelBatch(ctx context.Context, batchID string) error
+	MarkBatchAsDamaged(ctx context.Context, batchID string) error
 	GetBatchByOrderID(orderID string) (*domain.Batch, error)
 	GetBatchesByProductID(productID string) ([]*domain.Batch, error)
 	GetBatchesByStatus(status domain.BatchStatus) ([]*domain.Batch, error)
@@ -1,27 +1,177 @@
 package application
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/application/saga"
 	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/tracing"
 )
 
 // OrderService handles business logic for order events
 type OrderService struct {
-	batchService *BatchService
+	batchService BatchServiceInterface
+	sagas        *saga.Orchestrator
 }
 
-// NewOrderService creates a new OrderService
-func NewOrderService(batchService *BatchService) *OrderService {
+// NewOrderService creates a new OrderService. sagas may be nil, in which
+// case allocateInventory falls back to calling the batch service directly
+// instead of running the allocate_inventory saga.
+func NewOrderService(batchService BatchServiceInterface, sagas *saga.Orchestrator) *OrderService {
 	return &OrderService{
 		batchService: batchService,
+		sagas:        sagas,
 	}
 }
 
-// HandleOrderEvent processes the received order event
-func (s *OrderService) HandleOrderEvent(event domain.OrderEvent) error {
-	log.Printf("Received order event: Type=%s, OrderID=%s, Status=%s", 
+// allocateInventorySagaType identifies the saga.Definition built by
+// NewAllocateInventorySagaDefinition in the Orchestrator's registry.
+const allocateInventorySagaType = "allocate_inventory"
+
+// allocateInventoryPayload is the saga payload for the allocate_inventory
+// saga: the fields of a newly created order needed to add it to a batch and
+// confirm the resulting allocation.
+type allocateInventoryPayload struct {
+	OrderID   string `json:"order_id"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// NewAllocateInventorySagaDefinition builds the saga.Definition for the
+// order.created -> allocate_inventory flow: add the order to a batch, then
+// confirm the allocation, compensating either step by removing the order
+// from the batch.
+func NewAllocateInventorySagaDefinition(batchService BatchServiceInterface) saga.Definition {
+	// saga.StepFunc/CompensateFunc carry no context.Context of their own -
+	// a saga can resume a step long after the request that started it - so
+	// these run against a background context rather than one tied to any
+	// single inbound event.
+	removeOrder := func(payload json.RawMessage) error {
+		var p allocateInventoryPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return fmt.Errorf("failed to unmarshal allocate inventory payload: %w", err)
+		}
+		return batchService.RemoveOrderFromBatch(context.Background(), p.OrderID)
+	}
+
+	return saga.Definition{
+		Type: allocateInventorySagaType,
+		Steps: []saga.Step{
+			{
+				ID: "AllocateInventory",
+				Run: func(payload json.RawMessage) error {
+					var p allocateInventoryPayload
+					if err := json.Unmarshal(payload, &p); err != nil {
+						return fmt.Errorf("failed to unmarshal allocate inventory payload: %w", err)
+					}
+					_, err := batchService.AddOrderToBatch(context.Background(), p.OrderID, p.ProductID, p.Quantity, "allocated")
+					return err
+				},
+				Compensate: removeOrder,
+			},
+			{
+				ID: "ConfirmAllocation",
+				Run: func(payload json.RawMessage) error {
+					var p allocateInventoryPayload
+					if err := json.Unmarshal(payload, &p); err != nil {
+						return fmt.Errorf("failed to unmarshal allocate inventory payload: %w", err)
+					}
+					return batchService.UpdateOrderStatus(context.Background(), p.OrderID, "allocation_confirmed")
+				},
+				Compensate: removeOrder,
+			},
+		},
+	}
+}
+
+// processDamageSagaType identifies the saga.Definition built by
+// NewProcessDamageSagaDefinition in the Orchestrator's registry.
+const processDamageSagaType = "process_damage"
+
+// processDamagePayload is the saga payload for the process_damage saga: the
+// order fields needed to put it in a batch and mark that batch damaged.
+type processDamagePayload struct {
+	OrderID   string `json:"order_id"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// NewProcessDamageSagaDefinition builds the saga.Definition for major damage
+// processing: put the order in a damage_major batch, then mark that batch
+// damaged. Previously a failed MarkBatchAsDamaged call here was only logged,
+// leaving the order sitting in a batch that was never flagged damaged; now
+// that failure is compensated by MarkOrderDamaged's compensation, which
+// removes the order from its batch.
+func NewProcessDamageSagaDefinition(batchService BatchServiceInterface) saga.Definition {
+	return saga.Definition{
+		Type: processDamageSagaType,
+		Steps: []saga.Step{
+			{
+				ID: "MarkOrderDamaged",
+				Run: func(payload json.RawMessage) error {
+					var p processDamagePayload
+					if err := json.Unmarshal(payload, &p); err != nil {
+						return fmt.Errorf("failed to unmarshal process damage payload: %w", err)
+					}
+					if err := batchService.UpdateOrderStatus(context.Background(), p.OrderID, "damage_major"); err != nil {
+						_, err := batchService.AddOrderToBatch(context.Background(), p.OrderID, p.ProductID, p.Quantity, "damage_major")
+						return err
+					}
+					return nil
+				},
+				Compensate: func(payload json.RawMessage) error {
+					var p processDamagePayload
+					if err := json.Unmarshal(payload, &p); err != nil {
+						return fmt.Errorf("failed to unmarshal process damage payload: %w", err)
+					}
+					return batchService.RemoveOrderFromBatch(context.Background(), p.OrderID)
+				},
+			},
+			{
+				ID: "MarkBatchDamaged",
+				Run: func(payload json.RawMessage) error {
+					var p processDamagePayload
+					if err := json.Unmarshal(payload, &p); err != nil {
+						return fmt.Errorf("failed to unmarshal process damage payload: %w", err)
+					}
+					batch, err := batchService.GetBatchByOrderID(p.OrderID)
+					if err != nil {
+						return fmt.Errorf("failed to find batch for order %s: %w", p.OrderID, err)
+					}
+					return batchService.MarkBatchAsDamaged(context.Background(), batch.ID)
+				},
+				Compensate: func(payload json.RawMessage) error {
+					// MarkBatchAsDamaged has no inverse, so compensation is
+					// best-effort: flag the order so it doesn't silently look
+					// like ordinary major-damage processing succeeded.
+					var p processDamagePayload
+					if err := json.Unmarshal(payload, &p); err != nil {
+						return fmt.Errorf("failed to unmarshal process damage payload: %w", err)
+					}
+					return batchService.UpdateOrderStatus(context.Background(), p.OrderID, "damage_processing_failed")
+				},
+			},
+		},
+	}
+}
+
+// HandleOrderEvent processes the received order event. The inbound event's
+// TraceParent (set by the RabbitMQ consumer adapter from the CloudEvents
+// envelope) is attached to a context.Context here so every batch event this
+// handling produces carries it forward too.
+func (s *OrderService) HandleOrderEvent(event domain.OrderEvent) (err error) {
+	ctx := tracing.ContextWithTraceParent(context.Background(), event.TraceParent)
+
+	endSpan := tracing.StartSpan(ctx, "order_service.handle_order_event", map[string]string{
+		"event_type": event.EventType,
+		"order_id":   event.OrderID,
+	})
+	defer endSpan(&err)
+
+	log.Printf("Received order event: Type=%s, OrderID=%s, Status=%s",
 		event.EventType, event.OrderID, event.Order.Status)
 
 	// Check if this event is relevant for warehouse processing
@@ -37,21 +187,21 @@ func (s *OrderService) HandleOrderEvent(event domain.OrderEvent) error {
 	// Process based on the warehouse action
 	switch action {
 	case "process_damage":
-		return s.processDamage(event)
+		return s.processDamage(ctx, event)
 	case "allocate_inventory":
-		return s.allocateInventory(event)
+		return s.allocateInventory(ctx, event)
 	case "release_inventory":
-		return s.releaseInventory(event)
+		return s.releaseInventory(ctx, event)
 	case "update_inventory":
-		return s.updateInventory(event)
+		return s.updateInventory(ctx, event)
 	case "confirm_delivery":
-		return s.confirmDelivery(event)
+		return s.confirmDelivery(ctx, event)
 	case "process_return":
-		return s.processReturn(event)
+		return s.processReturn(ctx, event)
 	case "confirm_allocation":
-		return s.confirmAllocation(event)
+		return s.confirmAllocation(ctx, event)
 	case "confirm_release":
-		return s.confirmRelease(event)
+		return s.confirmRelease(ctx, event)
 	default:
 		log.Printf("Unknown warehouse action: %s", action)
 		return fmt.Errorf("unknown warehouse action: %s", action)
@@ -59,19 +209,20 @@ func (s *OrderService) HandleOrderEvent(event domain.OrderEvent) error {
 }
 
 // processDamage handles damage processing events
-func (s *OrderService) processDamage(event domain.OrderEvent) error {
-	log.Printf("Processing damage for order %s: Status=%s, Quantity=%d", 
+func (s *OrderService) processDamage(ctx context.Context, event domain.OrderEvent) error {
+	log.Printf("Processing damage for order %s: Status=%s, Quantity=%d",
 		event.OrderID, event.Order.Status, event.Order.Quantity)
-	
+
 	// Business logic for damage processing
 	switch event.Order.Status {
 	case "damage_detected_minor":
 		log.Printf("Minor damage detected for order %s - marking for inspection", event.OrderID)
 		// Try to update order status in batch, if not found create new batch
-		if err := s.batchService.UpdateOrderStatus(event.OrderID, "damage_minor"); err != nil {
+		if err := s.batchService.UpdateOrderStatus(ctx, event.OrderID, "damage_minor"); err != nil {
 			log.Printf("Order not found in existing batch, creating new batch for damage processing: %v", err)
 			// Create new batch with the order for damage processing
 			_, err := s.batchService.AddOrderToBatch(
+				ctx,
 				event.OrderID,
 				event.Order.ProductID,
 				event.Order.Quantity,
@@ -85,41 +236,61 @@ func (s *OrderService) processDamage(event domain.OrderEvent) error {
 		}
 	case "damage_detected_major":
 		log.Printf("Major damage detected for order %s - marking as damaged", event.OrderID)
-		// Try to update order status in batch, if not found create new batch
-		if err := s.batchService.UpdateOrderStatus(event.OrderID, "damage_major"); err != nil {
-			log.Printf("Order not found in existing batch, creating new batch for damage processing: %v", err)
-			// Create new batch with the order for damage processing
-			batch, err := s.batchService.AddOrderToBatch(
-				event.OrderID,
-				event.Order.ProductID,
-				event.Order.Quantity,
-				"damage_major",
-			)
-			if err != nil {
-				log.Printf("Failed to create batch for damage processing: %v", err)
-				return err
-			}
-			log.Printf("Created new batch %s for order %s with major damage status", batch.ID, event.OrderID)
-			// Mark the entire batch as damaged since it's major damage
-			if err := s.batchService.MarkBatchAsDamaged(batch.ID); err != nil {
-				log.Printf("Failed to mark batch as damaged: %v", err)
-			}
-		} else {
-			// Order was found and updated, now mark the batch as damaged
-			batch, err := s.batchService.GetBatchByOrderID(event.OrderID)
-			if err == nil {
-				if err := s.batchService.MarkBatchAsDamaged(batch.ID); err != nil {
+		if s.sagas == nil {
+			// Try to update order status in batch, if not found create new batch
+			if err := s.batchService.UpdateOrderStatus(ctx, event.OrderID, "damage_major"); err != nil {
+				log.Printf("Order not found in existing batch, creating new batch for damage processing: %v", err)
+				// Create new batch with the order for damage processing
+				batch, err := s.batchService.AddOrderToBatch(
+					ctx,
+					event.OrderID,
+					event.Order.ProductID,
+					event.Order.Quantity,
+					"damage_major",
+				)
+				if err != nil {
+					log.Printf("Failed to create batch for damage processing: %v", err)
+					return err
+				}
+				log.Printf("Created new batch %s for order %s with major damage status", batch.ID, event.OrderID)
+				// Mark the entire batch as damaged since it's major damage
+				if err := s.batchService.MarkBatchAsDamaged(ctx, batch.ID); err != nil {
 					log.Printf("Failed to mark batch as damaged: %v", err)
 				}
+			} else {
+				// Order was found and updated, now mark the batch as damaged
+				batch, err := s.batchService.GetBatchByOrderID(event.OrderID)
+				if err == nil {
+					if err := s.batchService.MarkBatchAsDamaged(ctx, batch.ID); err != nil {
+						log.Printf("Failed to mark batch as damaged: %v", err)
+					}
+				}
 			}
+			break
+		}
+
+		// A saga orchestrator is wired in: run AddOrderToBatch/MarkBatchAsDamaged
+		// as a process_damage saga so a failed MarkBatchAsDamaged removes the
+		// order from its batch instead of leaving it in an unflagged
+		// damage_major batch (see NewProcessDamageSagaDefinition).
+		payload := processDamagePayload{
+			OrderID:   event.OrderID,
+			ProductID: event.Order.ProductID,
+			Quantity:  event.Order.Quantity,
+		}
+		if err := s.sagas.Start(event.OrderID, processDamageSagaType, payload); err != nil {
+			log.Printf("Saga process_damage failed for order %s: %v", event.OrderID, err)
+			return err
 		}
+		log.Printf("Saga process_damage completed for order %s", event.OrderID)
 	case "damage_processed":
 		log.Printf("Damage processing completed for order %s", event.OrderID)
 		// Try to update order status to processed, if not found create new batch
-		if err := s.batchService.UpdateOrderStatus(event.OrderID, "damage_processed"); err != nil {
+		if err := s.batchService.UpdateOrderStatus(ctx, event.OrderID, "damage_processed"); err != nil {
 			log.Printf("Order not found in existing batch, creating new batch for damage processing: %v", err)
 			// Create new batch with the order for damage processing completion
 			_, err := s.batchService.AddOrderToBatch(
+				ctx,
 				event.OrderID,
 				event.Order.ProductID,
 				event.Order.Quantity,
@@ -134,38 +305,58 @@ func (s *OrderService) processDamage(event domain.OrderEvent) error {
 	default:
 		log.Printf("Unknown damage status: %s for order %s", event.Order.Status, event.OrderID)
 	}
-	
+
 	return nil
 }
 
-// allocateInventory handles inventory allocation for new orders
-func (s *OrderService) allocateInventory(event domain.OrderEvent) error {
-	log.Printf("Allocating inventory for order %s: ProductID=%s, Quantity=%d", 
+// allocateInventory handles inventory allocation for new orders. When a
+// saga orchestrator is wired in, the allocation and its confirmation run as
+// an allocate_inventory saga so a mid-flow failure is compensated instead of
+// leaving the order half-allocated; otherwise it falls back to calling the
+// batch service directly.
+func (s *OrderService) allocateInventory(ctx context.Context, event domain.OrderEvent) error {
+	log.Printf("Allocating inventory for order %s: ProductID=%s, Quantity=%d",
 		event.OrderID, event.Order.ProductID, event.Order.Quantity)
-	
-	// Add order to batch for processing
-	batch, err := s.batchService.AddOrderToBatch(
-		event.OrderID, 
-		event.Order.ProductID, 
-		event.Order.Quantity, 
-		"allocated",
-	)
-	if err != nil {
-		log.Printf("Failed to add order to batch: %v", err)
+
+	if s.sagas == nil {
+		// Add order to batch for processing
+		batch, err := s.batchService.AddOrderToBatch(
+			ctx,
+			event.OrderID,
+			event.Order.ProductID,
+			event.Order.Quantity,
+			"allocated",
+		)
+		if err != nil {
+			log.Printf("Failed to add order to batch: %v", err)
+			return err
+		}
+
+		log.Printf("Order %s added to batch %s for inventory allocation", event.OrderID, batch.ID)
+		return nil
+	}
+
+	payload := allocateInventoryPayload{
+		OrderID:   event.OrderID,
+		ProductID: event.Order.ProductID,
+		Quantity:  event.Order.Quantity,
+	}
+	if err := s.sagas.Start(event.OrderID, allocateInventorySagaType, payload); err != nil {
+		log.Printf("Saga allocate_inventory failed for order %s: %v", event.OrderID, err)
 		return err
 	}
-	
-	log.Printf("Order %s added to batch %s for inventory allocation", event.OrderID, batch.ID)
+
+	log.Printf("Saga allocate_inventory completed for order %s", event.OrderID)
 	return nil
 }
 
 // releaseInventory handles inventory release for cancelled orders
-func (s *OrderService) releaseInventory(event domain.OrderEvent) error {
-	log.Printf("Releasing inventory for cancelled order %s: ProductID=%s, Quantity=%d", 
+func (s *OrderService) releaseInventory(ctx context.Context, event domain.OrderEvent) error {
+	log.Printf("Releasing inventory for cancelled order %s: ProductID=%s, Quantity=%d",
 		event.OrderID, event.Order.ProductID, event.Order.Quantity)
-	
+
 	// Remove order from batch since it's cancelled
-	if err := s.batchService.RemoveOrderFromBatch(event.OrderID); err != nil {
+	if err := s.batchService.RemoveOrderFromBatch(ctx, event.OrderID); err != nil {
 		log.Printf("Failed to remove order from batch: %v", err)
 		return err
 	}
@@ -175,12 +366,12 @@ func (s *OrderService) releaseInventory(event domain.OrderEvent) error {
 }
 
 // updateInventory handles inventory updates for shipped orders
-func (s *OrderService) updateInventory(event domain.OrderEvent) error {
-	log.Printf("Updating inventory for shipped order %s: ProductID=%s, Quantity=%d", 
+func (s *OrderService) updateInventory(ctx context.Context, event domain.OrderEvent) error {
+	log.Printf("Updating inventory for shipped order %s: ProductID=%s, Quantity=%d",
 		event.OrderID, event.Order.ProductID, event.Order.Quantity)
-	
+
 	// Update order status to shipped in batch
-	if err := s.batchService.UpdateOrderStatus(event.OrderID, "shipped"); err != nil {
+	if err := s.batchService.UpdateOrderStatus(ctx, event.OrderID, "shipped"); err != nil {
 		log.Printf("Failed to update order status in batch: %v", err)
 		return err
 	}
@@ -190,11 +381,11 @@ func (s *OrderService) updateInventory(event domain.OrderEvent) error {
 }
 
 // confirmDelivery handles delivery confirmation
-func (s *OrderService) confirmDelivery(event domain.OrderEvent) error {
+func (s *OrderService) confirmDelivery(ctx context.Context, event domain.OrderEvent) error {
 	log.Printf("Confirming delivery for order %s", event.OrderID)
-	
+
 	// Update order status to delivered in batch
-	if err := s.batchService.UpdateOrderStatus(event.OrderID, "delivered"); err != nil {
+	if err := s.batchService.UpdateOrderStatus(ctx, event.OrderID, "delivered"); err != nil {
 		log.Printf("Failed to update order status in batch: %v", err)
 		return err
 	}
@@ -204,21 +395,22 @@ func (s *OrderService) confirmDelivery(event domain.OrderEvent) error {
 }
 
 // processReturn handles returned orders
-func (s *OrderService) processReturn(event domain.OrderEvent) error {
-	log.Printf("Processing return for order %s: ProductID=%s, Quantity=%d", 
+func (s *OrderService) processReturn(ctx context.Context, event domain.OrderEvent) error {
+	log.Printf("Processing return for order %s: ProductID=%s, Quantity=%d",
 		event.OrderID, event.Order.ProductID, event.Order.Quantity)
-	
+
 	// Update order status to returned in batch
-	if err := s.batchService.UpdateOrderStatus(event.OrderID, "returned"); err != nil {
+	if err := s.batchService.UpdateOrderStatus(ctx, event.OrderID, "returned"); err != nil {
 		log.Printf("Failed to update order status in batch: %v", err)
 		return err
 	}
-	
+
 	// Add returned item back to inventory by creating a new batch entry
 	_, err := s.batchService.AddOrderToBatch(
-		event.OrderID+"-return", 
-		event.Order.ProductID, 
-		event.Order.Quantity, 
+		ctx,
+		event.OrderID+"-return",
+		event.Order.ProductID,
+		event.Order.Quantity,
 		"returned",
 	)
 	if err != nil {
@@ -231,11 +423,11 @@ func (s *OrderService) processReturn(event domain.OrderEvent) error {
 }
 
 // confirmAllocation confirms inventory allocation
-func (s *OrderService) confirmAllocation(event domain.OrderEvent) error {
+func (s *OrderService) confirmAllocation(ctx context.Context, event domain.OrderEvent) error {
 	log.Printf("Confirming inventory allocation for order %s", event.OrderID)
-	
+
 	// Update order status to allocation confirmed in batch
-	if err := s.batchService.UpdateOrderStatus(event.OrderID, "allocation_confirmed"); err != nil {
+	if err := s.batchService.UpdateOrderStatus(ctx, event.OrderID, "allocation_confirmed"); err != nil {
 		log.Printf("Failed to update order status in batch: %v", err)
 		return err
 	}
@@ -245,11 +437,11 @@ func (s *OrderService) confirmAllocation(event domain.OrderEvent) error {
 }
 
 // confirmRelease confirms inventory release
-func (s *OrderService) confirmRelease(event domain.OrderEvent) error {
+func (s *OrderService) confirmRelease(ctx context.Context, event domain.OrderEvent) error {
 	log.Printf("Confirming inventory release for order %s", event.OrderID)
-	
+
 	// Update order status to release confirmed in batch
-	if err := s.batchService.UpdateOrderStatus(event.OrderID, "release_confirmed"); err != nil {
+	if err := s.batchService.UpdateOrderStatus(ctx, event.OrderID, "release_confirmed"); err != nil {
 		log.Printf("Failed to update order status in batch: %v", err)
 		return err
 	}
@@ -0,0 +1,260 @@
+// Package saga models a cross-service order/batch flow as a linear sequence
+// of steps, each with a registered compensating action, so a failure partway
+// through unwinds the steps that already succeeded instead of leaving
+// inventory and batches inconsistent.
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/retry"
+)
+
+// StepFunc executes one step of a saga against the given payload.
+type StepFunc func(payload json.RawMessage) error
+
+// CompensateFunc undoes a previously executed step.
+type CompensateFunc func(payload json.RawMessage) error
+
+// Step is one unit of work in a Definition, identified by an ID that is
+// stable across process restarts so SagaStepState can be matched back to it.
+type Step struct {
+	ID         string
+	Run        StepFunc
+	Compensate CompensateFunc
+	// Retry governs the timeout/retry policy for Run. A zero value disables
+	// retries (a single attempt is made).
+	Retry retry.Policy
+}
+
+// Definition is the registered sequence of steps for one saga type (e.g.
+// "allocate_inventory"). Steps run in order; on failure, the steps that
+// already completed are compensated in reverse order.
+type Definition struct {
+	Type  string
+	Steps []Step
+}
+
+// Orchestrator runs saga Definitions, persisting progress via a
+// domain.SagaRepository so an interrupted saga can be resumed, and
+// publishing the domain.SagaEvent* lifecycle events (started, step
+// completed/failed, compensated, aborted) so a saga's progress and any
+// compensation are observable on the batch events topic.
+type Orchestrator struct {
+	repo           domain.SagaRepository
+	eventsTopic    domain.BatchEventPublisher
+	definitions    map[string]Definition
+	deadLetterSink DeadLetterSink
+}
+
+// DeadLetterSink receives a saga that failed compensation, so it can be
+// routed aside for manual intervention instead of being silently left in
+// domain.SagaStatusFailed.
+type DeadLetterSink interface {
+	Send(saga *domain.Saga, reason string) error
+}
+
+// NewOrchestrator creates an Orchestrator. deadLetterSink may be nil, in
+// which case a saga whose compensation also fails is simply persisted with
+// domain.SagaStatusFailed and logged.
+func NewOrchestrator(repo domain.SagaRepository, eventsTopic domain.BatchEventPublisher, definitions []Definition, deadLetterSink DeadLetterSink) *Orchestrator {
+	registry := make(map[string]Definition, len(definitions))
+	for _, def := range definitions {
+		registry[def.Type] = def
+	}
+
+	return &Orchestrator{
+		repo:           repo,
+		eventsTopic:    eventsTopic,
+		definitions:    registry,
+		deadLetterSink: deadLetterSink,
+	}
+}
+
+// Start begins a new saga of the given type with the given ID and payload,
+// running it to completion or compensation before returning. sagaID should
+// be stable and unique per logical transaction (e.g. the order ID) so a
+// retried Start call resumes rather than duplicating work.
+func (o *Orchestrator) Start(sagaID, sagaType string, payload any) error {
+	def, ok := o.definitions[sagaType]
+	if !ok {
+		return fmt.Errorf("unknown saga type: %s", sagaType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal saga payload: %w", err)
+	}
+
+	existing, err := o.repo.FindByID(sagaID)
+	if err == nil && existing != nil {
+		return o.resume(existing, def)
+	}
+
+	steps := make([]domain.SagaStepState, len(def.Steps))
+	for i, step := range def.Steps {
+		steps[i] = domain.SagaStepState{StepID: step.ID, Status: domain.StepStatusPending}
+	}
+
+	now := time.Now().UTC()
+	instance := &domain.Saga{
+		ID:        sagaID,
+		Type:      sagaType,
+		Status:    domain.SagaStatusRunning,
+		Steps:     steps,
+		Payload:   data,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	o.publishSagaEvent(instance, domain.SagaEventStarted)
+	return o.run(instance, def)
+}
+
+// Retry resumes a saga that is stuck in SagaStatusRunning or
+// SagaStatusCompensating (e.g. after a crash), picking up from its persisted
+// current step instead of starting over.
+func (o *Orchestrator) Retry(sagaID string) error {
+	instance, err := o.repo.FindByID(sagaID)
+	if err != nil {
+		return fmt.Errorf("saga %s not found: %w", sagaID, err)
+	}
+
+	def, ok := o.definitions[instance.Type]
+	if !ok {
+		return fmt.Errorf("unknown saga type: %s", instance.Type)
+	}
+
+	return o.resume(instance, def)
+}
+
+func (o *Orchestrator) resume(instance *domain.Saga, def Definition) error {
+	switch instance.Status {
+	case domain.SagaStatusCompleted, domain.SagaStatusCompensated:
+		return nil
+	case domain.SagaStatusCompensating, domain.SagaStatusFailed:
+		return o.compensate(instance, def, instance.CurrentStep)
+	default:
+		return o.run(instance, def)
+	}
+}
+
+// run executes steps starting at instance.CurrentStep, persisting progress
+// after each one so a crash mid-saga resumes rather than restarts.
+func (o *Orchestrator) run(instance *domain.Saga, def Definition) error {
+	for i := instance.CurrentStep; i < len(def.Steps); i++ {
+		step := def.Steps[i]
+
+		// Step execution is keyed by (SagaID, StepID): a step already marked
+		// completed is skipped so a retried saga doesn't re-run side effects.
+		if instance.Steps[i].Status == domain.StepStatusCompleted {
+			continue
+		}
+
+		policy := step.Retry
+		if policy.MaxAttempts == 0 {
+			policy = retry.Policy{MaxAttempts: 1, Classify: func(error) retry.Classification { return retry.Terminal }}
+		}
+
+		err := retry.Do(context.Background(), func() error { return step.Run(instance.Payload) }, policy)
+		if err != nil {
+			log.Printf("Saga %s step %s failed: %v", instance.ID, step.ID, err)
+			instance.Steps[i].Status = domain.StepStatusFailed
+			instance.Steps[i].Error = err.Error()
+			instance.CurrentStep = i
+			instance.Status = domain.SagaStatusCompensating
+			instance.UpdatedAt = time.Now().UTC()
+			o.publishSagaEvent(instance, domain.SagaEventStepFailed)
+			if saveErr := o.repo.Save(instance); saveErr != nil {
+				log.Printf("Failed to persist saga %s after step failure: %v", instance.ID, saveErr)
+			}
+			return o.compensate(instance, def, i)
+		}
+
+		instance.Steps[i].Status = domain.StepStatusCompleted
+		instance.CurrentStep = i + 1
+		instance.UpdatedAt = time.Now().UTC()
+		o.publishSagaEvent(instance, domain.SagaEventStepCompleted)
+		if saveErr := o.repo.Save(instance); saveErr != nil {
+			log.Printf("Failed to persist saga %s progress: %v", instance.ID, saveErr)
+		}
+	}
+
+	instance.Status = domain.SagaStatusCompleted
+	instance.UpdatedAt = time.Now().UTC()
+	return o.repo.Save(instance)
+}
+
+// compensate undoes every step that completed before failedStep, in reverse
+// order. failedStep itself never ran to completion - it's the step that
+// just failed or (on resume) the step compensation was already in progress
+// on - so it is not compensated. If a compensator itself fails, the saga is
+// marked SagaStatusFailed and routed to the dead-letter sink, since there is
+// no further automated recourse.
+func (o *Orchestrator) compensate(instance *domain.Saga, def Definition, failedStep int) error {
+	instance.Status = domain.SagaStatusCompensating
+	for i := failedStep - 1; i >= 0; i-- {
+		if instance.Steps[i].Status != domain.StepStatusCompleted {
+			continue
+		}
+
+		step := def.Steps[i]
+		if step.Compensate == nil {
+			continue
+		}
+
+		if err := step.Compensate(instance.Payload); err != nil {
+			log.Printf("Saga %s compensation of step %s failed: %v", instance.ID, step.ID, err)
+			instance.Status = domain.SagaStatusFailed
+			instance.UpdatedAt = time.Now().UTC()
+			o.publishSagaEvent(instance, domain.SagaEventAborted)
+			if saveErr := o.repo.Save(instance); saveErr != nil {
+				log.Printf("Failed to persist saga %s after compensation failure: %v", instance.ID, saveErr)
+			}
+			o.sendToDeadLetter(instance, err)
+			return fmt.Errorf("saga %s compensation of step %s failed: %w", instance.ID, step.ID, err)
+		}
+
+		instance.Steps[i].Status = domain.StepStatusCompensated
+	}
+
+	instance.Status = domain.SagaStatusCompensated
+	instance.UpdatedAt = time.Now().UTC()
+	o.publishSagaEvent(instance, domain.SagaEventCompensated)
+	return o.repo.Save(instance)
+}
+
+// publishSagaEvent reuses the existing batch events topic to surface saga
+// lifecycle events, carrying the saga ID in BatchID since there is no
+// dedicated saga events topic in this service.
+func (o *Orchestrator) publishSagaEvent(instance *domain.Saga, eventType domain.BatchEventType) {
+	if o.eventsTopic == nil {
+		return
+	}
+
+	event := &domain.BatchEvent{
+		EventType: eventType,
+		BatchID:   instance.ID,
+		Timestamp: time.Now().UTC(),
+	}
+	// Saga lifecycle events aren't triggered by any single in-flight request,
+	// so there's no traceparent to carry here.
+	if err := o.eventsTopic.PublishBatchEvent(context.Background(), event); err != nil {
+		log.Printf("Failed to publish %s event for saga %s: %v", eventType, instance.ID, err)
+	}
+}
+
+func (o *Orchestrator) sendToDeadLetter(instance *domain.Saga, reason error) {
+	if o.deadLetterSink == nil {
+		log.Printf("No dead-letter sink configured, leaving saga %s in failed state for manual intervention", instance.ID)
+		return
+	}
+	if err := o.deadLetterSink.Send(instance, reason.Error()); err != nil {
+		log.Printf("Failed to send saga %s to dead-letter sink: %v", instance.ID, err)
+	}
+}
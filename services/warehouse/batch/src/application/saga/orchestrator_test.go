@@ -0,0 +1,198 @@
+package saga
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+)
+
+// fakeSagaRepository is a minimal in-memory domain.SagaRepository for tests.
+type fakeSagaRepository struct {
+	mu    sync.Mutex
+	sagas map[string]*domain.Saga
+}
+
+func newFakeSagaRepository() *fakeSagaRepository {
+	return &fakeSagaRepository{sagas: make(map[string]*domain.Saga)}
+}
+
+func (r *fakeSagaRepository) Save(saga *domain.Saga) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *saga
+	r.sagas[saga.ID] = &copied
+	return nil
+}
+
+func (r *fakeSagaRepository) FindByID(id string) (*domain.Saga, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saga, ok := r.sagas[id]
+	if !ok {
+		return nil, fmt.Errorf("saga %s not found", id)
+	}
+	copied := *saga
+	return &copied, nil
+}
+
+func (r *fakeSagaRepository) FindAll() ([]*domain.Saga, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*domain.Saga
+	for _, saga := range r.sagas {
+		copied := *saga
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+func testDefinition(step1, step2 func(json.RawMessage) error, compensate1, compensate2 func(json.RawMessage) error) Definition {
+	return Definition{
+		Type: "test_saga",
+		Steps: []Step{
+			{ID: "step1", Run: step1, Compensate: compensate1},
+			{ID: "step2", Run: step2, Compensate: compensate2},
+		},
+	}
+}
+
+func TestOrchestrator_Start_CompletesAllSteps(t *testing.T) {
+	repo := newFakeSagaRepository()
+	var ran []string
+
+	def := testDefinition(
+		func(json.RawMessage) error { ran = append(ran, "step1"); return nil },
+		func(json.RawMessage) error { ran = append(ran, "step2"); return nil },
+		nil, nil,
+	)
+
+	orchestrator := NewOrchestrator(repo, nil, []Definition{def}, nil)
+	if err := orchestrator.Start("saga-1", "test_saga", map[string]string{"k": "v"}); err != nil {
+		t.Fatalf("expected saga to complete, got %v", err)
+	}
+
+	if len(ran) != 2 || ran[0] != "step1" || ran[1] != "step2" {
+		t.Fatalf("expected both steps to run in order, got %v", ran)
+	}
+
+	saved, err := repo.FindByID("saga-1")
+	if err != nil {
+		t.Fatalf("expected saga to be persisted: %v", err)
+	}
+	if saved.Status != domain.SagaStatusCompleted {
+		t.Errorf("expected status %s, got %s", domain.SagaStatusCompleted, saved.Status)
+	}
+}
+
+func TestOrchestrator_Start_CompensatesOnStepFailure(t *testing.T) {
+	repo := newFakeSagaRepository()
+	var compensated []string
+
+	def := testDefinition(
+		func(json.RawMessage) error { return nil },
+		func(json.RawMessage) error { return errors.New("step2 boom") },
+		func(json.RawMessage) error { compensated = append(compensated, "step1"); return nil },
+		func(json.RawMessage) error { compensated = append(compensated, "step2"); return nil },
+	)
+
+	orchestrator := NewOrchestrator(repo, nil, []Definition{def}, nil)
+	if err := orchestrator.Start("saga-2", "test_saga", map[string]string{}); err != nil {
+		t.Fatalf("expected compensation to succeed without returning an error, got %v", err)
+	}
+
+	if len(compensated) != 1 || compensated[0] != "step1" {
+		t.Fatalf("expected only step1 (the completed step) to be compensated, got %v", compensated)
+	}
+
+	saved, err := repo.FindByID("saga-2")
+	if err != nil {
+		t.Fatalf("expected saga to be persisted: %v", err)
+	}
+	if saved.Status != domain.SagaStatusCompensated {
+		t.Errorf("expected status %s, got %s", domain.SagaStatusCompensated, saved.Status)
+	}
+}
+
+func TestOrchestrator_Start_PublishesLifecycleEvents(t *testing.T) {
+	repo := newFakeSagaRepository()
+	events := domain.NewMockBatchEventPublisher()
+
+	def := testDefinition(
+		func(json.RawMessage) error { return nil },
+		func(json.RawMessage) error { return nil },
+		nil, nil,
+	)
+
+	orchestrator := NewOrchestrator(repo, events, []Definition{def}, nil)
+	if err := orchestrator.Start("saga-4", "test_saga", map[string]string{}); err != nil {
+		t.Fatalf("expected saga to complete, got %v", err)
+	}
+
+	got := events.GetPublishedEvents()
+	wantTypes := []domain.BatchEventType{
+		domain.SagaEventStarted,
+		domain.SagaEventStepCompleted,
+		domain.SagaEventStepCompleted,
+	}
+	if len(got) != len(wantTypes) {
+		t.Fatalf("expected %d events, got %d: %v", len(wantTypes), len(got), got)
+	}
+	for i, want := range wantTypes {
+		if got[i].EventType != want {
+			t.Errorf("event %d: expected type %s, got %s", i, want, got[i].EventType)
+		}
+		if got[i].BatchID != "saga-4" {
+			t.Errorf("event %d: expected BatchID (correlation id) %q, got %q", i, "saga-4", got[i].BatchID)
+		}
+	}
+}
+
+func TestOrchestrator_Start_PublishesAbortedWhenCompensationFails(t *testing.T) {
+	repo := newFakeSagaRepository()
+	events := domain.NewMockBatchEventPublisher()
+
+	def := testDefinition(
+		func(json.RawMessage) error { return nil },
+		func(json.RawMessage) error { return errors.New("step2 boom") },
+		func(json.RawMessage) error { return errors.New("compensation also fails") },
+		nil,
+	)
+
+	orchestrator := NewOrchestrator(repo, events, []Definition{def}, nil)
+	if err := orchestrator.Start("saga-5", "test_saga", map[string]string{}); err == nil {
+		t.Fatal("expected an error when compensation fails")
+	}
+
+	aborted := events.GetEventsByType(domain.SagaEventAborted)
+	if len(aborted) != 1 {
+		t.Fatalf("expected exactly one saga.aborted event, got %d", len(aborted))
+	}
+}
+
+func TestOrchestrator_Start_MarksFailedWhenCompensationFails(t *testing.T) {
+	repo := newFakeSagaRepository()
+
+	def := testDefinition(
+		func(json.RawMessage) error { return nil },
+		func(json.RawMessage) error { return errors.New("step2 boom") },
+		func(json.RawMessage) error { return errors.New("compensation also fails") },
+		nil,
+	)
+
+	orchestrator := NewOrchestrator(repo, nil, []Definition{def}, nil)
+	if err := orchestrator.Start("saga-3", "test_saga", map[string]string{}); err == nil {
+		t.Fatal("expected an error when compensation fails")
+	}
+
+	saved, err := repo.FindByID("saga-3")
+	if err != nil {
+		t.Fatalf("expected saga to be persisted: %v", err)
+	}
+	if saved.Status != domain.SagaStatusFailed {
+		t.Errorf("expected status %s, got %s", domain.SagaStatusFailed, saved.Status)
+	}
+}
@@ -0,0 +1,56 @@
+package application
+
+import (
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+)
+
+// SagaDTO represents a saga for API responses
+type SagaDTO struct {
+	ID          string        `json:"id"`
+	Type        string        `json:"type"`
+	Status      string        `json:"status"`
+	CurrentStep int           `json:"current_step"`
+	Steps       []SagaStepDTO `json:"steps"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// SagaStepDTO represents a single step's outcome within a SagaDTO
+type SagaStepDTO struct {
+	StepID string `json:"step_id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ToSagaDTO converts a domain saga to a DTO
+func ToSagaDTO(saga *domain.Saga) *SagaDTO {
+	steps := make([]SagaStepDTO, len(saga.Steps))
+	for i, step := range saga.Steps {
+		steps[i] = SagaStepDTO{
+			StepID: step.StepID,
+			Status: string(step.Status),
+			Error:  step.Error,
+		}
+	}
+
+	return &SagaDTO{
+		ID:          saga.ID,
+		Type:        saga.Type,
+		Status:      string(saga.Status),
+		CurrentStep: saga.CurrentStep,
+		Steps:       steps,
+		CreatedAt:   saga.CreatedAt,
+		UpdatedAt:   saga.UpdatedAt,
+	}
+}
+
+// ToSagaDTOs converts a slice of domain sagas to DTOs
+func ToSagaDTOs(sagas []*domain.Saga) []*SagaDTO {
+	dtos := make([]*SagaDTO, len(sagas))
+	for i, saga := range sagas {
+		dtos[i] = ToSagaDTO(saga)
+	}
+	return dtos
+}
@@ -24,6 +24,11 @@ type BatchItem struct {
 	Status      string    `json:"status"`
 	AddedAt     time.Time `json:"added_at"`
 	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+	// WeightKg is the item's weight, used to evaluate a BatchPolicy's
+	// MaxWeightKg trigger. It defaults to zero until a weight is supplied by
+	// the upstream order source, so MaxWeightKg never trips for items added
+	// without one.
+	WeightKg float64 `json:"weight_kg,omitempty"`
 }
 
 // Batch represents a batch aggregate in the warehouse domain
@@ -196,4 +201,13 @@ func (b *Batch) GetTotalQuantity() int {
 		total += item.Quantity
 	}
 	return total
+}
+
+// GetTotalWeight returns the total weight in kg of all items in the batch
+func (b *Batch) GetTotalWeight() float64 {
+	total := 0.0
+	for _, item := range b.Items {
+		total += item.WeightKg
+	}
+	return total
 }
\ No newline at end of file
@@ -1,7 +1,10 @@
 package domain
 
 import (
+	"context"
 	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/tracing"
 )
 
 // BatchEventType represents the type of batch event
@@ -16,6 +19,24 @@ const (
 	BatchEventCompleted     BatchEventType = "batch.completed"
 	BatchEventCancelled     BatchEventType = "batch.cancelled"
 	BatchEventDamaged       BatchEventType = "batch.marked_damaged"
+	// BatchEventSealedByPolicy is published alongside BatchEventCompleted
+	// whenever a BatchPolicy trigger (rather than a manual ProcessBatch
+	// call) is what sealed the batch, naming the rule that fired so
+	// downstream shipping services can react.
+	BatchEventSealedByPolicy BatchEventType = "batch.sealed_by_policy"
+
+	// The SagaEvent* types below are published by application/saga.Orchestrator
+	// to report a saga's lifecycle on the same topic as batch events, since
+	// this service has no dedicated saga events topic. BatchID carries the
+	// saga ID (which callers set to the triggering OrderID), so consumers can
+	// correlate a saga's events without a separate correlation field.
+	SagaEventStarted       BatchEventType = "saga.started"
+	SagaEventStepCompleted BatchEventType = "saga.step.completed"
+	SagaEventStepFailed    BatchEventType = "saga.step.failed"
+	SagaEventCompensated   BatchEventType = "saga.compensated"
+	// SagaEventAborted is published when a saga's compensation itself fails,
+	// leaving it in SagaStatusFailed with no further automated recourse.
+	SagaEventAborted BatchEventType = "saga.aborted"
 )
 
 // BatchEvent represents a domain event for batch operations
@@ -26,22 +47,35 @@ type BatchEvent struct {
 	Batch       *Batch         `json:"batch"`
 	OrderID     *string        `json:"order_id,omitempty"`     // For item-specific events
 	ItemDetails *BatchItem     `json:"item_details,omitempty"` // For item-specific events
+	// Rule names the BatchPolicy trigger that fired, set only on
+	// BatchEventSealedByPolicy events (e.g. "max_items", "max_age").
+	Rule        string         `json:"rule,omitempty"`
 	Timestamp   time.Time      `json:"timestamp"`
+	// IdempotencyKey identifies the event payload so downstream consumers can
+	// deduplicate redeliveries of the same batch cut (empty when not set).
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// TraceParent carries the W3C traceparent of the order event that
+	// triggered this batch event, so the Kafka publisher can propagate it
+	// onto the CloudEvents headers. Empty when no incoming trace is known.
+	TraceParent string `json:"traceparent,omitempty"`
 }
 
-// NewBatchCreatedEvent creates a new batch created event
-func NewBatchCreatedEvent(batch *Batch) *BatchEvent {
+// NewBatchCreatedEvent creates a new batch created event. ctx's traceparent
+// (if any) is attached so downstream consumers can stitch this event's span
+// to the request that caused it.
+func NewBatchCreatedEvent(ctx context.Context, batch *Batch) *BatchEvent {
 	return &BatchEvent{
-		EventType: BatchEventCreated,
-		BatchID:   batch.ID,
-		ProductID: batch.ProductID,
-		Batch:     batch,
-		Timestamp: time.Now().UTC(),
+		EventType:   BatchEventCreated,
+		BatchID:     batch.ID,
+		ProductID:   batch.ProductID,
+		Batch:       batch,
+		Timestamp:   time.Now().UTC(),
+		TraceParent: tracing.TraceParentFromContext(ctx),
 	}
 }
 
 // NewBatchItemAddedEvent creates a new batch item added event
-func NewBatchItemAddedEvent(batch *Batch, orderID string, item *BatchItem) *BatchEvent {
+func NewBatchItemAddedEvent(ctx context.Context, batch *Batch, orderID string, item *BatchItem) *BatchEvent {
 	return &BatchEvent{
 		EventType:   BatchEventItemAdded,
 		BatchID:     batch.ID,
@@ -50,23 +84,25 @@ func NewBatchItemAddedEvent(batch *Batch, orderID string, item *BatchItem) *Batc
 		OrderID:     &orderID,
 		ItemDetails: item,
 		Timestamp:   time.Now().UTC(),
+		TraceParent: tracing.TraceParentFromContext(ctx),
 	}
 }
 
 // NewBatchItemRemovedEvent creates a new batch item removed event
-func NewBatchItemRemovedEvent(batch *Batch, orderID string) *BatchEvent {
+func NewBatchItemRemovedEvent(ctx context.Context, batch *Batch, orderID string) *BatchEvent {
 	return &BatchEvent{
-		EventType: BatchEventItemRemoved,
-		BatchID:   batch.ID,
-		ProductID: batch.ProductID,
-		Batch:     batch,
-		OrderID:   &orderID,
-		Timestamp: time.Now().UTC(),
+		EventType:   BatchEventItemRemoved,
+		BatchID:     batch.ID,
+		ProductID:   batch.ProductID,
+		Batch:       batch,
+		OrderID:     &orderID,
+		Timestamp:   time.Now().UTC(),
+		TraceParent: tracing.TraceParentFromContext(ctx),
 	}
 }
 
 // NewBatchItemUpdatedEvent creates a new batch item updated event
-func NewBatchItemUpdatedEvent(batch *Batch, orderID string, item *BatchItem) *BatchEvent {
+func NewBatchItemUpdatedEvent(ctx context.Context, batch *Batch, orderID string, item *BatchItem) *BatchEvent {
 	return &BatchEvent{
 		EventType:   BatchEventItemUpdated,
 		BatchID:     batch.ID,
@@ -75,54 +111,73 @@ func NewBatchItemUpdatedEvent(batch *Batch, orderID string, item *BatchItem) *Ba
 		OrderID:     &orderID,
 		ItemDetails: item,
 		Timestamp:   time.Now().UTC(),
+		TraceParent: tracing.TraceParentFromContext(ctx),
 	}
 }
 
 // NewBatchProcessingStartedEvent creates a new batch processing started event
-func NewBatchProcessingStartedEvent(batch *Batch) *BatchEvent {
+func NewBatchProcessingStartedEvent(ctx context.Context, batch *Batch) *BatchEvent {
 	return &BatchEvent{
-		EventType: BatchEventProcessing,
-		BatchID:   batch.ID,
-		ProductID: batch.ProductID,
-		Batch:     batch,
-		Timestamp: time.Now().UTC(),
+		EventType:   BatchEventProcessing,
+		BatchID:     batch.ID,
+		ProductID:   batch.ProductID,
+		Batch:       batch,
+		Timestamp:   time.Now().UTC(),
+		TraceParent: tracing.TraceParentFromContext(ctx),
 	}
 }
 
 // NewBatchCompletedEvent creates a new batch completed event
-func NewBatchCompletedEvent(batch *Batch) *BatchEvent {
+func NewBatchCompletedEvent(ctx context.Context, batch *Batch) *BatchEvent {
 	return &BatchEvent{
-		EventType: BatchEventCompleted,
-		BatchID:   batch.ID,
-		ProductID: batch.ProductID,
-		Batch:     batch,
-		Timestamp: time.Now().UTC(),
+		EventType:   BatchEventCompleted,
+		BatchID:     batch.ID,
+		ProductID:   batch.ProductID,
+		Batch:       batch,
+		Timestamp:   time.Now().UTC(),
+		TraceParent: tracing.TraceParentFromContext(ctx),
 	}
 }
 
 // NewBatchCancelledEvent creates a new batch cancelled event
-func NewBatchCancelledEvent(batch *Batch) *BatchEvent {
+func NewBatchCancelledEvent(ctx context.Context, batch *Batch) *BatchEvent {
 	return &BatchEvent{
-		EventType: BatchEventCancelled,
-		BatchID:   batch.ID,
-		ProductID: batch.ProductID,
-		Batch:     batch,
-		Timestamp: time.Now().UTC(),
+		EventType:   BatchEventCancelled,
+		BatchID:     batch.ID,
+		ProductID:   batch.ProductID,
+		Batch:       batch,
+		Timestamp:   time.Now().UTC(),
+		TraceParent: tracing.TraceParentFromContext(ctx),
 	}
 }
 
 // NewBatchDamagedEvent creates a new batch damaged event
-func NewBatchDamagedEvent(batch *Batch) *BatchEvent {
+func NewBatchDamagedEvent(ctx context.Context, batch *Batch) *BatchEvent {
 	return &BatchEvent{
-		EventType: BatchEventDamaged,
-		BatchID:   batch.ID,
-		ProductID: batch.ProductID,
-		Batch:     batch,
-		Timestamp: time.Now().UTC(),
+		EventType:   BatchEventDamaged,
+		BatchID:     batch.ID,
+		ProductID:   batch.ProductID,
+		Batch:       batch,
+		Timestamp:   time.Now().UTC(),
+		TraceParent: tracing.TraceParentFromContext(ctx),
+	}
+}
+
+// NewBatchSealedByPolicyEvent creates a batch.sealed_by_policy event, naming
+// the BatchPolicy rule that triggered the seal.
+func NewBatchSealedByPolicyEvent(ctx context.Context, batch *Batch, rule string) *BatchEvent {
+	return &BatchEvent{
+		EventType:   BatchEventSealedByPolicy,
+		BatchID:     batch.ID,
+		ProductID:   batch.ProductID,
+		Batch:       batch,
+		Rule:        rule,
+		Timestamp:   time.Now().UTC(),
+		TraceParent: tracing.TraceParentFromContext(ctx),
 	}
 }
 
 // BatchEventPublisher defines the interface for publishing batch events
 type BatchEventPublisher interface {
-	PublishBatchEvent(event *BatchEvent) error
+	PublishBatchEvent(ctx context.Context, event *BatchEvent) error
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -10,7 +11,7 @@ func TestNewBatchCreatedEvent(t *testing.T) {
 	batch := NewBatch("test-batch-1", "prod-123")
 	
 	// Create the event
-	event := NewBatchCreatedEvent(batch)
+	event := NewBatchCreatedEvent(context.Background(), batch)
 	
 	// Verify event properties
 	if event.EventType != BatchEventCreated {
@@ -59,7 +60,7 @@ func TestNewBatchItemAddedEvent(t *testing.T) {
 	}
 	
 	// Create the event
-	event := NewBatchItemAddedEvent(batch, orderID, item)
+	event := NewBatchItemAddedEvent(context.Background(), batch, orderID, item)
 	
 	// Verify event properties
 	if event.EventType != BatchEventItemAdded {
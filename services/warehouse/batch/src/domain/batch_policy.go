@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// BatchPolicy configures when a product's open batch is sealed
+// automatically, independent of every other product's thresholds. A zero
+// value for any trigger disables it.
+type BatchPolicy struct {
+	ProductID string `json:"product_id"`
+	// MaxItems seals the batch once it holds this many items.
+	MaxItems int `json:"max_items"`
+	// MaxQuantity seals the batch once its total quantity reaches this value.
+	MaxQuantity int `json:"max_quantity"`
+	// MaxAge seals the batch once it has been open this long.
+	MaxAge time.Duration `json:"max_age"`
+	// MaxWeightKg seals the batch once its total weight reaches this value.
+	MaxWeightKg float64 `json:"max_weight_kg"`
+	// CutoffCron is a standard 5-field cron expression (e.g. "0 18 * * *")
+	// naming a recurring cutoff after which any open batch for this product
+	// is sealed on the next policy scan, e.g. to guarantee same-day batches
+	// ship by a courier pickup time.
+	CutoffCron string `json:"cutoff_cron,omitempty"`
+}
+
+// PolicyRepository stores the BatchPolicy in effect per product.
+type PolicyRepository interface {
+	// Save stores or updates the policy for policy.ProductID.
+	Save(policy BatchPolicy) error
+
+	// FindByProductID retrieves the policy configured for productID, or an
+	// error if none has been configured yet.
+	FindByProductID(productID string) (BatchPolicy, error)
+
+	// GetAll retrieves every configured policy.
+	GetAll() ([]BatchPolicy, error)
+}
@@ -1,9 +1,13 @@
 package domain
 
+import "context"
+
 // BatchRepository defines the contract for batch persistence
 type BatchRepository interface {
-	// Save stores or updates a batch
-	Save(batch *Batch) error
+	// Save stores or updates a batch. ctx carries the request's traceparent
+	// for implementations (e.g. a future SQL-backed repository) that want to
+	// attach it to the write span.
+	Save(ctx context.Context, batch *Batch) error
 	
 	// FindByID retrieves a batch by its ID
 	FindByID(id string) (*Batch, error)
@@ -0,0 +1,71 @@
+// Package cloudevents wraps outgoing domain events in a CloudEvents 1.0
+// structured-mode envelope, so any CNCF-compliant broker or bridge (Knative,
+// Argo Events, AWS EventBridge) can consume them without a per-event schema
+// rewrite. This complements pkg/eventing's binary-mode "ce_*" headers: the
+// two modes carry the same attributes, just in different places.
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SpecVersion is the CloudEvents spec version this package implements.
+const SpecVersion = "1.0"
+
+// Envelope is a CloudEvents 1.0 structured-mode envelope: the context
+// attributes and the event payload travel together in one JSON document.
+type Envelope struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+	// TraceParent carries the W3C traceparent of the event that caused this
+	// one, as a CloudEvents extension attribute. Omitted when empty.
+	TraceParent string `json:"traceparent,omitempty"`
+}
+
+// Wrap builds a structured-mode envelope around data. domainType is the
+// module's short event type (e.g. "order.created") and is mapped to the
+// CloudEvents "type" attribute as "com.medisupply.<domainType>.v1", giving
+// consumers a stable, versioned type regardless of how the module names its
+// own events internally.
+func Wrap(source, domainType, subject string, data any, traceParent string) (*Envelope, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("cloudevents: failed to marshal event data: %w", err)
+	}
+
+	return &Envelope{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.New().String(),
+		Source:          source,
+		Type:            Type(domainType),
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            payload,
+		TraceParent:     traceParent,
+	}, nil
+}
+
+// Type maps a module-internal event type like "order.created" to the
+// CloudEvents "type" attribute convention used across medisupply services:
+// "com.medisupply.order.created.v1".
+func Type(domainType string) string {
+	return fmt.Sprintf("com.medisupply.%s.v1", domainType)
+}
+
+// Unwrap decodes envelope.Data into out. Callers that need to auto-detect a
+// structured-mode payload should check for a non-empty SpecVersion first
+// (see messaging.Registry's content-type sniffing).
+func (e *Envelope) Unwrap(out any) error {
+	return json.Unmarshal(e.Data, out)
+}
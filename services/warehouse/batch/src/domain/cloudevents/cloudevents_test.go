@@ -0,0 +1,43 @@
+package cloudevents
+
+import "testing"
+
+func TestType_MapsDomainTypeToVersionedCloudEventType(t *testing.T) {
+	got := Type("order.created")
+	want := "com.medisupply.order.created.v1"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWrap_RoundTripsDataThroughUnwrap(t *testing.T) {
+	type payload struct {
+		Foo string `json:"foo"`
+	}
+
+	envelope, err := Wrap("warehouse-batch-service", "batch.completed", "BATCH-1", payload{Foo: "bar"}, "traceparent-value")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if envelope.SpecVersion != SpecVersion {
+		t.Errorf("expected specversion %q, got %q", SpecVersion, envelope.SpecVersion)
+	}
+	if envelope.Type != "com.medisupply.batch.completed.v1" {
+		t.Errorf("unexpected type: %s", envelope.Type)
+	}
+	if envelope.ID == "" {
+		t.Error("expected a generated ID")
+	}
+	if envelope.TraceParent != "traceparent-value" {
+		t.Errorf("expected traceparent to round-trip, got %q", envelope.TraceParent)
+	}
+
+	var decoded payload
+	if err := envelope.Unwrap(&decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Foo != "bar" {
+		t.Errorf("expected decoded.Foo to be %q, got %q", "bar", decoded.Foo)
+	}
+}
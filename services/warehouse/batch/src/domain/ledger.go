@@ -0,0 +1,15 @@
+package domain
+
+// ProcessedEventLedger records which inbound order events have already been
+// handled, so a broker redelivery (Kafka/RabbitMQ at-least-once semantics)
+// can be detected and the handler short-circuited instead of reapplying its
+// side effects, e.g. adding the same order to a batch twice.
+type ProcessedEventLedger interface {
+	// SeenBefore reports whether eventID was already recorded by a prior
+	// MarkProcessed call.
+	SeenBefore(eventID string) (bool, error)
+	// MarkProcessed records eventID as processed, along with a short
+	// human-readable result (e.g. "ok", or an error summary) kept for
+	// diagnostics.
+	MarkProcessed(eventID, result string) error
+}
@@ -1,6 +1,11 @@
 package domain
 
-import "log"
+import (
+	"context"
+	"log"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/tracing"
+)
 
 // MockBatchEventPublisher is a mock implementation of BatchEventPublisher for testing
 type MockBatchEventPublisher struct {
@@ -17,8 +22,16 @@ func NewMockBatchEventPublisher() *MockBatchEventPublisher {
 	}
 }
 
-// PublishBatchEvent implements the BatchEventPublisher interface
-func (m *MockBatchEventPublisher) PublishBatchEvent(event *BatchEvent) error {
+// PublishBatchEvent implements the BatchEventPublisher interface. Like the
+// real adapters, it runs under a span (see pkg/tracing) so tests exercising
+// trace propagation see a MockBatchEventPublisher-produced span too.
+func (m *MockBatchEventPublisher) PublishBatchEvent(ctx context.Context, event *BatchEvent) (err error) {
+	endSpan := tracing.StartSpan(ctx, "mock.publish_batch_event", map[string]string{
+		"event_type": string(event.EventType),
+		"batch_id":   event.BatchID,
+	})
+	defer endSpan(&err)
+
 	if m.ShouldFail {
 		if m.FailureError != nil {
 			return m.FailureError
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Order represents an order in the system
 type Order struct {
@@ -20,6 +23,25 @@ type OrderEvent struct {
 	OrderID   string    `json:"order_id"`
 	Order     Order     `json:"order"`
 	Timestamp time.Time `json:"timestamp"`
+	// TraceParent is the W3C traceparent propagated from the CloudEvents
+	// headers on the Kafka message, when present, so it can flow through to
+	// any batch event this order event triggers.
+	TraceParent string `json:"traceparent,omitempty"`
+	// EventID is an explicit, producer-assigned identifier for this event,
+	// when set. Used as the ProcessedEventLedger key in preference to the
+	// derived key from LedgerKey.
+	EventID string `json:"event_id,omitempty"`
+}
+
+// LedgerKey returns the identifier a ProcessedEventLedger should use to
+// detect a redelivered copy of this event: the explicit EventID when the
+// producer set one, otherwise a key derived from (EventType, OrderID,
+// Timestamp).
+func (oe OrderEvent) LedgerKey() string {
+	if oe.EventID != "" {
+		return oe.EventID
+	}
+	return fmt.Sprintf("%s:%s:%d", oe.EventType, oe.OrderID, oe.Timestamp.UnixNano())
 }
 
 // OrderEventHandler defines the contract for handling order events
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// OutboxEvent is a BatchEvent queued for publishing in the same unit of work
+// as the batch state change that produced it, so the pair can be persisted
+// atomically and a broker outage never loses the event outright. Sequence is
+// monotonic per AggregateID, giving consumers a (AggregateID, Sequence) key
+// to detect replayed rows after a relay retry.
+type OutboxEvent struct {
+	ID          string
+	AggregateID string
+	Sequence    int
+	Event       *BatchEvent
+	Published   bool
+	Attempts    int
+	LastError   string
+	CreatedAt   time.Time
+	PublishedAt *time.Time
+}
+
+// OutboxRepository persists outbox rows alongside the aggregate they
+// describe, so SaveBatchAndEvents can apply both in the same unit of work.
+// BatchMemoryRepository implements this directly; a SQL-backed implementation
+// would wrap both writes in a single DB transaction.
+type OutboxRepository interface {
+	// SaveBatchAndEvents atomically saves batch and appends events to the
+	// outbox, assigning each a Sequence after the highest already recorded
+	// for batch.ID.
+	SaveBatchAndEvents(batch *Batch, events []*BatchEvent) error
+	// FindUnpublished returns up to limit outbox rows not yet marked
+	// published, ordered by (AggregateID, Sequence) for fair relay across
+	// aggregates.
+	FindUnpublished(limit int) ([]*OutboxEvent, error)
+	// MarkPublished marks the outbox row published so the relay won't pick
+	// it up again.
+	MarkPublished(id string) error
+	// MarkFailed records a failed publish attempt against the row, for
+	// backoff and outbox-lag observability.
+	MarkFailed(id string, publishErr error) error
+	// CountUnpublished returns the number of rows not yet published, used to
+	// report outbox lag.
+	CountUnpublished() (int, error)
+}
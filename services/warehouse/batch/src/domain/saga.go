@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SagaStatus represents the overall lifecycle state of a saga.
+type SagaStatus string
+
+const (
+	SagaStatusRunning      SagaStatus = "running"
+	SagaStatusCompleted    SagaStatus = "completed"
+	SagaStatusCompensating SagaStatus = "compensating"
+	SagaStatusCompensated  SagaStatus = "compensated"
+	// SagaStatusFailed means a step failed AND the subsequent compensation
+	// also failed; the saga is stuck and requires manual intervention.
+	SagaStatusFailed SagaStatus = "failed"
+)
+
+// StepStatus represents the outcome of a single saga step.
+type StepStatus string
+
+const (
+	StepStatusPending     StepStatus = "pending"
+	StepStatusCompleted   StepStatus = "completed"
+	StepStatusFailed      StepStatus = "failed"
+	StepStatusCompensated StepStatus = "compensated"
+)
+
+// SagaStepState is the persisted outcome of one step in a Saga.
+type SagaStepState struct {
+	StepID string     `json:"step_id"`
+	Status StepStatus `json:"status"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// Saga is the persisted state of a cross-service transaction: its type (so
+// the orchestrator can look up the step/compensator definition again after a
+// restart), its current position, and the per-step outcomes needed to resume
+// or compensate it.
+type Saga struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Status      SagaStatus      `json:"status"`
+	CurrentStep int             `json:"current_step"`
+	Steps       []SagaStepState `json:"steps"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// SagaRepository defines the contract for saga persistence, so an
+// in-progress saga can be resumed or inspected after a crash.
+type SagaRepository interface {
+	Save(saga *Saga) error
+	FindByID(id string) (*Saga, error)
+	FindAll() ([]*Saga, error)
+}
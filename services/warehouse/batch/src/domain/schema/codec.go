@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+// magicByte is Confluent's wire-format marker: every envelope this package
+// produces or consumes starts with this byte, followed by a 4-byte
+// big-endian schema ID, followed by the payload.
+const magicByte = 0x0
+
+// envelopeHeaderSize is the magic byte plus the 4-byte schema ID.
+const envelopeHeaderSize = 5
+
+// Codec wraps and unwraps a payload in the Confluent Schema Registry wire
+// format. Encode is keyed by subject (a Schema Registry subject name, e.g.
+// OrderEventSubject) so a single Codec can serve every event type this
+// service publishes; Decode needs no subject, since the schema ID in the
+// envelope already identifies it.
+type Codec interface {
+	Encode(ctx context.Context, subject string, payload []byte) ([]byte, error)
+	Decode(ctx context.Context, payload []byte) ([]byte, error)
+}
+
+// PassthroughCodec is the no-op Codec used when no schema registry is
+// configured (SCHEMA_REGISTRY_URL unset): Encode and Decode return payload
+// unchanged, so callers get today's plain-JSON behavior.
+type PassthroughCodec struct{}
+
+// Encode implements Codec.
+func (PassthroughCodec) Encode(ctx context.Context, subject string, payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+// Decode implements Codec.
+func (PassthroughCodec) Decode(ctx context.Context, payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+// RegistryCodec is the real Codec, backed by a RegistryClient. It registers
+// each subject's schema on first use (see schemas) and caches the resulting
+// ID for the lifetime of the process, so a hot publish path only pays the
+// registration round-trip once.
+type RegistryCodec struct {
+	client  *RegistryClient
+	schemas map[string]Schema
+}
+
+// NewRegistryCodec returns a RegistryCodec resolving subject schemas from
+// schemas (see DefaultSchemas) against client.
+func NewRegistryCodec(client *RegistryClient, schemas map[string]Schema) *RegistryCodec {
+	return &RegistryCodec{client: client, schemas: schemas}
+}
+
+// Encode registers subject's schema (if not already registered) and
+// prepends the resulting schema ID, Confluent wire-format style, to payload.
+func (c *RegistryCodec) Encode(ctx context.Context, subject string, payload []byte) ([]byte, error) {
+	def, ok := c.schemas[subject]
+	if !ok {
+		return nil, fmt.Errorf("schema: no schema registered for subject %q", subject)
+	}
+
+	id, err := c.client.Register(ctx, subject, def.Text, def.Type)
+	if err != nil {
+		return nil, fmt.Errorf("schema: failed to resolve schema id for subject %q: %w", subject, err)
+	}
+
+	envelope := make([]byte, envelopeHeaderSize+len(payload))
+	envelope[0] = magicByte
+	binary.BigEndian.PutUint32(envelope[1:envelopeHeaderSize], uint32(id))
+	copy(envelope[envelopeHeaderSize:], payload)
+	return envelope, nil
+}
+
+// Decode strips the Confluent wire-format envelope from payload, confirming
+// the schema ID it carries resolves against the registry, and returns the
+// remaining bytes.
+func (c *RegistryCodec) Decode(ctx context.Context, payload []byte) ([]byte, error) {
+	if len(payload) < envelopeHeaderSize || payload[0] != magicByte {
+		return nil, fmt.Errorf("schema: payload is missing the Confluent wire-format envelope")
+	}
+
+	id := binary.BigEndian.Uint32(payload[1:envelopeHeaderSize])
+	if _, err := c.client.GetByID(ctx, int(id)); err != nil {
+		return nil, fmt.Errorf("schema: failed to resolve schema id %d: %w", id, err)
+	}
+
+	return payload[envelopeHeaderSize:], nil
+}
+
+// NewCodec returns a RegistryCodec against registryURL, or PassthroughCodec
+// when registryURL is empty (SCHEMA_REGISTRY_URL unset). The bool return
+// reports which one, so a caller like main.go can decide whether to tag
+// outgoing messages with the schema-registry content type.
+func NewCodec(registryURL string) (codec Codec, enabled bool) {
+	if registryURL == "" {
+		return PassthroughCodec{}, false
+	}
+	return NewRegistryCodec(NewRegistryClient(registryURL, nil), DefaultSchemas()), true
+}
@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPassthroughCodec_RoundTripsUnchanged(t *testing.T) {
+	codec := PassthroughCodec{}
+	payload := []byte(`{"batch_id":"BATCH-1"}`)
+
+	encoded, err := codec.Encode(context.Background(), BatchEventSubject, payload)
+	if err != nil {
+		t.Fatalf("Encode() returned error: %v", err)
+	}
+	if string(encoded) != string(payload) {
+		t.Errorf("Encode() = %q, want unchanged %q", encoded, payload)
+	}
+
+	decoded, err := codec.Decode(context.Background(), encoded)
+	if err != nil {
+		t.Fatalf("Decode() returned error: %v", err)
+	}
+	if string(decoded) != string(payload) {
+		t.Errorf("Decode() = %q, want unchanged %q", decoded, payload)
+	}
+}
+
+func TestRegistryCodec_EncodeThenDecode_RoundTrips(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			w.Write([]byte(`{"id": 42}`))
+		default:
+			w.Write([]byte(`{"schema": "{}"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewRegistryClient(server.URL, nil)
+	codec := NewRegistryCodec(client, DefaultSchemas())
+
+	payload := []byte(`{"batch_id":"BATCH-1"}`)
+	encoded, err := codec.Encode(context.Background(), BatchEventSubject, payload)
+	if err != nil {
+		t.Fatalf("Encode() returned error: %v", err)
+	}
+	if len(encoded) != envelopeHeaderSize+len(payload) {
+		t.Fatalf("Encode() produced %d bytes, want %d", len(encoded), envelopeHeaderSize+len(payload))
+	}
+
+	decoded, err := codec.Decode(context.Background(), encoded)
+	if err != nil {
+		t.Fatalf("Decode() returned error: %v", err)
+	}
+	if string(decoded) != string(payload) {
+		t.Errorf("Decode() = %q, want %q", decoded, payload)
+	}
+}
+
+func TestRegistryCodec_Encode_UnknownSubjectFails(t *testing.T) {
+	client := NewRegistryClient("http://unused", nil)
+	codec := NewRegistryCodec(client, map[string]Schema{})
+
+	if _, err := codec.Encode(context.Background(), BatchEventSubject, []byte(`{}`)); err == nil {
+		t.Fatal("expected an error for a subject with no registered schema")
+	}
+}
+
+func TestRegistryCodec_Decode_RejectsPayloadWithoutEnvelope(t *testing.T) {
+	client := NewRegistryClient("http://unused", nil)
+	codec := NewRegistryCodec(client, DefaultSchemas())
+
+	if _, err := codec.Decode(context.Background(), []byte(`{"batch_id":"BATCH-1"}`)); err == nil {
+		t.Fatal("expected an error for a payload missing the wire-format envelope")
+	}
+}
+
+func TestNewCodec_EmptyURLReturnsPassthrough(t *testing.T) {
+	codec, enabled := NewCodec("")
+	if enabled {
+		t.Error("expected enabled=false for an empty registry URL")
+	}
+	if _, ok := codec.(PassthroughCodec); !ok {
+		t.Errorf("expected a PassthroughCodec, got %T", codec)
+	}
+}
+
+func TestNewCodec_NonEmptyURLReturnsRegistryCodec(t *testing.T) {
+	codec, enabled := NewCodec("http://schema-registry:8081")
+	if !enabled {
+		t.Error("expected enabled=true for a non-empty registry URL")
+	}
+	if _, ok := codec.(*RegistryCodec); !ok {
+		t.Errorf("expected a *RegistryCodec, got %T", codec)
+	}
+}
@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// RegistryClient is a minimal Confluent Schema Registry HTTP client: just
+// enough to register a subject's schema and resolve a schema by the ID the
+// registry assigned it, each cached locally so a hot publish/consume path
+// only round-trips to the registry once per subject or ID.
+type RegistryClient struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	idBySubject map[string]int
+	textByID    map[int]string
+}
+
+// NewRegistryClient returns a RegistryClient against baseURL (e.g.
+// "http://schema-registry:8081"). A nil httpClient uses http.DefaultClient.
+func NewRegistryClient(baseURL string, httpClient *http.Client) *RegistryClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &RegistryClient{
+		baseURL:     baseURL,
+		httpClient:  httpClient,
+		idBySubject: make(map[string]int),
+		textByID:    make(map[int]string),
+	}
+}
+
+// registerRequest is the POST /subjects/{name}/versions request body.
+type registerRequest struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType"`
+}
+
+// registerResponse is the POST /subjects/{name}/versions response body.
+type registerResponse struct {
+	ID int `json:"id"`
+}
+
+// Register registers schemaText (of schemaType, e.g. SchemaTypeJSON) under
+// subject via POST /subjects/{subject}/versions, returning the ID the
+// registry assigned it. A subject already registered with the same text
+// returns the existing ID, per the registry's own idempotency guarantee.
+// The resolved ID is cached, so a later Register or GetByID call for the
+// same subject/ID doesn't hit the network again.
+func (c *RegistryClient) Register(ctx context.Context, subject, schemaText, schemaType string) (int, error) {
+	c.mu.Lock()
+	if id, ok := c.idBySubject[subject]; ok {
+		c.mu.Unlock()
+		return id, nil
+	}
+	c.mu.Unlock()
+
+	body, err := json.Marshal(registerRequest{Schema: schemaText, SchemaType: schemaType})
+	if err != nil {
+		return 0, fmt.Errorf("schema: failed to marshal register request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/subjects/%s/versions", c.baseURL, subject)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("schema: failed to build register request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("schema: register request for subject %q failed: %w", subject, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("schema: registry rejected subject %q with status %d", subject, resp.StatusCode)
+	}
+
+	var decoded registerResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return 0, fmt.Errorf("schema: failed to decode register response for subject %q: %w", subject, err)
+	}
+
+	c.mu.Lock()
+	c.idBySubject[subject] = decoded.ID
+	c.textByID[decoded.ID] = schemaText
+	c.mu.Unlock()
+
+	return decoded.ID, nil
+}
+
+// getByIDResponse is the GET /schemas/ids/{id} response body.
+type getByIDResponse struct {
+	Schema string `json:"schema"`
+}
+
+// GetByID resolves id to its registered schema text via
+// GET /schemas/ids/{id}, serving from the local cache when already known
+// (e.g. from a prior Register call for the same subject).
+func (c *RegistryClient) GetByID(ctx context.Context, id int) (string, error) {
+	c.mu.Lock()
+	if text, ok := c.textByID[id]; ok {
+		c.mu.Unlock()
+		return text, nil
+	}
+	c.mu.Unlock()
+
+	url := fmt.Sprintf("%s/schemas/ids/%d", c.baseURL, id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("schema: failed to build lookup request for id %d: %w", id, err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("schema: lookup request for id %d failed: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("schema: registry has no schema registered for id %d (status %d)", id, resp.StatusCode)
+	}
+
+	var decoded getByIDResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return "", fmt.Errorf("schema: failed to decode lookup response for id %d: %w", id, err)
+	}
+
+	c.mu.Lock()
+	c.textByID[id] = decoded.Schema
+	c.mu.Unlock()
+
+	return decoded.Schema, nil
+}
@@ -0,0 +1,97 @@
+package schema
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRegistryClient_Register_ReturnsAssignedID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/subjects/warehouse-batch-events-value/versions" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		w.Write([]byte(`{"id": 7}`))
+	}))
+	defer server.Close()
+
+	client := NewRegistryClient(server.URL, nil)
+	id, err := client.Register(context.Background(), BatchEventSubject, "{}", SchemaTypeJSON)
+	if err != nil {
+		t.Fatalf("Register() returned error: %v", err)
+	}
+	if id != 7 {
+		t.Errorf("Register() = %d, want 7", id)
+	}
+}
+
+func TestRegistryClient_Register_CachesBySubject(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"id": 3}`))
+	}))
+	defer server.Close()
+
+	client := NewRegistryClient(server.URL, nil)
+	for i := 0; i < 3; i++ {
+		if _, err := client.Register(context.Background(), BatchEventSubject, "{}", SchemaTypeJSON); err != nil {
+			t.Fatalf("Register() returned error: %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("registry saw %d requests, want 1 (subsequent calls should hit the cache)", requests)
+	}
+}
+
+func TestRegistryClient_GetByID_ResolvesSchemaText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/schemas/ids/7" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		w.Write([]byte(`{"schema": "{\"title\":\"BatchEvent\"}"}`))
+	}))
+	defer server.Close()
+
+	client := NewRegistryClient(server.URL, nil)
+	text, err := client.GetByID(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetByID() returned error: %v", err)
+	}
+	if text != `{"title":"BatchEvent"}` {
+		t.Errorf("GetByID() = %q, want %q", text, `{"title":"BatchEvent"}`)
+	}
+}
+
+func TestRegistryClient_GetByID_CachesByID(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"schema": "{}"}`))
+	}))
+	defer server.Close()
+
+	client := NewRegistryClient(server.URL, nil)
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetByID(context.Background(), 7); err != nil {
+			t.Fatalf("GetByID() returned error: %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("registry saw %d requests, want 1 (subsequent calls should hit the cache)", requests)
+	}
+}
+
+func TestRegistryClient_GetByID_UnknownIDReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewRegistryClient(server.URL, nil)
+	if _, err := client.GetByID(context.Background(), 999); err == nil {
+		t.Fatal("expected an error for an unknown schema id")
+	}
+}
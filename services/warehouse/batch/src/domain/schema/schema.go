@@ -0,0 +1,76 @@
+// Package schema integrates this service with a Confluent-compatible
+// Schema Registry (GET/POST /subjects/{name}/versions, GET /schemas/ids/{id})
+// so OrderEvent and BatchEvent payloads carry a registered, versioned schema
+// instead of drifting silently across services as ad-hoc JSON.
+//
+// It deliberately does not introduce binary Avro or Protobuf encoding: this
+// repo has no go.mod/vendoring to pull in an Avro or Protobuf codec library
+// (see messaging.ProtobufCodec's own admission that it has "no generated
+// protobuf message types yet"), and rewriting every producer/consumer's wire
+// format to a binary encoding is a much larger, riskier change than what this
+// package is scoped to. Instead, Schema.Text holds a JSON-Schema-style
+// description of an event's required shape, registered as SchemaType "JSON"
+// - the registry API itself is schema-language-agnostic, so this still gets
+// the compatibility-checking and cross-service discoverability a registry
+// exists for. RegistryCodec then wraps the existing JSON payload in the
+// Confluent wire-format envelope (magic byte + 4-byte schema ID) so a
+// consumer can resolve exactly which registered schema produced a message.
+package schema
+
+// Schema describes a subject registered with the Schema Registry: the
+// schema's text (for this package, a JSON-Schema-style description of an
+// event's required fields) and the registry's "schemaType" for it.
+type Schema struct {
+	Text string
+	Type string
+}
+
+// SchemaTypeJSON is the Schema Registry's "schemaType" value for JSON
+// Schema-style definitions, as opposed to "AVRO" or "PROTOBUF".
+const SchemaTypeJSON = "JSON"
+
+// OrderEventSubject is the Schema Registry subject name for domain.OrderEvent
+// payloads, following Confluent's "{topic}-value" convention for the
+// order-events topic.
+const OrderEventSubject = "order-events-value"
+
+// BatchEventSubject is the Schema Registry subject name for
+// domain.BatchEvent payloads, following Confluent's "{topic}-value"
+// convention for the warehouse-batch-events topic.
+const BatchEventSubject = "warehouse-batch-events-value"
+
+// DefaultSchemas returns the Schema Registry subjects this service registers
+// for its own event types: OrderEventSubject and BatchEventSubject.
+func DefaultSchemas() map[string]Schema {
+	return map[string]Schema{
+		OrderEventSubject: {
+			Type: SchemaTypeJSON,
+			Text: `{
+  "title": "OrderEvent",
+  "type": "object",
+  "required": ["event_type", "order_id", "order", "timestamp"],
+  "properties": {
+    "event_type": {"type": "string"},
+    "order_id": {"type": "string"},
+    "order": {"type": "object"},
+    "timestamp": {"type": "string", "format": "date-time"}
+  }
+}`,
+		},
+		BatchEventSubject: {
+			Type: SchemaTypeJSON,
+			Text: `{
+  "title": "BatchEvent",
+  "type": "object",
+  "required": ["event_type", "batch_id", "product_id", "timestamp"],
+  "properties": {
+    "event_type": {"type": "string"},
+    "batch_id": {"type": "string"},
+    "product_id": {"type": "string"},
+    "order_id": {"type": ["string", "null"]},
+    "timestamp": {"type": "string", "format": "date-time"}
+  }
+}`,
+		},
+	}
+}
@@ -1,162 +1,516 @@
-package drivenadapters
-
-import (
-	"context"
-	"encoding/json"
-	"fmt"
-	"log"
-	"strings"
-	"time"
-
-	"github.com/segmentio/kafka-go"
-	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
-)
-
-// BatchEventPublisherAdapter implements the BatchEventPublisher interface using Kafka
-type BatchEventPublisherAdapter struct {
-	writer        *kafka.Writer
-	topic         string
-	brokerAddress string
-}
-
-// NewBatchEventPublisherAdapter creates a new BatchEventPublisherAdapter
-func NewBatchEventPublisherAdapter(brokerAddress, topic string) *BatchEventPublisherAdapter {
-	writer := &kafka.Writer{
-		Addr:         kafka.TCP(brokerAddress),
-		Topic:        topic,
-		Balancer:     &kafka.LeastBytes{},
-		RequiredAcks: kafka.RequireOne,
-		Async:        false, // Synchronous writes for reliability
-		WriteTimeout: 10 * time.Second,
-		ReadTimeout:  10 * time.Second,
-	}
-
-	return &BatchEventPublisherAdapter{
-		writer:        writer,
-		topic:         topic,
-		brokerAddress: brokerAddress,
-	}
-}
-
-// PublishBatchEvent publishes a batch event to Kafka
-func (p *BatchEventPublisherAdapter) PublishBatchEvent(event *domain.BatchEvent) error {
-	// Serialize the event to JSON
-	eventData, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to marshal batch event: %w", err)
-	}
-
-	// Create Kafka message
-	message := kafka.Message{
-		Key:   []byte(event.BatchID), // Use batch ID as partition key
-		Value: eventData,
-		Headers: []kafka.Header{
-			{
-				Key:   "event_type",
-				Value: []byte(event.EventType),
-			},
-			{
-				Key:   "batch_id",
-				Value: []byte(event.BatchID),
-			},
-			{
-				Key:   "product_id",
-				Value: []byte(event.ProductID),
-			},
-			{
-				Key:   "timestamp",
-				Value: []byte(event.Timestamp.Format(time.RFC3339)),
-			},
-		},
-	}
-
-	// Add order_id header if present
-	if event.OrderID != nil {
-		message.Headers = append(message.Headers, kafka.Header{
-			Key:   "order_id",
-			Value: []byte(*event.OrderID),
-		})
-	}
-
-	// Write message to Kafka with retry logic for topic/partition errors
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	err = p.writer.WriteMessages(ctx, message)
-	if err != nil {
-		// Check if it's an "Unknown Topic Or Partition" error (Kafka error code 3)
-		if isUnknownTopicOrPartitionError(err) {
-			log.Printf("Unknown topic or partition error detected for topic '%s', attempting to recreate writer: %v", p.topic, err)
-			
-			// Close the current writer
-			if closeErr := p.writer.Close(); closeErr != nil {
-				log.Printf("Warning: failed to close old writer: %v", closeErr)
-			}
-			
-			// Recreate the writer
-			p.recreateWriter()
-			
-			// Wait a moment for topic to be available
-			log.Printf("Waiting 2 seconds for topic '%s' to become available...", p.topic)
-			time.Sleep(2 * time.Second)
-			
-			// Retry the write operation with new writer
-			log.Printf("Retrying batch event publish for batch %s with recreated writer", event.BatchID)
-			retryCtx, retryCancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer retryCancel()
-			
-			if retryErr := p.writer.WriteMessages(retryCtx, message); retryErr != nil {
-				log.Printf("Retry failed for batch event %s (batch %s): %v", event.EventType, event.BatchID, retryErr)
-				return fmt.Errorf("failed to write batch event to Kafka after retry: %w", retryErr)
-			}
-			
-			log.Printf("Successfully published batch event after writer recreation: %s for batch %s", event.EventType, event.BatchID)
-			return nil
-		}
-		
-		return fmt.Errorf("failed to write batch event to Kafka: %w", err)
-	}
-
-	log.Printf("Successfully published batch event: %s for batch %s", event.EventType, event.BatchID)
-	return nil
-}
-
-// recreateWriter creates a new Kafka writer instance
-func (p *BatchEventPublisherAdapter) recreateWriter() {
-	log.Printf("Recreating Kafka writer for topic %s", p.topic)
-	
-	p.writer = &kafka.Writer{
-		Addr:         kafka.TCP(p.brokerAddress),
-		Topic:        p.topic,
-		Balancer:     &kafka.LeastBytes{},
-		RequiredAcks: kafka.RequireOne,
-		Async:        false, // Synchronous writes for reliability
-		WriteTimeout: 10 * time.Second,
-		ReadTimeout:  10 * time.Second,
-	}
-	
-	log.Printf("Kafka writer recreated successfully for topic %s", p.topic)
-}
-
-// isUnknownTopicOrPartitionError checks if the error is related to unknown topic or partition
-func isUnknownTopicOrPartitionError(err error) bool {
-	if err == nil {
-		return false
-	}
-	
-	// Check for Kafka error code 3 (UnknownTopicOrPartition)
-	// This can appear in different error message formats
-	errStr := strings.ToLower(err.Error())
-	return strings.Contains(errStr, "[3] unknown topic or partition") ||
-		   strings.Contains(errStr, "unknowntopicorpartition") ||
-		   strings.Contains(errStr, "unknown topic or partition") ||
-		   strings.Contains(errStr, "topic or partition that does not exist")
-}
-
-// Close closes the Kafka writer
-func (p *BatchEventPublisherAdapter) Close() error {
-	if p.writer != nil {
-		return p.writer.Close()
-	}
-	return nil
-}
\ No newline at end of file
+package drivenadapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain/cloudevents"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain/schema"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/driven-adapters/kafkaadmin"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/messaging"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/eventing"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/eventschema"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/kafkaauth"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/kafkaoptions"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/tracing"
+	"github.com/segmentio/kafka-go"
+)
+
+// eventSource identifies this service as the CloudEvents "source" attribute.
+const eventSource = "warehouse-batch-service"
+
+// CloudEventsModeBinary carries CloudEvents attributes as "ce_*" Kafka
+// headers alongside a message value that is the plain event JSON.
+const CloudEventsModeBinary = "binary"
+
+// CloudEventsModeStructured wraps the whole event in a single
+// "application/cloudevents+json" envelope per domain/cloudevents, for
+// brokers/bridges that expect the structured-mode content type.
+const CloudEventsModeStructured = "structured"
+
+// SelfHealConfig configures how the adapter reacts to an
+// UnknownTopicOrPartition write error. Its zero value (a nil Admin) leaves
+// the adapter's original behavior unchanged: blindly recreate the writer and
+// retry once, on the assumption that's usually just a race against topic
+// creation on startup.
+type SelfHealConfig struct {
+	// Admin, when non-nil, is consulted to confirm the topic is genuinely
+	// missing (as opposed to a leader election in progress) before the
+	// adapter does anything else about an UnknownTopicOrPartition error.
+	Admin *kafkaadmin.Bootstrapper
+	// AutoCreateTopics, when true, has the adapter create TopicSpec via
+	// Admin once Admin confirms the topic is missing, then wait for the
+	// broker's metadata to reflect it before retrying the write.
+	AutoCreateTopics bool
+	// TopicSpec describes the topic to create when AutoCreateTopics is set.
+	TopicSpec kafkaadmin.TopicSpec
+}
+
+// BatchEventPublisherAdapter implements the BatchEventPublisher interface using Kafka
+type BatchEventPublisherAdapter struct {
+	writerMu        sync.RWMutex
+	writer          *kafka.Writer
+	topic           string
+	brokerAddress   string
+	cloudEventsMode string
+	// transport carries the SASL mechanism and TLS config built from
+	// authConfig, if any, so recreateWriter can reapply it to the fresh
+	// writer it builds after a connection error.
+	transport kafka.RoundTripper
+	// options carries the compression/batching/async tuning recreateWriter
+	// reapplies to the fresh writer it builds after a connection error.
+	options kafkaoptions.PublisherOptions
+	// reconnector recovers the writer from connection errors with
+	// exponential backoff and spools writes behind a circuit breaker once
+	// that backoff window is exhausted; see its doc comment.
+	reconnector *reconnector
+	// selfHeal configures the adapter's reaction to an
+	// UnknownTopicOrPartition write error; see SelfHealConfig's doc comment.
+	selfHeal SelfHealConfig
+	// schemas, when non-nil, validates each event's payload against its
+	// registered eventschema.Schema before it's published.
+	schemas *eventschema.Registry
+	// schemaCodec, when it reports enabled (schemaCodecEnabled), wraps the
+	// published JSON payload in the Confluent Schema Registry wire format
+	// (see domain/schema.Codec) and tags the message with
+	// messaging.SchemaRegistryContentType so a consumer routes it to
+	// messaging.SchemaRegistryCodec instead of plain JSON. Left at its zero
+	// value (schema.PassthroughCodec{}, schemaCodecEnabled false), the
+	// adapter's wire format is unchanged from before this field existed.
+	schemaCodec        schema.Codec
+	schemaCodecEnabled bool
+}
+
+// NewBatchEventPublisherAdapter creates a new BatchEventPublisherAdapter.
+// cloudEventsMode selects CloudEventsModeBinary (default, when empty) or
+// CloudEventsModeStructured. authConfig selects the SASL mechanism and TLS
+// settings the writer authenticates with against brokerAddress; its zero
+// value disables both and kafka-go's default transport is used. options
+// tunes compression, batching, and async mode; see
+// kafkaoptions.PublisherOptions for the reliability trade-offs of each
+// setting - in particular, options.Async trades the synchronous-write
+// reliability this adapter otherwise provides for higher throughput, so it
+// should only be enabled when the caller (e.g. the outbox relay) can tell
+// a publish failed some other way. reconnectConfig tunes the backoff and
+// queue size the adapter falls back to when the connection itself is
+// unhealthy; see reconnector's doc comment. selfHeal configures how the
+// adapter reacts to an UnknownTopicOrPartition write error; its zero value
+// preserves the adapter's original blind recreate-and-retry behavior.
+// schemas may be nil, in which case events are published unvalidated.
+// schemaCodec wraps the published payload in the Schema Registry wire
+// format when schemaCodecEnabled is true (see domain/schema.NewCodec);
+// passing schema.PassthroughCodec{}, false preserves the adapter's original
+// plain-JSON wire format.
+func NewBatchEventPublisherAdapter(brokerAddress, topic, cloudEventsMode string, authConfig kafkaauth.Config, options kafkaoptions.PublisherOptions, reconnectConfig ReconnectConfig, selfHeal SelfHealConfig, schemas *eventschema.Registry, schemaCodec schema.Codec, schemaCodecEnabled bool) *BatchEventPublisherAdapter {
+	transport, err := kafkaauth.BuildTransport(authConfig)
+	if err != nil {
+		log.Printf("Failed to build Kafka auth transport, falling back to an unauthenticated connection: %v", err)
+		transport = nil
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokerAddress),
+		Topic:        topic,
+		Balancer:     &kafka.LeastBytes{},
+		RequiredAcks: kafka.RequireOne,
+		Async:        options.Async,
+		Completion:   options.Completion,
+		Compression:  options.Compression,
+		BatchSize:    options.BatchSize,
+		BatchBytes:   options.BatchBytes,
+		BatchTimeout: options.BatchTimeout,
+		WriteTimeout: 10 * time.Second,
+		ReadTimeout:  10 * time.Second,
+		Transport:    transport,
+	}
+
+	if cloudEventsMode == "" {
+		cloudEventsMode = CloudEventsModeBinary
+	}
+
+	return &BatchEventPublisherAdapter{
+		writer:             writer,
+		topic:              topic,
+		brokerAddress:      brokerAddress,
+		cloudEventsMode:    cloudEventsMode,
+		transport:          transport,
+		options:            options,
+		reconnector:        newReconnector(reconnectConfig),
+		selfHeal:           selfHeal,
+		schemas:            schemas,
+		schemaCodec:        schemaCodec,
+		schemaCodecEnabled: schemaCodecEnabled,
+	}
+}
+
+// ConnectionState returns the adapter's current view of its Kafka
+// connectivity.
+func (p *BatchEventPublisherAdapter) ConnectionState() ConnectionState {
+	return p.reconnector.State()
+}
+
+// ConnectionStateChanges returns a channel of ConnectionState transitions,
+// so the application layer can react to a degraded connection (e.g. pause
+// accepting new batches while ConnectionStateFailed).
+func (p *BatchEventPublisherAdapter) ConnectionStateChanges() <-chan ConnectionState {
+	return p.reconnector.StateChanges()
+}
+
+// PublishBatchEvent publishes a batch event to Kafka. The publish is wrapped
+// in a span (see pkg/tracing) carrying the broker address and topic as
+// attributes, so it shows up alongside whatever trace ctx carries.
+func (p *BatchEventPublisherAdapter) PublishBatchEvent(ctx context.Context, event *domain.BatchEvent) (err error) {
+	endSpan := tracing.StartSpan(ctx, "kafka.publish_batch_event", map[string]string{
+		"broker":     p.brokerAddress,
+		"topic":      p.topic,
+		"event_type": string(event.EventType),
+	})
+	defer endSpan(&err)
+
+	if p.schemas != nil {
+		if err := p.validateSchema(event); err != nil {
+			return err
+		}
+	}
+
+	message, err := p.buildMessage(event)
+	if err != nil {
+		return err
+	}
+
+	err = p.write(ctx, event, message)
+	return err
+}
+
+// validateSchema marshals event and checks it against the schema registered
+// for its event type, if any (see eventschema.Registry.Validate).
+func (p *BatchEventPublisherAdapter) validateSchema(event *domain.BatchEvent) error {
+	eventData, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch event for schema validation: %w", err)
+	}
+
+	if err := p.schemas.Validate(string(event.EventType), eventData); err != nil {
+		return fmt.Errorf("batch event failed schema validation: %w", err)
+	}
+	return nil
+}
+
+// buildMessage renders event as a kafka.Message in the adapter's configured
+// CloudEvents mode.
+func (p *BatchEventPublisherAdapter) buildMessage(event *domain.BatchEvent) (kafka.Message, error) {
+	if p.cloudEventsMode == CloudEventsModeStructured {
+		return p.buildStructuredMessage(event)
+	}
+	return p.buildBinaryMessage(event)
+}
+
+// buildStructuredMessage wraps event in a single CloudEvents 1.0
+// "application/cloudevents+json" envelope, for brokers/bridges that expect
+// structured mode rather than "ce_*" headers.
+func (p *BatchEventPublisherAdapter) buildStructuredMessage(event *domain.BatchEvent) (kafka.Message, error) {
+	envelope, err := cloudevents.Wrap(eventSource, string(event.EventType), event.BatchID, event, event.TraceParent)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to build structured CloudEvents envelope: %w", err)
+	}
+
+	envelopeData, err := json.Marshal(envelope)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal CloudEvents envelope: %w", err)
+	}
+
+	return kafka.Message{
+		Key:   []byte(event.BatchID),
+		Value: envelopeData,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/cloudevents+json")},
+		},
+	}, nil
+}
+
+// buildBinaryMessage renders event as plain JSON with CloudEvents 1.0
+// attributes carried as "ce_*" binary-mode headers alongside the existing
+// domain headers, so consumers get a stable id/source/type/subject for
+// tracing without changing the message value.
+func (p *BatchEventPublisherAdapter) buildBinaryMessage(event *domain.BatchEvent) (kafka.Message, error) {
+	// Serialize the event to JSON
+	eventData, err := json.Marshal(event)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal batch event: %w", err)
+	}
+
+	headers := []kafka.Header{}
+	if p.schemaCodecEnabled {
+		wrapped, err := p.schemaCodec.Encode(context.Background(), schema.BatchEventSubject, eventData)
+		if err != nil {
+			return kafka.Message{}, fmt.Errorf("failed to encode batch event with schema registry: %w", err)
+		}
+		eventData = wrapped
+		headers = append(headers, kafka.Header{Key: "content-type", Value: []byte(messaging.SchemaRegistryContentType)})
+	}
+
+	ceAttrs := eventing.NewAttributes(eventSource, cloudevents.Type(string(event.EventType)), event.BatchID, event.TraceParent)
+
+	// Create Kafka message
+	message := kafka.Message{
+		Key:   []byte(event.BatchID), // Use batch ID as partition key
+		Value: eventData,
+		Headers: append(append(headers, ceAttrs.Headers()...),
+			kafka.Header{
+				Key:   "event_type",
+				Value: []byte(event.EventType),
+			},
+			kafka.Header{
+				Key:   "batch_id",
+				Value: []byte(event.BatchID),
+			},
+			kafka.Header{
+				Key:   "product_id",
+				Value: []byte(event.ProductID),
+			},
+			kafka.Header{
+				Key:   "timestamp",
+				Value: []byte(event.Timestamp.Format(time.RFC3339)),
+			},
+		),
+	}
+
+	// Add order_id header if present
+	if event.OrderID != nil {
+		message.Headers = append(message.Headers, kafka.Header{
+			Key:   "order_id",
+			Value: []byte(*event.OrderID),
+		})
+	}
+
+	return message, nil
+}
+
+// write sends message to Kafka. A topic/partition error is retried once
+// immediately against a freshly recreated writer, since that's usually just
+// a race against topic creation on startup. Any other connection error (a
+// network failure, a broker mid-restart, no elected partition leader) hands
+// the message to the reconnector instead: it's queued behind the circuit
+// breaker and a background reconnect loop takes over recovering the
+// writer, so this call doesn't block retrying something the immediate
+// retry above won't fix.
+func (p *BatchEventPublisherAdapter) write(ctx context.Context, event *domain.BatchEvent, message kafka.Message) error {
+	if p.reconnector.circuitOpen() {
+		log.Printf("Circuit breaker open for topic '%s', queuing batch event %s for batch %s instead of writing directly", p.topic, event.EventType, event.BatchID)
+		p.reconnector.enqueue(message)
+		return fmt.Errorf("kafka connection unavailable, batch event %s for batch %s queued for retry", event.EventType, event.BatchID)
+	}
+
+	err := p.writeRaw(ctx, message)
+	if err == nil {
+		p.reconnector.markConnected()
+		log.Printf("Successfully published batch event: %s for batch %s", event.EventType, event.BatchID)
+		return nil
+	}
+
+	if isUnknownTopicOrPartitionError(err) {
+		log.Printf("Unknown topic or partition error detected for topic '%s', attempting to recreate writer: %v", p.topic, err)
+		if p.selfHeal.Admin != nil {
+			p.healTopic(ctx)
+		}
+		p.recreateWriter()
+
+		log.Printf("Retrying batch event publish for batch %s with recreated writer", event.BatchID)
+		retryErr := p.writeRaw(ctx, message)
+		if retryErr == nil {
+			p.reconnector.markConnected()
+			log.Printf("Successfully published batch event after writer recreation: %s for batch %s", event.EventType, event.BatchID)
+			return nil
+		}
+		err = retryErr
+	}
+
+	if isConnectionError(err) {
+		log.Printf("Connection error publishing batch event %s for batch %s, queuing for retry and triggering reconnect: %v", event.EventType, event.BatchID, err)
+		p.reconnector.enqueue(message)
+		p.triggerReconnect(context.Background())
+		return fmt.Errorf("failed to write batch event to Kafka, queued for retry once reconnected: %w", err)
+	}
+
+	return fmt.Errorf("failed to write batch event to Kafka: %w", err)
+}
+
+// writeRaw performs a single direct write against the current writer, with
+// no circuit-breaker or reconnect handling of its own. It's the primitive
+// both write and the reconnector's queue-draining rebuild step are built on.
+func (p *BatchEventPublisherAdapter) writeRaw(ctx context.Context, message kafka.Message) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	p.writerMu.RLock()
+	writer := p.writer
+	p.writerMu.RUnlock()
+
+	return writer.WriteMessages(ctx, message)
+}
+
+// triggerReconnect starts the reconnector's background backoff loop if one
+// isn't already running. Each attempt recreates the writer and, as its
+// success check, tries to drain whatever the circuit breaker has queued so
+// far - an empty queue counts as success, since there's nothing left to
+// validate connectivity against until the next real write.
+func (p *BatchEventPublisherAdapter) triggerReconnect(ctx context.Context) {
+	if !p.reconnector.beginReconnect() {
+		return
+	}
+
+	go func() {
+		defer p.reconnector.endReconnect()
+
+		rebuild := func() error {
+			p.recreateWriter()
+			// The queue-draining rebuild runs on the reconnector's own
+			// background loop, detached from any single publish call's
+			// context, so it uses a fresh background context here.
+			return p.reconnector.drainQueue(func(message kafka.Message) error {
+				return p.writeRaw(context.Background(), message)
+			})
+		}
+
+		if err := p.reconnector.reconnect(ctx, rebuild); err != nil {
+			log.Printf("Batch event publisher exhausted reconnect attempts for topic '%s': %v", p.topic, err)
+		}
+	}()
+}
+
+// healTopic reacts to an UnknownTopicOrPartition write error by confirming
+// with the broker, via selfHeal.Admin, that the topic is genuinely missing -
+// as opposed to a leader election still in progress, which looks the same
+// to a producer - before (if selfHeal.AutoCreateTopics is set) recreating it
+// and waiting for the broker's metadata to reflect it. Every outcome is only
+// logged, never returned: whatever happens here, recreateWriter and the
+// retry below run exactly as they always did.
+func (p *BatchEventPublisherAdapter) healTopic(ctx context.Context) {
+	exists, err := p.selfHeal.Admin.TopicExists(ctx, p.topic)
+	if err != nil {
+		log.Printf("Kafka admin: failed to confirm topic '%s' is missing: %v", p.topic, err)
+		return
+	}
+	if exists {
+		log.Printf("Kafka admin: topic '%s' still exists per broker metadata, likely a transient leader election", p.topic)
+		return
+	}
+	if !p.selfHeal.AutoCreateTopics {
+		log.Printf("Kafka admin: topic '%s' confirmed missing, AutoCreateTopics disabled", p.topic)
+		return
+	}
+
+	log.Printf("Kafka admin: topic '%s' confirmed missing, creating it", p.topic)
+	if err := p.selfHeal.Admin.EnsureTopic(ctx, p.selfHeal.TopicSpec); err != nil {
+		log.Printf("Kafka admin: failed to create topic '%s': %v", p.topic, err)
+		return
+	}
+
+	if err := p.selfHeal.Admin.WaitForTopic(ctx, p.topic, 500*time.Millisecond, 10*time.Second); err != nil {
+		log.Printf("Kafka admin: %v", err)
+	}
+}
+
+// recreateWriter replaces the Kafka writer with a fresh one carrying the
+// same address, topic, auth, and tuning, closing the old one afterwards.
+func (p *BatchEventPublisherAdapter) recreateWriter() {
+	log.Printf("Recreating Kafka writer for topic %s", p.topic)
+
+	newWriter := &kafka.Writer{
+		Addr:         kafka.TCP(p.brokerAddress),
+		Topic:        p.topic,
+		Balancer:     &kafka.LeastBytes{},
+		RequiredAcks: kafka.RequireOne,
+		Async:        p.options.Async,
+		Completion:   p.options.Completion,
+		Compression:  p.options.Compression,
+		BatchSize:    p.options.BatchSize,
+		BatchBytes:   p.options.BatchBytes,
+		BatchTimeout: p.options.BatchTimeout,
+		WriteTimeout: 10 * time.Second,
+		ReadTimeout:  10 * time.Second,
+		Transport:    p.transport,
+	}
+
+	p.writerMu.Lock()
+	oldWriter := p.writer
+	p.writer = newWriter
+	p.writerMu.Unlock()
+
+	if oldWriter != nil {
+		if closeErr := oldWriter.Close(); closeErr != nil {
+			log.Printf("Warning: failed to close old writer: %v", closeErr)
+		}
+	}
+
+	log.Printf("Kafka writer recreated successfully for topic %s", p.topic)
+}
+
+// isUnknownTopicOrPartitionError checks if the error is related to unknown topic or partition
+func isUnknownTopicOrPartitionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// Check for Kafka error code 3 (UnknownTopicOrPartition)
+	// This can appear in different error message formats
+	errStr := strings.ToLower(err.Error())
+	return strings.Contains(errStr, "[3] unknown topic or partition") ||
+		strings.Contains(errStr, "unknowntopicorpartition") ||
+		strings.Contains(errStr, "unknown topic or partition") ||
+		strings.Contains(errStr, "topic or partition that does not exist")
+}
+
+// isConnectionError reports whether err indicates the Kafka connection
+// itself is unhealthy - a network failure, a broker mid-restart, or a
+// partition whose leader hasn't been (re-)elected yet - as opposed to e.g.
+// a malformed message, so the reconnector's backoff loop only kicks in for
+// errors a reconnect might actually fix.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if isUnknownTopicOrPartitionError(err) {
+		return true
+	}
+
+	errStr := strings.ToLower(err.Error())
+	connectionPatterns := []string{
+		"leader not available",
+		"connection refused",
+		"connection reset",
+		"broken pipe",
+		"no route to host",
+		"network is unreachable",
+		"i/o timeout",
+		"eof",
+		"no such host",
+		"not enough replicas",
+		"broker not available",
+	}
+	for _, pattern := range connectionPatterns {
+		if strings.Contains(errStr, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close closes the Kafka writer
+func (p *BatchEventPublisherAdapter) Close() error {
+	p.writerMu.RLock()
+	writer := p.writer
+	p.writerMu.RUnlock()
+
+	if writer != nil {
+		return writer.Close()
+	}
+	return nil
+}
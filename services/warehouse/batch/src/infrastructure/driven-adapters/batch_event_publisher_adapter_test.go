@@ -1,90 +1,180 @@
-package drivenadapters
-
-import (
-	"errors"
-	"testing"
-)
-
-func TestIsUnknownTopicOrPartitionError(t *testing.T) {
-	testCases := []struct {
-		name     string
-		err      error
-		expected bool
-	}{
-		{
-			name:     "nil error",
-			err:      nil,
-			expected: false,
-		},
-		{
-			name:     "exact match with error code",
-			err:      errors.New("[3] Unknown Topic Or Partition: the request is for a topic or partition that does not exist on this broker"),
-			expected: true,
-		},
-		{
-			name:     "lowercase version",
-			err:      errors.New("[3] unknown topic or partition: the request is for a topic or partition that does not exist on this broker"),
-			expected: true,
-		},
-		{
-			name:     "UnknownTopicOrPartition format",
-			err:      errors.New("kafka: UnknownTopicOrPartition"),
-			expected: true,
-		},
-		{
-			name:     "generic unknown topic message",
-			err:      errors.New("unknown topic or partition"),
-			expected: true,
-		},
-		{
-			name:     "topic does not exist message",
-			err:      errors.New("topic or partition that does not exist"),
-			expected: true,
-		},
-		{
-			name:     "different error",
-			err:      errors.New("connection refused"),
-			expected: false,
-		},
-		{
-			name:     "different kafka error",
-			err:      errors.New("[1] OffsetOutOfRange"),
-			expected: false,
-		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			result := isUnknownTopicOrPartitionError(tc.err)
-			if result != tc.expected {
-				t.Errorf("Expected %v, got %v for error: %v", tc.expected, result, tc.err)
-			}
-		})
-	}
-}
-
-func TestBatchEventPublisherAdapterCreation(t *testing.T) {
-	brokerAddress := "localhost:9092"
-	topic := "test-topic"
-	
-	adapter := NewBatchEventPublisherAdapter(brokerAddress, topic)
-	
-	if adapter == nil {
-		t.Fatal("Expected adapter to be created, got nil")
-	}
-	
-	if adapter.topic != topic {
-		t.Errorf("Expected topic %s, got %s", topic, adapter.topic)
-	}
-	
-	if adapter.brokerAddress != brokerAddress {
-		t.Errorf("Expected broker address %s, got %s", brokerAddress, adapter.brokerAddress)
-	}
-	
-	if adapter.writer == nil {
-		t.Error("Expected writer to be initialized")
-	}
-	
-	// Clean up
-	adapter.Close()
-}
\ No newline at end of file
+package drivenadapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain/cloudevents"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain/schema"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/kafkaauth"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/kafkaoptions"
+)
+
+func TestIsUnknownTopicOrPartitionError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: false,
+		},
+		{
+			name:     "exact match with error code",
+			err:      errors.New("[3] Unknown Topic Or Partition: the request is for a topic or partition that does not exist on this broker"),
+			expected: true,
+		},
+		{
+			name:     "lowercase version",
+			err:      errors.New("[3] unknown topic or partition: the request is for a topic or partition that does not exist on this broker"),
+			expected: true,
+		},
+		{
+			name:     "UnknownTopicOrPartition format",
+			err:      errors.New("kafka: UnknownTopicOrPartition"),
+			expected: true,
+		},
+		{
+			name:     "generic unknown topic message",
+			err:      errors.New("unknown topic or partition"),
+			expected: true,
+		},
+		{
+			name:     "topic does not exist message",
+			err:      errors.New("topic or partition that does not exist"),
+			expected: true,
+		},
+		{
+			name:     "different error",
+			err:      errors.New("connection refused"),
+			expected: false,
+		},
+		{
+			name:     "different kafka error",
+			err:      errors.New("[1] OffsetOutOfRange"),
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := isUnknownTopicOrPartitionError(tc.err)
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v for error: %v", tc.expected, result, tc.err)
+			}
+		})
+	}
+}
+
+func TestBatchEventPublisherAdapterCreation(t *testing.T) {
+	brokerAddress := "localhost:9092"
+	topic := "test-topic"
+
+	adapter := NewBatchEventPublisherAdapter(brokerAddress, topic, "", kafkaauth.Config{}, kafkaoptions.PublisherOptions{}, ReconnectConfig{}, SelfHealConfig{}, nil, schema.PassthroughCodec{}, false)
+
+	if adapter == nil {
+		t.Fatal("Expected adapter to be created, got nil")
+	}
+
+	if adapter.topic != topic {
+		t.Errorf("Expected topic %s, got %s", topic, adapter.topic)
+	}
+
+	if adapter.brokerAddress != brokerAddress {
+		t.Errorf("Expected broker address %s, got %s", brokerAddress, adapter.brokerAddress)
+	}
+
+	if adapter.writer == nil {
+		t.Error("Expected writer to be initialized")
+	}
+
+	if adapter.cloudEventsMode != CloudEventsModeBinary {
+		t.Errorf("Expected cloudEventsMode to default to %q, got %q", CloudEventsModeBinary, adapter.cloudEventsMode)
+	}
+
+	// Clean up
+	adapter.Close()
+}
+
+func TestBatchEventPublisherAdapter_BuildStructuredMessage(t *testing.T) {
+	adapter := NewBatchEventPublisherAdapter("localhost:9092", "test-topic", CloudEventsModeStructured, kafkaauth.Config{}, kafkaoptions.PublisherOptions{}, ReconnectConfig{}, SelfHealConfig{}, nil, schema.PassthroughCodec{}, false)
+	defer adapter.Close()
+
+	event := domain.NewBatchCreatedEvent(context.Background(), domain.NewBatch("BATCH-1", "product-1"))
+
+	message, err := adapter.buildMessage(event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	foundContentType := false
+	for _, h := range message.Headers {
+		if h.Key == "content-type" {
+			foundContentType = true
+			if string(h.Value) != "application/cloudevents+json" {
+				t.Errorf("expected content-type application/cloudevents+json, got %s", h.Value)
+			}
+		}
+	}
+	if !foundContentType {
+		t.Error("expected a content-type header on the structured-mode message")
+	}
+
+	var envelope cloudevents.Envelope
+	if err := json.Unmarshal(message.Value, &envelope); err != nil {
+		t.Fatalf("expected message value to be a CloudEvents envelope: %v", err)
+	}
+	if envelope.Type != cloudevents.Type(string(event.EventType)) {
+		t.Errorf("expected type %s, got %s", cloudevents.Type(string(event.EventType)), envelope.Type)
+	}
+	if envelope.Subject != event.BatchID {
+		t.Errorf("expected subject %s, got %s", event.BatchID, envelope.Subject)
+	}
+}
+
+func TestBatchEventPublisherAdapter_BinaryHeadersMatchStructuredPayloadType(t *testing.T) {
+	event := domain.NewBatchCreatedEvent(context.Background(), domain.NewBatch("BATCH-1", "product-1"))
+
+	binaryAdapter := NewBatchEventPublisherAdapter("localhost:9092", "test-topic", CloudEventsModeBinary, kafkaauth.Config{}, kafkaoptions.PublisherOptions{}, ReconnectConfig{}, SelfHealConfig{}, nil, schema.PassthroughCodec{}, false)
+	defer binaryAdapter.Close()
+	structuredAdapter := NewBatchEventPublisherAdapter("localhost:9092", "test-topic", CloudEventsModeStructured, kafkaauth.Config{}, kafkaoptions.PublisherOptions{}, ReconnectConfig{}, SelfHealConfig{}, nil, schema.PassthroughCodec{}, false)
+	defer structuredAdapter.Close()
+
+	binaryMessage, err := binaryAdapter.buildMessage(event)
+	if err != nil {
+		t.Fatalf("unexpected error building binary-mode message: %v", err)
+	}
+	structuredMessage, err := structuredAdapter.buildMessage(event)
+	if err != nil {
+		t.Fatalf("unexpected error building structured-mode message: %v", err)
+	}
+
+	binaryHeader := func(key string) string {
+		for _, h := range binaryMessage.Headers {
+			if h.Key == key {
+				return string(h.Value)
+			}
+		}
+		return ""
+	}
+
+	var envelope cloudevents.Envelope
+	if err := json.Unmarshal(structuredMessage.Value, &envelope); err != nil {
+		t.Fatalf("expected structured message value to be a CloudEvents envelope: %v", err)
+	}
+
+	if binaryHeader("ce_type") != envelope.Type {
+		t.Errorf("expected binary ce_type %q to match structured type %q", binaryHeader("ce_type"), envelope.Type)
+	}
+	if binaryHeader("ce_subject") != envelope.Subject {
+		t.Errorf("expected binary ce_subject %q to match structured subject %q", binaryHeader("ce_subject"), envelope.Subject)
+	}
+	if binaryHeader("content-type") != envelope.DataContentType {
+		t.Errorf("expected binary content-type %q to match structured datacontenttype %q", binaryHeader("content-type"), envelope.DataContentType)
+	}
+}
@@ -1,15 +1,24 @@
 package drivenadapters
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
 )
 
-// BatchMemoryRepository implements BatchRepository using in-memory storage
+// BatchMemoryRepository implements BatchRepository using in-memory storage.
+// It also implements domain.OutboxRepository over the same map and mutex, so
+// SaveBatchAndEvents can persist a batch and its outgoing events atomically -
+// a SQL-backed replacement would do the same in a single DB transaction.
 type BatchMemoryRepository struct {
 	batches map[string]*domain.Batch
+	outbox  map[string]*domain.OutboxEvent
+	nextSeq map[string]int
 	mutex   sync.RWMutex
 }
 
@@ -17,12 +26,14 @@ type BatchMemoryRepository struct {
 func NewBatchMemoryRepository() *BatchMemoryRepository {
 	return &BatchMemoryRepository{
 		batches: make(map[string]*domain.Batch),
+		outbox:  make(map[string]*domain.OutboxEvent),
+		nextSeq: make(map[string]int),
 		mutex:   sync.RWMutex{},
 	}
 }
 
 // Save stores or updates a batch
-func (r *BatchMemoryRepository) Save(batch *domain.Batch) error {
+func (r *BatchMemoryRepository) Save(ctx context.Context, batch *domain.Batch) error {
 	if batch == nil {
 		return fmt.Errorf("batch cannot be nil")
 	}
@@ -173,4 +184,114 @@ func (r *BatchMemoryRepository) GetBatchCount() int {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	return len(r.batches)
+}
+
+// SaveBatchAndEvents implements domain.OutboxRepository. It saves batch and
+// appends events to the outbox under the same lock used by Save, so a reader
+// can never observe the batch state change without its events, or vice
+// versa. Each event is assigned the next Sequence for batch.ID, giving
+// consumers a (AggregateID, Sequence) pair to dedup replayed rows on.
+func (r *BatchMemoryRepository) SaveBatchAndEvents(batch *domain.Batch, events []*domain.BatchEvent) error {
+	if batch == nil {
+		return fmt.Errorf("batch cannot be nil")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	batchCopy := *batch
+	itemsCopy := make([]domain.BatchItem, len(batch.Items))
+	copy(itemsCopy, batch.Items)
+	batchCopy.Items = itemsCopy
+	r.batches[batch.ID] = &batchCopy
+
+	now := time.Now().UTC()
+	for _, event := range events {
+		seq := r.nextSeq[batch.ID] + 1
+		r.nextSeq[batch.ID] = seq
+
+		row := &domain.OutboxEvent{
+			ID:          uuid.New().String(),
+			AggregateID: batch.ID,
+			Sequence:    seq,
+			Event:       event,
+			CreatedAt:   now,
+		}
+		r.outbox[row.ID] = row
+	}
+
+	return nil
+}
+
+// FindUnpublished implements domain.OutboxRepository.
+func (r *BatchMemoryRepository) FindUnpublished(limit int) ([]*domain.OutboxEvent, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var rows []*domain.OutboxEvent
+	for _, row := range r.outbox {
+		if !row.Published {
+			rowCopy := *row
+			rows = append(rows, &rowCopy)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].AggregateID != rows[j].AggregateID {
+			return rows[i].AggregateID < rows[j].AggregateID
+		}
+		return rows[i].Sequence < rows[j].Sequence
+	})
+
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+// MarkPublished implements domain.OutboxRepository.
+func (r *BatchMemoryRepository) MarkPublished(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	row, exists := r.outbox[id]
+	if !exists {
+		return fmt.Errorf("outbox row %s not found", id)
+	}
+
+	now := time.Now().UTC()
+	row.Published = true
+	row.PublishedAt = &now
+	return nil
+}
+
+// MarkFailed implements domain.OutboxRepository.
+func (r *BatchMemoryRepository) MarkFailed(id string, publishErr error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	row, exists := r.outbox[id]
+	if !exists {
+		return fmt.Errorf("outbox row %s not found", id)
+	}
+
+	row.Attempts++
+	if publishErr != nil {
+		row.LastError = publishErr.Error()
+	}
+	return nil
+}
+
+// CountUnpublished implements domain.OutboxRepository.
+func (r *BatchMemoryRepository) CountUnpublished() (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, row := range r.outbox {
+		if !row.Published {
+			count++
+		}
+	}
+	return count, nil
 }
\ No newline at end of file
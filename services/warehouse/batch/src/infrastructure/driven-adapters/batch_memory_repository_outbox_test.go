@@ -0,0 +1,105 @@
+package drivenadapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+)
+
+func TestBatchMemoryRepository_SaveBatchAndEventsAssignsSequentialIDs(t *testing.T) {
+	repo := NewBatchMemoryRepository()
+	batch := domain.NewBatch("BATCH-1", "product-1")
+
+	if err := repo.SaveBatchAndEvents(batch, []*domain.BatchEvent{
+		domain.NewBatchCreatedEvent(context.Background(), batch),
+		domain.NewBatchProcessingStartedEvent(context.Background(), batch),
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := repo.FindUnpublished(10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 unpublished rows, got %d", len(rows))
+	}
+	if rows[0].Sequence != 1 || rows[1].Sequence != 2 {
+		t.Errorf("expected sequences 1 and 2, got %d and %d", rows[0].Sequence, rows[1].Sequence)
+	}
+	if rows[0].AggregateID != batch.ID || rows[1].AggregateID != batch.ID {
+		t.Error("expected both rows to carry the batch ID as AggregateID")
+	}
+
+	saved, err := repo.FindByID(batch.ID)
+	if err != nil {
+		t.Fatalf("expected batch to have been saved alongside its events: %v", err)
+	}
+	if saved.ID != batch.ID {
+		t.Errorf("expected saved batch ID %s, got %s", batch.ID, saved.ID)
+	}
+}
+
+func TestBatchMemoryRepository_MarkPublishedExcludesRowFromUnpublished(t *testing.T) {
+	repo := NewBatchMemoryRepository()
+	batch := domain.NewBatch("BATCH-2", "product-2")
+
+	if err := repo.SaveBatchAndEvents(batch, []*domain.BatchEvent{domain.NewBatchCreatedEvent(context.Background(), batch)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := repo.FindUnpublished(10)
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("expected 1 unpublished row, got %d (err: %v)", len(rows), err)
+	}
+
+	if err := repo.MarkPublished(rows[0].ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	remaining, err := repo.FindUnpublished(10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("expected no unpublished rows after MarkPublished, got %d", len(remaining))
+	}
+
+	count, err := repo.CountUnpublished()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected CountUnpublished to be 0, got %d", count)
+	}
+}
+
+func TestBatchMemoryRepository_MarkFailedRecordsAttemptAndKeepsRowUnpublished(t *testing.T) {
+	repo := NewBatchMemoryRepository()
+	batch := domain.NewBatch("BATCH-3", "product-3")
+
+	if err := repo.SaveBatchAndEvents(batch, []*domain.BatchEvent{domain.NewBatchCreatedEvent(context.Background(), batch)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, _ := repo.FindUnpublished(10)
+	if err := repo.MarkFailed(rows[0].ID, errors.New("boom")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	remaining, err := repo.FindUnpublished(10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(remaining) != 1 {
+		t.Fatalf("expected the row to still be unpublished after a failed attempt, got %d rows", len(remaining))
+	}
+	if remaining[0].Attempts != 1 {
+		t.Errorf("expected Attempts to be incremented to 1, got %d", remaining[0].Attempts)
+	}
+	if remaining[0].LastError == "" {
+		t.Error("expected LastError to be recorded")
+	}
+}
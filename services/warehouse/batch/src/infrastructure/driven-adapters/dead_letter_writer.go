@@ -0,0 +1,57 @@
+package drivenadapters
+
+import (
+	"context"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/messaging"
+	"github.com/segmentio/kafka-go"
+)
+
+// DeadLetterWriter publishes messages that a consumer could not process
+// (e.g. malformed JSON) to a dead-letter topic instead of dropping them,
+// so poison messages don't block the consumer group and can be inspected
+// or replayed later.
+type DeadLetterWriter struct {
+	writer *kafka.Writer
+}
+
+// NewDeadLetterWriter creates a DeadLetterWriter publishing to topic.
+func NewDeadLetterWriter(brokerAddress, topic string) *DeadLetterWriter {
+	return &DeadLetterWriter{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokerAddress),
+			Topic:        topic,
+			Balancer:     &kafka.LeastBytes{},
+			RequiredAcks: kafka.RequireOne,
+			WriteTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Send publishes the original message key/value to the dead-letter topic,
+// tagging it with the reason it was rejected.
+func (w *DeadLetterWriter) Send(ctx context.Context, original messaging.RawMessage, reason string) error {
+	headers := make([]kafka.Header, 0, len(original.Headers)+2)
+	for key, value := range original.Headers {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+	headers = append(headers,
+		kafka.Header{Key: "dlq_reason", Value: []byte(reason)},
+		kafka.Header{Key: "dlq_source_topic", Value: []byte(original.Topic)},
+	)
+
+	return w.writer.WriteMessages(ctx, kafka.Message{
+		Key:     original.Key,
+		Value:   original.Value,
+		Headers: headers,
+	})
+}
+
+// Close closes the underlying Kafka writer.
+func (w *DeadLetterWriter) Close() error {
+	if w.writer != nil {
+		return w.writer.Close()
+	}
+	return nil
+}
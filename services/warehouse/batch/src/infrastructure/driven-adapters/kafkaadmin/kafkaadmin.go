@@ -0,0 +1,228 @@
+// Package kafkaadmin ensures the topics a service depends on exist (and are
+// configured as expected) before it starts consuming/producing, so pods fail
+// fast with a clear error instead of spamming UnknownTopicOrPartition.
+package kafkaadmin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// TopicSpec describes the desired shape of a topic this service depends on.
+type TopicSpec struct {
+	Name              string
+	NumPartitions     int
+	ReplicationFactor int
+	RetentionMs       int64
+}
+
+// Bootstrapper describes and creates the topics a service depends on.
+type Bootstrapper struct {
+	client          *kafka.Client
+	reconcileTopics bool
+}
+
+// NewBootstrapper creates a Bootstrapper against the given broker address.
+// reconcileTopics controls whether Ensure will alter configs on existing
+// topics that have drifted from spec (--reconcile-topics).
+func NewBootstrapper(brokerAddress string, reconcileTopics bool) *Bootstrapper {
+	return &Bootstrapper{
+		client: &kafka.Client{
+			Addr:    kafka.TCP(brokerAddress),
+			Timeout: 10 * time.Second,
+		},
+		reconcileTopics: reconcileTopics,
+	}
+}
+
+// Ensure describes the cluster and each requested topic, creates any that
+// are missing, and logs config drift on existing ones. It returns an error
+// if the broker is unreachable or topic creation is denied, so callers can
+// fail fast on startup.
+func (b *Bootstrapper) Ensure(ctx context.Context, specs []TopicSpec) error {
+	metadata, err := b.client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return fmt.Errorf("kafkaadmin: failed to describe cluster: %w", err)
+	}
+	log.Printf("kafkaadmin: connected to cluster %s with %d broker(s)", metadata.Controller.Host, len(metadata.Brokers))
+
+	existing := make(map[string]kafka.Topic, len(metadata.Topics))
+	for _, topic := range metadata.Topics {
+		existing[topic.Name] = topic
+	}
+
+	var toCreate []kafka.TopicConfig
+	for _, spec := range specs {
+		topic, found := existing[spec.Name]
+		if !found || topic.Error != nil {
+			toCreate = append(toCreate, kafka.TopicConfig{
+				Topic:             spec.Name,
+				NumPartitions:     spec.NumPartitions,
+				ReplicationFactor: spec.ReplicationFactor,
+				ConfigEntries: []kafka.ConfigEntry{
+					{ConfigName: "retention.ms", ConfigValue: fmt.Sprintf("%d", spec.RetentionMs)},
+				},
+			})
+			continue
+		}
+
+		if len(topic.Partitions) != spec.NumPartitions {
+			log.Printf("kafkaadmin: config drift on topic %q: have %d partitions, want %d",
+				spec.Name, len(topic.Partitions), spec.NumPartitions)
+			if b.reconcileTopics {
+				if err := b.alterRetention(ctx, spec); err != nil {
+					log.Printf("kafkaadmin: failed to reconcile topic %q: %v", spec.Name, err)
+				}
+			}
+		}
+	}
+
+	if len(toCreate) == 0 {
+		return nil
+	}
+
+	resp, err := b.client.CreateTopics(ctx, &kafka.CreateTopicsRequest{
+		Topics: toCreate,
+	})
+	if err != nil {
+		return fmt.Errorf("kafkaadmin: failed to create topics: %w", err)
+	}
+
+	for name, topicErr := range resp.Errors {
+		if topicErr != nil {
+			return fmt.Errorf("kafkaadmin: failed to create topic %q: %w", name, topicErr)
+		}
+		log.Printf("kafkaadmin: created missing topic %q", name)
+	}
+
+	return nil
+}
+
+// alterRetention applies the spec's retention.ms to an existing topic that
+// has drifted, when reconciliation is enabled.
+func (b *Bootstrapper) alterRetention(ctx context.Context, spec TopicSpec) error {
+	_, err := b.client.AlterConfigs(ctx, &kafka.AlterConfigsRequest{
+		Resources: []kafka.AlterConfigRequestResource{
+			{
+				ResourceType: kafka.ResourceTypeTopic,
+				ResourceName: spec.Name,
+				Configs: []kafka.AlterConfigRequestConfig{
+					{Name: "retention.ms", Value: fmt.Sprintf("%d", spec.RetentionMs)},
+				},
+			},
+		},
+	})
+	return err
+}
+
+// TopicExists reports whether topic is present in the broker's current
+// metadata, the same "does it really not exist" question a publisher
+// reacting to an UnknownTopicOrPartition write error needs answered before
+// assuming it has to (re)create the topic, rather than just a leader
+// election in progress.
+func (b *Bootstrapper) TopicExists(ctx context.Context, topic string) (bool, error) {
+	metadata, err := b.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return false, fmt.Errorf("kafkaadmin: failed to describe topic %q: %w", topic, err)
+	}
+	for _, t := range metadata.Topics {
+		if t.Name == topic && t.Error == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// EnsureTopic is Ensure for a single topic spec, for a caller reacting to
+// one missing topic discovered at runtime rather than bootstrapping a
+// whole service's topic set at startup.
+func (b *Bootstrapper) EnsureTopic(ctx context.Context, spec TopicSpec) error {
+	return b.Ensure(ctx, []TopicSpec{spec})
+}
+
+// WaitForTopic polls the broker's metadata for topic every pollEvery until
+// it appears, ctx is cancelled, or timeout elapses. Kafka propagates topic
+// creation across the cluster asynchronously, so a caller that just issued
+// CreateTopics (e.g. via EnsureTopic) needs this before retrying a write
+// against the new topic.
+func (b *Bootstrapper) WaitForTopic(ctx context.Context, topic string, pollEvery, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollEvery)
+	defer ticker.Stop()
+
+	for {
+		if exists, err := b.TopicExists(ctx, topic); err == nil && exists {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("kafkaadmin: timed out after %s waiting for topic %q to appear", timeout, topic)
+		case <-ticker.C:
+		}
+	}
+}
+
+// TopicDescription is one topic's describe-topics output, as returned by
+// DescribeTopics: each partition's ID, leader broker, and replica/ISR set,
+// for operators diagnosing broker state without shelling into a pod.
+type TopicDescription struct {
+	Name       string                 `json:"name"`
+	Partitions []PartitionDescription `json:"partitions"`
+}
+
+// PartitionDescription describes a single partition of a TopicDescription.
+type PartitionDescription struct {
+	ID       int   `json:"id"`
+	Leader   int   `json:"leader"`
+	Replicas []int `json:"replicas"`
+	ISR      []int `json:"isr"`
+}
+
+// DescribeTopics returns partition/leader/ISR metadata for each of topics.
+// A topic absent from the broker (or reporting its own metadata error,
+// e.g. UnknownTopicOrPartition) is simply omitted from the result rather
+// than failing the whole call, so one missing topic doesn't hide the
+// others' state from an operator.
+func (b *Bootstrapper) DescribeTopics(ctx context.Context, topics []string) ([]TopicDescription, error) {
+	metadata, err := b.client.Metadata(ctx, &kafka.MetadataRequest{Topics: topics})
+	if err != nil {
+		return nil, fmt.Errorf("kafkaadmin: failed to describe topics: %w", err)
+	}
+
+	descriptions := make([]TopicDescription, 0, len(metadata.Topics))
+	for _, topic := range metadata.Topics {
+		if topic.Error != nil {
+			continue
+		}
+
+		partitions := make([]PartitionDescription, len(topic.Partitions))
+		for i, p := range topic.Partitions {
+			partitions[i] = PartitionDescription{
+				ID:       p.ID,
+				Leader:   p.Leader.ID,
+				Replicas: brokerIDs(p.Replicas),
+				ISR:      brokerIDs(p.Isr),
+			}
+		}
+		descriptions = append(descriptions, TopicDescription{Name: topic.Name, Partitions: partitions})
+	}
+	return descriptions, nil
+}
+
+// brokerIDs extracts each broker's ID, the only part of kafka.Broker
+// PartitionDescription's JSON shape needs.
+func brokerIDs(brokers []kafka.Broker) []int {
+	ids := make([]int, len(brokers))
+	for i, broker := range brokers {
+		ids[i] = broker.ID
+	}
+	return ids
+}
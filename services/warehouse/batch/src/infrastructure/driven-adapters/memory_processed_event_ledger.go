@@ -0,0 +1,119 @@
+package drivenadapters
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// defaultLedgerMaxEntries and defaultLedgerTTL bound MemoryProcessedEventLedger's
+// memory use: without them, a long-running consumer would grow its dedup map
+// forever. Eviction is FIFO by insertion order (not true LRU access order,
+// since SeenBefore never needs to "touch" an entry) plus a TTL sweep, which
+// is enough for catching the narrow redelivery window a broker at-least-once
+// guarantee opens, without keeping every event ID a service has ever seen.
+const (
+	defaultLedgerMaxEntries = 10000
+	defaultLedgerTTL        = 24 * time.Hour
+)
+
+// processedEventEntry records the outcome and time of a processed event,
+// kept for diagnostics, plus its position in the eviction order.
+type processedEventEntry struct {
+	Result      string
+	ProcessedAt time.Time
+	elem        *list.Element
+}
+
+// MemoryProcessedEventLedger is an in-memory implementation of
+// domain.ProcessedEventLedger, bounded to maxEntries with TTL-based eviction
+// so a single long-running consumer can't grow it without limit. A
+// persistent backend shared across replicas (e.g. a SQL-backed one,
+// mirroring BatchMemoryRepository's relationship to a future SQL-backed
+// BatchRepository) can be swapped in by implementing the same interface.
+type MemoryProcessedEventLedger struct {
+	mutex      sync.Mutex
+	entries    map[string]*processedEventEntry
+	order      *list.List // front = oldest insertion, back = newest
+	maxEntries int
+	ttl        time.Duration
+}
+
+// NewMemoryProcessedEventLedger creates a new MemoryProcessedEventLedger
+// bounded to defaultLedgerMaxEntries entries, each expiring after
+// defaultLedgerTTL.
+func NewMemoryProcessedEventLedger() *MemoryProcessedEventLedger {
+	return &MemoryProcessedEventLedger{
+		entries:    make(map[string]*processedEventEntry),
+		order:      list.New(),
+		maxEntries: defaultLedgerMaxEntries,
+		ttl:        defaultLedgerTTL,
+	}
+}
+
+// SeenBefore reports whether eventID has already been recorded and hasn't
+// since expired.
+func (l *MemoryProcessedEventLedger) SeenBefore(eventID string) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.evictExpiredLocked()
+
+	_, exists := l.entries[eventID]
+	return exists, nil
+}
+
+// MarkProcessed records eventID as processed, evicting the oldest entry if
+// this one would push the ledger past maxEntries.
+func (l *MemoryProcessedEventLedger) MarkProcessed(eventID, result string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.evictExpiredLocked()
+
+	if existing, ok := l.entries[eventID]; ok {
+		existing.Result = result
+		existing.ProcessedAt = time.Now()
+		return nil
+	}
+
+	elem := l.order.PushBack(eventID)
+	l.entries[eventID] = &processedEventEntry{Result: result, ProcessedAt: time.Now(), elem: elem}
+
+	for len(l.entries) > l.maxEntries {
+		l.evictOldestLocked()
+	}
+
+	return nil
+}
+
+// evictExpiredLocked removes every entry older than l.ttl, oldest insertion
+// first, stopping at the first entry still within ttl. Callers must hold
+// l.mutex.
+func (l *MemoryProcessedEventLedger) evictExpiredLocked() {
+	now := time.Now()
+	for {
+		front := l.order.Front()
+		if front == nil {
+			return
+		}
+		eventID := front.Value.(string)
+		entry, ok := l.entries[eventID]
+		if !ok || now.Sub(entry.ProcessedAt) <= l.ttl {
+			return
+		}
+		l.order.Remove(front)
+		delete(l.entries, eventID)
+	}
+}
+
+// evictOldestLocked removes the single oldest entry by insertion order.
+// Callers must hold l.mutex.
+func (l *MemoryProcessedEventLedger) evictOldestLocked() {
+	front := l.order.Front()
+	if front == nil {
+		return
+	}
+	l.order.Remove(front)
+	delete(l.entries, front.Value.(string))
+}
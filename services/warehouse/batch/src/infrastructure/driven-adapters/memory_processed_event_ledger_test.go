@@ -0,0 +1,71 @@
+package drivenadapters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryProcessedEventLedger_SeenBeforeReflectsMarkProcessed(t *testing.T) {
+	ledger := NewMemoryProcessedEventLedger()
+
+	seen, err := ledger.SeenBefore("evt-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen {
+		t.Fatal("expected evt-1 to be unseen before MarkProcessed")
+	}
+
+	if err := ledger.MarkProcessed("evt-1", "ok"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	seen, err = ledger.SeenBefore("evt-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !seen {
+		t.Error("expected evt-1 to be seen after MarkProcessed")
+	}
+
+	seen, err = ledger.SeenBefore("evt-2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen {
+		t.Error("expected a different event ID to remain unseen")
+	}
+}
+
+func TestMemoryProcessedEventLedger_EvictsOldestPastMaxEntries(t *testing.T) {
+	ledger := NewMemoryProcessedEventLedger()
+	ledger.maxEntries = 2
+
+	for _, eventID := range []string{"evt-1", "evt-2", "evt-3"} {
+		if err := ledger.MarkProcessed(eventID, "ok"); err != nil {
+			t.Fatalf("unexpected error marking %s: %v", eventID, err)
+		}
+	}
+
+	if seen, _ := ledger.SeenBefore("evt-1"); seen {
+		t.Error("expected evt-1 to be evicted once the ledger exceeded maxEntries")
+	}
+	if seen, _ := ledger.SeenBefore("evt-3"); !seen {
+		t.Error("expected the most recently marked event to remain")
+	}
+}
+
+func TestMemoryProcessedEventLedger_ExpiresEntriesPastTTL(t *testing.T) {
+	ledger := NewMemoryProcessedEventLedger()
+	ledger.ttl = time.Millisecond
+
+	if err := ledger.MarkProcessed("evt-1", "ok"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if seen, _ := ledger.SeenBefore("evt-1"); seen {
+		t.Error("expected evt-1 to have expired past ttl")
+	}
+}
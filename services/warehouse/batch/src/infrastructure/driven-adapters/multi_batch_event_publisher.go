@@ -0,0 +1,68 @@
+package drivenadapters
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+)
+
+// ClosableBatchEventPublisher is a domain.BatchEventPublisher that also owns
+// a connection worth closing on shutdown (BatchEventPublisherAdapter,
+// publisher/mqtt.Publisher and SinkBatchEventPublisher all satisfy this).
+// Exported so main.go can assemble a variable-length list of configured
+// destinations before handing them to NewMultiBatchEventPublisher.
+type ClosableBatchEventPublisher interface {
+	domain.BatchEventPublisher
+	Close() error
+}
+
+// MultiBatchEventPublisher fans a single BatchEvent out to every configured
+// destination (e.g. Kafka and MQTT), so operators can add or swap sinks via
+// config.PublishersConfig.Targets without the domain/application layers
+// knowing more than one exists. A publish is attempted against every
+// destination even if an earlier one fails, so one unhealthy sink doesn't
+// suppress delivery to the others; all failures are joined into a single
+// returned error.
+type MultiBatchEventPublisher struct {
+	publishers []ClosableBatchEventPublisher
+}
+
+// NewMultiBatchEventPublisher returns a MultiBatchEventPublisher that
+// publishes to every publisher given, in order.
+func NewMultiBatchEventPublisher(publishers ...ClosableBatchEventPublisher) *MultiBatchEventPublisher {
+	return &MultiBatchEventPublisher{publishers: publishers}
+}
+
+// PublishBatchEvent publishes event to every underlying publisher,
+// continuing past a failure, and returns a single error combining every
+// failure encountered (nil if all succeeded).
+func (m *MultiBatchEventPublisher) PublishBatchEvent(ctx context.Context, event *domain.BatchEvent) error {
+	var failures []string
+	for _, publisher := range m.publishers {
+		if err := publisher.PublishBatchEvent(ctx, event); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("multi-publisher: %d of %d destination(s) failed: %s", len(failures), len(m.publishers), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Close closes every underlying publisher, continuing past a failure, and
+// returns a single error combining every failure encountered (nil if all
+// succeeded).
+func (m *MultiBatchEventPublisher) Close() error {
+	var failures []string
+	for _, publisher := range m.publishers {
+		if err := publisher.Close(); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("multi-publisher: %d of %d destination(s) failed to close: %s", len(failures), len(m.publishers), strings.Join(failures, "; "))
+	}
+	return nil
+}
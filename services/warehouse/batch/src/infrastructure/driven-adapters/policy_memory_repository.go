@@ -0,0 +1,61 @@
+package drivenadapters
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+)
+
+// PolicyMemoryRepository implements domain.PolicyRepository using in-memory storage
+type PolicyMemoryRepository struct {
+	policies map[string]domain.BatchPolicy
+	mutex    sync.RWMutex
+}
+
+// NewPolicyMemoryRepository creates a new in-memory policy repository
+func NewPolicyMemoryRepository() *PolicyMemoryRepository {
+	return &PolicyMemoryRepository{
+		policies: make(map[string]domain.BatchPolicy),
+		mutex:    sync.RWMutex{},
+	}
+}
+
+// Save stores or updates the policy for policy.ProductID
+func (r *PolicyMemoryRepository) Save(policy domain.BatchPolicy) error {
+	if policy.ProductID == "" {
+		return fmt.Errorf("policy must have a product ID")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.policies[policy.ProductID] = policy
+	return nil
+}
+
+// FindByProductID retrieves the policy configured for productID
+func (r *PolicyMemoryRepository) FindByProductID(productID string) (domain.BatchPolicy, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	policy, exists := r.policies[productID]
+	if !exists {
+		return domain.BatchPolicy{}, fmt.Errorf("no policy configured for product %s", productID)
+	}
+
+	return policy, nil
+}
+
+// GetAll retrieves every configured policy
+func (r *PolicyMemoryRepository) GetAll() ([]domain.BatchPolicy, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := make([]domain.BatchPolicy, 0, len(r.policies))
+	for _, policy := range r.policies {
+		result = append(result, policy)
+	}
+
+	return result, nil
+}
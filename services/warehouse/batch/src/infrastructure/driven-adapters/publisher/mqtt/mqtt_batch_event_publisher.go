@@ -0,0 +1,231 @@
+// Package mqtt implements domain.BatchEventPublisher over an MQTT broker,
+// as an additional fan-out destination alongside
+// drivenadapters.BatchEventPublisherAdapter's Kafka writer. It carries the
+// same CloudEvents 1.0 structured-mode envelope (domain/cloudevents.Wrap)
+// the Kafka adapter uses in CloudEventsModeStructured, since
+// github.com/eclipse/paho.mqtt.golang only speaks MQTT 3.1.1 and has no
+// binary-mode property mechanism to carry CloudEvents attributes outside
+// the payload (see mqtt-order-event-client/publisher.MqttPublisher, which
+// documents the same constraint).
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain/cloudevents"
+)
+
+// eventSource identifies this service as the CloudEvents "source" attribute,
+// matching drivenadapters.eventSource.
+const eventSource = "warehouse-batch-service"
+
+// TLSConfig holds the MQTT publisher's TLS settings, mirroring
+// kafkaauth.Config's TLS fields.
+type TLSConfig struct {
+	Enable             bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// Config configures Publisher.
+type Config struct {
+	// Broker is the MQTT broker URL, e.g. "tcp://localhost:1883".
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+	// TopicTemplate derives the publish topic for each BatchEvent; see
+	// Config.topic for the placeholders it supports.
+	TopicTemplate string
+	QoS           byte
+	Retained      bool
+	TLS           TLSConfig
+}
+
+// ConfigFromEnv reads Config from the MQTT_BATCH_* env vars:
+//   - MQTT_BATCH_BROKER (default: tcp://localhost:1883)
+//   - MQTT_BATCH_CLIENT_ID (default: warehouse-batch-service)
+//   - MQTT_BATCH_USERNAME, MQTT_BATCH_PASSWORD (optional)
+//   - MQTT_BATCH_TOPIC_TEMPLATE (default: "batches/{product_id}/{event_type}")
+//   - MQTT_BATCH_QOS (0|1|2, default: 0)
+//   - MQTT_BATCH_RETAINED (true/false, default: false)
+//   - MQTT_BATCH_TLS_ENABLE (true/false, default: false)
+//   - MQTT_BATCH_TLS_CA_FILE, MQTT_BATCH_TLS_CERT_FILE, MQTT_BATCH_TLS_KEY_FILE
+//   - MQTT_BATCH_TLS_INSECURE_SKIP_VERIFY (true/false, default: false)
+func ConfigFromEnv() Config {
+	return Config{
+		Broker:        getEnv("MQTT_BATCH_BROKER", "tcp://localhost:1883"),
+		ClientID:      getEnv("MQTT_BATCH_CLIENT_ID", "warehouse-batch-service"),
+		Username:      getEnv("MQTT_BATCH_USERNAME", ""),
+		Password:      getEnv("MQTT_BATCH_PASSWORD", ""),
+		TopicTemplate: getEnv("MQTT_BATCH_TOPIC_TEMPLATE", "batches/{product_id}/{event_type}"),
+		QoS:           byte(getEnvInt("MQTT_BATCH_QOS", 0)),
+		Retained:      getEnvBool("MQTT_BATCH_RETAINED", false),
+		TLS: TLSConfig{
+			Enable:             getEnvBool("MQTT_BATCH_TLS_ENABLE", false),
+			CAFile:             getEnv("MQTT_BATCH_TLS_CA_FILE", ""),
+			CertFile:           getEnv("MQTT_BATCH_TLS_CERT_FILE", ""),
+			KeyFile:            getEnv("MQTT_BATCH_TLS_KEY_FILE", ""),
+			InsecureSkipVerify: getEnvBool("MQTT_BATCH_TLS_INSECURE_SKIP_VERIFY", false),
+		},
+	}
+}
+
+// topic derives the publish topic for event by substituting
+// "{product_id}", "{batch_id}", "{event_type}" and "{order_id}" in
+// TopicTemplate. order_id substitutes as the empty string for events with a
+// nil OrderID.
+func (c Config) topic(event *domain.BatchEvent) string {
+	var orderID string
+	if event.OrderID != nil {
+		orderID = *event.OrderID
+	}
+	replacer := strings.NewReplacer(
+		"{product_id}", event.ProductID,
+		"{batch_id}", event.BatchID,
+		"{event_type}", string(event.EventType),
+		"{order_id}", orderID,
+	)
+	return replacer.Replace(c.TopicTemplate)
+}
+
+// Publisher implements domain.BatchEventPublisher over an MQTT broker.
+type Publisher struct {
+	client paho.Client
+	config Config
+}
+
+// NewPublisher connects to config.Broker and returns a Publisher, or an
+// error if the connection fails.
+func NewPublisher(config Config) (*Publisher, error) {
+	opts := paho.NewClientOptions()
+	opts.AddBroker(config.Broker)
+	opts.SetClientID(config.ClientID)
+	opts.SetCleanSession(true)
+
+	if config.Username != "" {
+		opts.SetUsername(config.Username)
+	}
+	if config.Password != "" {
+		opts.SetPassword(config.Password)
+	}
+
+	if config.TLS.Enable {
+		tlsConfig, err := buildTLSConfig(config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	client := paho.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("mqtt: connect error: %w", token.Error())
+	}
+
+	return &Publisher{client: client, config: config}, nil
+}
+
+// PublishBatchEvent wraps event in a CloudEvents 1.0 structured-mode
+// envelope and publishes it to the topic derived from event (see
+// Config.topic), respecting ctx cancellation while waiting for the publish
+// to complete.
+func (p *Publisher) PublishBatchEvent(ctx context.Context, event *domain.BatchEvent) error {
+	envelope, err := cloudevents.Wrap(eventSource, string(event.EventType), event.BatchID, event, event.TraceParent)
+	if err != nil {
+		return fmt.Errorf("mqtt: failed to build CloudEvents envelope: %w", err)
+	}
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("mqtt: failed to marshal CloudEvents envelope: %w", err)
+	}
+
+	topic := p.config.topic(event)
+
+	done := make(chan error, 1)
+	go func() {
+		token := p.client.Publish(topic, p.config.QoS, p.config.Retained, payload)
+		token.Wait()
+		done <- token.Error()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
+
+// Close disconnects the MQTT client.
+func (p *Publisher) Close() error {
+	if p == nil || p.client == nil {
+		return nil
+	}
+	p.client.Disconnect(250)
+	return nil
+}
+
+func buildTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+
+	if cfg.CAFile != "" {
+		caCert, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("mqtt: failed to read TLS CA file %s: %w", cfg.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("mqtt: failed to parse TLS CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("mqtt: failed to load TLS client cert/key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
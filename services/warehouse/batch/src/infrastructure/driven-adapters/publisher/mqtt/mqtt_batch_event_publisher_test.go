@@ -0,0 +1,39 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+)
+
+func TestConfigTopic_SubstitutesAllPlaceholders(t *testing.T) {
+	orderID := "order-456"
+	event := &domain.BatchEvent{
+		EventType: domain.BatchEventItemAdded,
+		BatchID:   "batch-123",
+		ProductID: "prod-789",
+		OrderID:   &orderID,
+	}
+	cfg := Config{TopicTemplate: "batches/{product_id}/{event_type}/{batch_id}/{order_id}"}
+
+	got := cfg.topic(event)
+	want := "batches/prod-789/batch.item_added/batch-123/order-456"
+	if got != want {
+		t.Errorf("topic() = %q, want %q", got, want)
+	}
+}
+
+func TestConfigTopic_NilOrderIDSubstitutesEmptyString(t *testing.T) {
+	event := &domain.BatchEvent{
+		EventType: domain.BatchEventCreated,
+		BatchID:   "batch-123",
+		ProductID: "prod-789",
+	}
+	cfg := Config{TopicTemplate: "batches/{product_id}/{event_type}/{order_id}"}
+
+	got := cfg.topic(event)
+	want := "batches/prod-789/batch.created/"
+	if got != want {
+		t.Errorf("topic() = %q, want %q", got, want)
+	}
+}
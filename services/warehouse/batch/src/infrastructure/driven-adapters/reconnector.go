@@ -0,0 +1,209 @@
+package drivenadapters
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/retry"
+)
+
+// ConnectionState reports BatchEventPublisherAdapter's current view of its
+// Kafka connectivity, pushed onto reconnector.StateChanges() so the
+// application layer can react (e.g. pause accepting new batches while
+// ConnectionStateFailed).
+type ConnectionState string
+
+const (
+	ConnectionStateConnecting   ConnectionState = "connecting"
+	ConnectionStateConnected    ConnectionState = "connected"
+	ConnectionStateReconnecting ConnectionState = "reconnecting"
+	ConnectionStateFailed       ConnectionState = "failed"
+)
+
+// ReconnectConfig configures the reconnector's backoff schedule and how
+// many failed writes it will hold onto while reconnecting.
+type ReconnectConfig struct {
+	// Policy governs the exponential backoff between reconnect attempts -
+	// InitialDelay is the base delay, MaxDelay caps it, and Jitter
+	// randomizes each wait. A zero value defaults to 5 attempts starting at
+	// 500ms, doubling up to 30s, with 20% jitter.
+	Policy retry.Policy
+	// QueueSize bounds how many writes the circuit breaker spools while the
+	// connection is down or being rebuilt. A zero value defaults to 100.
+	QueueSize int
+}
+
+// reconnector owns BatchEventPublisherAdapter's recovery from a broken
+// Kafka connection. On a connection error it recreates the writer with
+// exponential backoff in a background goroutine, reporting ConnectionState
+// transitions on a channel the application layer can observe. Once the
+// backoff window is exhausted without success, it reports
+// ConnectionStateFailed and opens a circuit breaker: further failed writes
+// are spooled into a bounded queue (oldest dropped first once full) instead
+// of being retried inline, so a prolonged broker outage degrades to bounded
+// memory use and logged drops rather than an ever-growing backlog of
+// blocked publish calls. Messages that make it into the queue may still be
+// published again later by whatever retried the original PublishBatchEvent
+// call (e.g. the outbox relay); that's fine, since downstream consumers
+// already dedupe by domain.ProcessedEventLedger.
+type reconnector struct {
+	policy retry.Policy
+
+	mu    sync.Mutex
+	state ConnectionState
+
+	stateCh chan ConnectionState
+
+	reconnectingMu sync.Mutex
+	reconnecting   bool
+
+	queue     chan kafka.Message
+	queueSize int
+}
+
+func newReconnector(cfg ReconnectConfig) *reconnector {
+	policy := cfg.Policy
+	if policy.MaxAttempts == 0 && policy.InitialDelay == 0 {
+		policy = defaultReconnectPolicy
+	}
+
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+
+	return &reconnector{
+		policy:    policy,
+		state:     ConnectionStateConnecting,
+		stateCh:   make(chan ConnectionState, 16),
+		queue:     make(chan kafka.Message, queueSize),
+		queueSize: queueSize,
+	}
+}
+
+var defaultReconnectPolicy = retry.Policy{
+	MaxAttempts:  5,
+	InitialDelay: 500 * time.Millisecond,
+	MaxDelay:     30 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+// State returns the reconnector's current ConnectionState.
+func (r *reconnector) State() ConnectionState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// StateChanges returns a channel of ConnectionState transitions. It is
+// never closed and has a small buffer; a slow consumer misses intermediate
+// transitions, but State() always reflects the latest one.
+func (r *reconnector) StateChanges() <-chan ConnectionState {
+	return r.stateCh
+}
+
+func (r *reconnector) setState(state ConnectionState) {
+	r.mu.Lock()
+	r.state = state
+	r.mu.Unlock()
+
+	select {
+	case r.stateCh <- state:
+	default:
+		// No consumer keeping up: drop the notification rather than block
+		// the reconnect loop on it.
+	}
+}
+
+// markConnected reports a successful direct write, closing the circuit
+// breaker if it was open.
+func (r *reconnector) markConnected() {
+	if r.State() != ConnectionStateConnected {
+		r.setState(ConnectionStateConnected)
+	}
+}
+
+// circuitOpen reports whether writes should be spooled to the bounded
+// queue instead of attempted directly.
+func (r *reconnector) circuitOpen() bool {
+	return r.State() == ConnectionStateFailed
+}
+
+// beginReconnect claims the right to run the reconnect loop, returning
+// false if one is already in flight.
+func (r *reconnector) beginReconnect() bool {
+	r.reconnectingMu.Lock()
+	defer r.reconnectingMu.Unlock()
+	if r.reconnecting {
+		return false
+	}
+	r.reconnecting = true
+	return true
+}
+
+func (r *reconnector) endReconnect() {
+	r.reconnectingMu.Lock()
+	r.reconnecting = false
+	r.reconnectingMu.Unlock()
+}
+
+// reconnect retries rebuild with exponential backoff per r.policy until it
+// succeeds or ctx is cancelled, reporting ConnectionStateReconnecting while
+// it works and ConnectionStateConnected on success. If rebuild keeps
+// failing until the policy's attempts are exhausted, it reports
+// ConnectionStateFailed - opening the circuit breaker - and returns the
+// last error.
+func (r *reconnector) reconnect(ctx context.Context, rebuild func() error) error {
+	r.setState(ConnectionStateReconnecting)
+
+	if err := retry.Do(ctx, rebuild, r.policy); err != nil {
+		r.setState(ConnectionStateFailed)
+		return err
+	}
+
+	r.setState(ConnectionStateConnected)
+	return nil
+}
+
+// enqueue spools message onto the bounded retry queue, dropping (and
+// logging) the oldest queued message first if the queue is already full.
+func (r *reconnector) enqueue(message kafka.Message) {
+	select {
+	case r.queue <- message:
+		return
+	default:
+	}
+
+	select {
+	case dropped := <-r.queue:
+		log.Printf("Reconnect queue full (capacity %d), dropping oldest queued batch event for key %s", r.queueSize, string(dropped.Key))
+	default:
+	}
+
+	select {
+	case r.queue <- message:
+	default:
+		log.Printf("Failed to enqueue batch event for key %s after freeing space in the reconnect queue", string(message.Key))
+	}
+}
+
+// drainQueue flushes the queued backlog through write, stopping and
+// re-enqueuing on the first failure so the next reconnect attempt (or
+// drain) picks up where this one left off.
+func (r *reconnector) drainQueue(write func(kafka.Message) error) error {
+	for {
+		select {
+		case message := <-r.queue:
+			if err := write(message); err != nil {
+				r.enqueue(message)
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
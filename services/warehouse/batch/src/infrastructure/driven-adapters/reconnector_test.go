@@ -0,0 +1,112 @@
+package drivenadapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/retry"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil error", err: nil, expected: false},
+		{name: "unknown topic or partition", err: errors.New("[3] Unknown Topic Or Partition"), expected: true},
+		{name: "connection refused", err: errors.New("dial tcp: connection refused"), expected: true},
+		{name: "i/o timeout", err: errors.New("read tcp: i/o timeout"), expected: true},
+		{name: "broker not available", err: errors.New("broker not available"), expected: true},
+		{name: "unrelated error", err: errors.New("message too large"), expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := isConnectionError(tc.err); result != tc.expected {
+				t.Errorf("Expected %v, got %v for error: %v", tc.expected, result, tc.err)
+			}
+		})
+	}
+}
+
+func TestReconnectorBeginReconnectMutualExclusion(t *testing.T) {
+	r := newReconnector(ReconnectConfig{})
+
+	if !r.beginReconnect() {
+		t.Fatal("Expected first beginReconnect to succeed")
+	}
+	if r.beginReconnect() {
+		t.Fatal("Expected second beginReconnect to fail while one is in flight")
+	}
+
+	r.endReconnect()
+
+	if !r.beginReconnect() {
+		t.Fatal("Expected beginReconnect to succeed again after endReconnect")
+	}
+}
+
+func TestReconnectorEnqueueDropsOldestWhenFull(t *testing.T) {
+	r := newReconnector(ReconnectConfig{QueueSize: 2})
+
+	r.enqueue(kafka.Message{Key: []byte("first")})
+	r.enqueue(kafka.Message{Key: []byte("second")})
+	r.enqueue(kafka.Message{Key: []byte("third")})
+
+	first := <-r.queue
+	if string(first.Key) != "second" {
+		t.Errorf("Expected oldest message to have been dropped, got key %q first", first.Key)
+	}
+}
+
+func TestReconnectorCircuitOpenReflectsFailedState(t *testing.T) {
+	r := newReconnector(ReconnectConfig{})
+
+	if r.circuitOpen() {
+		t.Error("Expected circuit to be closed before any failure")
+	}
+
+	r.setState(ConnectionStateFailed)
+	if !r.circuitOpen() {
+		t.Error("Expected circuit to be open once state is Failed")
+	}
+}
+
+func TestReconnectorReconnectSucceeds(t *testing.T) {
+	r := newReconnector(ReconnectConfig{Policy: retry.Policy{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond}})
+
+	attempts := 0
+	err := r.reconnect(context.Background(), func() error {
+		attempts++
+		if attempts < 2 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Expected reconnect to succeed, got %v", err)
+	}
+	if r.State() != ConnectionStateConnected {
+		t.Errorf("Expected state Connected after successful reconnect, got %s", r.State())
+	}
+}
+
+func TestReconnectorReconnectExhaustsToFailed(t *testing.T) {
+	r := newReconnector(ReconnectConfig{Policy: retry.Policy{MaxAttempts: 2, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond}})
+
+	err := r.reconnect(context.Background(), func() error {
+		return errors.New("still broken")
+	})
+
+	if err == nil {
+		t.Fatal("Expected reconnect to return an error once attempts are exhausted")
+	}
+	if r.State() != ConnectionStateFailed {
+		t.Errorf("Expected state Failed after exhausted reconnect, got %s", r.State())
+	}
+}
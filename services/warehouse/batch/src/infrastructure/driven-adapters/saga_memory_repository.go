@@ -0,0 +1,77 @@
+package drivenadapters
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+)
+
+// SagaMemoryRepository implements domain.SagaRepository using in-memory storage
+type SagaMemoryRepository struct {
+	sagas map[string]*domain.Saga
+	mutex sync.RWMutex
+}
+
+// NewSagaMemoryRepository creates a new in-memory saga repository
+func NewSagaMemoryRepository() *SagaMemoryRepository {
+	return &SagaMemoryRepository{
+		sagas: make(map[string]*domain.Saga),
+		mutex: sync.RWMutex{},
+	}
+}
+
+// Save stores or updates a saga
+func (r *SagaMemoryRepository) Save(saga *domain.Saga) error {
+	if saga == nil {
+		return fmt.Errorf("saga cannot be nil")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Create a deep copy to avoid external modifications
+	sagaCopy := *saga
+	stepsCopy := make([]domain.SagaStepState, len(saga.Steps))
+	copy(stepsCopy, saga.Steps)
+	sagaCopy.Steps = stepsCopy
+
+	r.sagas[saga.ID] = &sagaCopy
+	return nil
+}
+
+// FindByID retrieves a saga by its ID
+func (r *SagaMemoryRepository) FindByID(id string) (*domain.Saga, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	saga, exists := r.sagas[id]
+	if !exists {
+		return nil, fmt.Errorf("saga with ID %s not found", id)
+	}
+
+	// Return a copy to avoid external modifications
+	sagaCopy := *saga
+	stepsCopy := make([]domain.SagaStepState, len(saga.Steps))
+	copy(stepsCopy, saga.Steps)
+	sagaCopy.Steps = stepsCopy
+
+	return &sagaCopy, nil
+}
+
+// FindAll retrieves every saga, for the /sagas inspection endpoint
+func (r *SagaMemoryRepository) FindAll() ([]*domain.Saga, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*domain.Saga
+	for _, saga := range r.sagas {
+		sagaCopy := *saga
+		stepsCopy := make([]domain.SagaStepState, len(saga.Steps))
+		copy(stepsCopy, saga.Steps)
+		sagaCopy.Steps = stepsCopy
+		result = append(result, &sagaCopy)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,69 @@
+package drivenadapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain/cloudevents"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/sink"
+)
+
+// SinkBatchEventPublisher adapts any sink.Sink into a
+// domain.BatchEventPublisher, for new destinations (an HTTP webhook, stdout)
+// that don't warrant a bespoke adapter the way Kafka
+// (BatchEventPublisherAdapter) and MQTT (publisher/mqtt.Publisher) have. It
+// wraps each event in the same CloudEvents 1.0 structured-mode envelope
+// publisher/mqtt.Publisher uses, so every sink emits the same wire format
+// regardless of transport.
+type SinkBatchEventPublisher struct {
+	sink          sink.Sink
+	topicTemplate string
+}
+
+// NewSinkBatchEventPublisher returns a SinkBatchEventPublisher publishing
+// through dest. topicTemplate derives the per-event topic the same way
+// publisher/mqtt.Config.TopicTemplate does, substituting "{product_id}",
+// "{batch_id}", "{event_type}" and "{order_id}".
+func NewSinkBatchEventPublisher(dest sink.Sink, topicTemplate string) *SinkBatchEventPublisher {
+	return &SinkBatchEventPublisher{sink: dest, topicTemplate: topicTemplate}
+}
+
+// PublishBatchEvent wraps event in a CloudEvents envelope and publishes it
+// to the topic derived from topicTemplate, keyed by event.BatchID.
+func (p *SinkBatchEventPublisher) PublishBatchEvent(ctx context.Context, event *domain.BatchEvent) error {
+	envelope, err := cloudevents.Wrap(eventSource, string(event.EventType), event.BatchID, event, event.TraceParent)
+	if err != nil {
+		return fmt.Errorf("sink: failed to build CloudEvents envelope: %w", err)
+	}
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("sink: failed to marshal CloudEvents envelope: %w", err)
+	}
+
+	return p.sink.Publish(ctx, p.topic(event), event.BatchID, payload)
+}
+
+// topic derives the publish topic for event, mirroring
+// publisher/mqtt.Config.topic.
+func (p *SinkBatchEventPublisher) topic(event *domain.BatchEvent) string {
+	var orderID string
+	if event.OrderID != nil {
+		orderID = *event.OrderID
+	}
+	replacer := strings.NewReplacer(
+		"{product_id}", event.ProductID,
+		"{batch_id}", event.BatchID,
+		"{event_type}", string(event.EventType),
+		"{order_id}", orderID,
+	)
+	return replacer.Replace(p.topicTemplate)
+}
+
+// Close releases the underlying sink.
+func (p *SinkBatchEventPublisher) Close() error {
+	return p.sink.Close()
+}
@@ -0,0 +1,53 @@
+package drivenadapters
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+)
+
+type fakeSink struct {
+	gotTopic, gotKey string
+	gotPayload       []byte
+}
+
+func (f *fakeSink) Publish(ctx context.Context, topic, key string, payload []byte) error {
+	f.gotTopic, f.gotKey, f.gotPayload = topic, key, payload
+	return nil
+}
+
+func (f *fakeSink) Close() error { return nil }
+
+func TestSinkBatchEventPublisher_PublishBatchEvent_WrapsEventAndDerivesTopic(t *testing.T) {
+	orderID := "order-456"
+	event := &domain.BatchEvent{
+		EventType: domain.BatchEventItemAdded,
+		BatchID:   "batch-123",
+		ProductID: "prod-789",
+		OrderID:   &orderID,
+	}
+	fake := &fakeSink{}
+	publisher := NewSinkBatchEventPublisher(fake, "batches/{product_id}/{event_type}/{order_id}")
+
+	if err := publisher.PublishBatchEvent(context.Background(), event); err != nil {
+		t.Fatalf("PublishBatchEvent() returned error: %v", err)
+	}
+
+	wantTopic := "batches/prod-789/batch.item_added/order-456"
+	if fake.gotTopic != wantTopic {
+		t.Errorf("topic = %q, want %q", fake.gotTopic, wantTopic)
+	}
+	if fake.gotKey != "batch-123" {
+		t.Errorf("key = %q, want %q", fake.gotKey, "batch-123")
+	}
+
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(fake.gotPayload, &envelope); err != nil {
+		t.Fatalf("payload is not valid JSON: %v", err)
+	}
+	if envelope["type"] == nil || envelope["type"] == "" {
+		t.Errorf("envelope type is empty, want a CloudEvents type derived from %v", domain.BatchEventItemAdded)
+	}
+}
@@ -1,206 +1,570 @@
-package drivingadapters
-
-import (
-	"context"
-	"log"
-	"net/http"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/application"
-	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
-)
-
-// ApiServiceAdapter is responsible for exposing the application's capabilities
-// over HTTP protocol through RESTful web service endpoints
-type ApiServiceAdapter struct {
-	server       *http.Server
-	router       *gin.Engine
-	port         string
-	batchService application.BatchServiceInterface
-}
-
-// NewApiServiceAdapter creates a new ApiServiceAdapter
-func NewApiServiceAdapter(port string, batchService application.BatchServiceInterface) *ApiServiceAdapter {
-	// Set gin to release mode for production
-	gin.SetMode(gin.ReleaseMode)
-	
-	router := gin.New()
-	
-	// Add middleware
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-	
-	adapter := &ApiServiceAdapter{
-		router:       router,
-		port:         port,
-		batchService: batchService,
-	}
-	
-	// Setup routes
-	adapter.setupRoutes()
-	
-	// Create HTTP server
-	adapter.server = &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
-	}
-	
-	return adapter
-}
-
-// setupRoutes configures all HTTP routes
-func (adapter *ApiServiceAdapter) setupRoutes() {
-	// Health check endpoint
-	adapter.router.GET("/health", adapter.healthHandler)
-	
-	// Batch endpoints
-	v1 := adapter.router.Group("/api/v1")
-	{
-		v1.GET("/batches", adapter.getAllBatchesHandler)
-		v1.GET("/batches/product/:productId", adapter.getBatchesByProductHandler)
-		v1.GET("/batches/status/:status", adapter.getBatchesByStatusHandler)
-		v1.GET("/batches/order/:orderId", adapter.getBatchByOrderHandler)
-	}
-}
-
-// healthHandler handles health check requests
-func (adapter *ApiServiceAdapter) healthHandler(c *gin.Context) {
-	response := gin.H{
-		"status":    "healthy",
-		"service":   "warehouse-batch",
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	}
-	
-	c.JSON(http.StatusOK, response)
-}
-
-// Start begins the HTTP server
-func (adapter *ApiServiceAdapter) Start(ctx context.Context) {
-	log.Printf("Starting HTTP API service adapter on port %s...", adapter.port)
-	
-	// Start server in a goroutine
-	go func() {
-		if err := adapter.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("HTTP server error: %v", err)
-		}
-	}()
-	
-	// Wait for context cancellation
-	<-ctx.Done()
-	log.Println("HTTP API service adapter stopping...")
-	
-	// Graceful shutdown
-	adapter.Stop()
-}
-
-// Stop gracefully shuts down the HTTP server
-func (adapter *ApiServiceAdapter) Stop() {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	if err := adapter.server.Shutdown(ctx); err != nil {
-		log.Printf("HTTP server shutdown error: %v", err)
-	} else {
-		log.Println("HTTP API service adapter stopped gracefully")
-	}
-}
-
-// getAllBatchesHandler handles GET /api/v1/batches
-func (adapter *ApiServiceAdapter) getAllBatchesHandler(c *gin.Context) {
-	batches, err := adapter.batchService.GetAllBatches()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve batches",
-			"details": err.Error(),
-		})
-		return
-	}
-	
-	batchDTOs := application.ToBatchDTOs(batches)
-	c.JSON(http.StatusOK, gin.H{
-		"batches": batchDTOs,
-		"count":   len(batchDTOs),
-	})
-}
-
-// getBatchesByProductHandler handles GET /api/v1/batches/product/:productId
-func (adapter *ApiServiceAdapter) getBatchesByProductHandler(c *gin.Context) {
-	productID := c.Param("productId")
-	if productID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Product ID is required",
-		})
-		return
-	}
-	
-	batches, err := adapter.batchService.GetBatchesByProductID(productID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve batches for product",
-			"details": err.Error(),
-		})
-		return
-	}
-	
-	batchDTOs := application.ToBatchDTOs(batches)
-	c.JSON(http.StatusOK, gin.H{
-		"product_id": productID,
-		"batches":    batchDTOs,
-		"count":      len(batchDTOs),
-	})
-}
-
-// getBatchesByStatusHandler handles GET /api/v1/batches/status/:status
-func (adapter *ApiServiceAdapter) getBatchesByStatusHandler(c *gin.Context) {
-	statusStr := c.Param("status")
-	if statusStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Status is required",
-		})
-		return
-	}
-	
-	status := domain.BatchStatus(statusStr)
-	batches, err := adapter.batchService.GetBatchesByStatus(status)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve batches by status",
-			"details": err.Error(),
-		})
-		return
-	}
-	
-	batchDTOs := application.ToBatchDTOs(batches)
-	c.JSON(http.StatusOK, gin.H{
-		"status":  status,
-		"batches": batchDTOs,
-		"count":   len(batchDTOs),
-	})
-}
-
-// getBatchByOrderHandler handles GET /api/v1/batches/order/:orderId
-func (adapter *ApiServiceAdapter) getBatchByOrderHandler(c *gin.Context) {
-	orderID := c.Param("orderId")
-	if orderID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Order ID is required",
-		})
-		return
-	}
-	
-	batch, err := adapter.batchService.GetBatchByOrderID(orderID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Batch not found for order",
-			"details": err.Error(),
-		})
-		return
-	}
-	
-	batchDTO := application.ToBatchDTO(batch)
-	c.JSON(http.StatusOK, gin.H{
-		"order_id": orderID,
-		"batch":    batchDTO,
-	})
-}
\ No newline at end of file
+package drivingadapters
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/application"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/application/saga"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/driven-adapters/kafkaadmin"
+	consumerkafka "github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/kafka/consumer"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// ApiServiceAdapter is responsible for exposing the application's capabilities
+// over HTTP protocol through RESTful web service endpoints
+type ApiServiceAdapter struct {
+	server       *http.Server
+	router       *gin.Engine
+	port         string
+	batchService application.BatchServiceInterface
+	sagaRepo     domain.SagaRepository
+	sagas        *saga.Orchestrator
+	policyRepo   domain.PolicyRepository
+	outboxRepo   domain.OutboxRepository
+	ready        <-chan struct{}
+	admin        *kafkaadmin.Bootstrapper
+	kafkaTopics  []string
+	// dedupSources feed the consumer_dedup_* gauges in metricsHandler; empty
+	// (not nil) when no consumer adapter was wired in, so the scrape just
+	// reports zeros rather than needing a nil check per adapter.
+	dedupSources []*OrderEventConsumerAdapter
+	dlqReplayer  *consumerkafka.DLQReplayer
+}
+
+// NewApiServiceAdapter creates a new ApiServiceAdapter. sagaRepo, sagas,
+// policyRepo, and outboxRepo may be nil, in which case their respective
+// endpoints return 404s instead of panicking. ready may also be nil, in
+// which case /readyz always reports ready immediately - used when no
+// startup catchup gate applies (e.g. order events are AMQP-backed rather
+// than Kafka-backed). admin may be nil, in which case the Kafka admin
+// endpoints return 404s; kafkaTopics lists the topics those endpoints
+// describe. dedupSources lists the order event consumer adapters whose
+// ledger hit/miss counters metricsHandler should report; it may be nil.
+// dlqReplayer may also be nil, in which case /admin/dlq/replay returns a 404
+// instead of panicking.
+func NewApiServiceAdapter(port string, batchService application.BatchServiceInterface, sagaRepo domain.SagaRepository, sagas *saga.Orchestrator, policyRepo domain.PolicyRepository, outboxRepo domain.OutboxRepository, ready <-chan struct{}, admin *kafkaadmin.Bootstrapper, kafkaTopics []string, dedupSources []*OrderEventConsumerAdapter, dlqReplayer *consumerkafka.DLQReplayer) *ApiServiceAdapter {
+	// Set gin to release mode for production
+	gin.SetMode(gin.ReleaseMode)
+
+	router := gin.New()
+
+	// Add middleware
+	router.Use(gin.Logger())
+	router.Use(gin.Recovery())
+	router.Use(requestIDMiddleware)
+
+	adapter := &ApiServiceAdapter{
+		router:       router,
+		port:         port,
+		batchService: batchService,
+		sagaRepo:     sagaRepo,
+		sagas:        sagas,
+		policyRepo:   policyRepo,
+		outboxRepo:   outboxRepo,
+		ready:        ready,
+		admin:        admin,
+		kafkaTopics:  kafkaTopics,
+		dedupSources: dedupSources,
+		dlqReplayer:  dlqReplayer,
+	}
+
+	// Setup routes
+	adapter.setupRoutes()
+
+	// Create HTTP server
+	adapter.server = &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+
+	return adapter
+}
+
+// requestIDMiddleware extracts an incoming "traceparent" (W3C Trace
+// Context) or "X-Request-ID" header and attaches it to the request's
+// context via pkg/tracing - the same mechanism BatchEventPublisherAdapter
+// and batch_aggregator.go already use to carry a traceparent onto published
+// BatchEvents - generating a fresh one when neither header is present. It
+// also echoes the id back as X-Request-ID so a caller that sent neither
+// header can still correlate its own logs with ours.
+func requestIDMiddleware(c *gin.Context) {
+	requestID := c.GetHeader("traceparent")
+	if requestID == "" {
+		requestID = c.GetHeader("X-Request-ID")
+	}
+	if requestID == "" {
+		requestID = tracing.NewTraceParent()
+	}
+
+	c.Request = c.Request.WithContext(tracing.ContextWithTraceParent(c.Request.Context(), requestID))
+	c.Header("X-Request-ID", requestID)
+	c.Next()
+}
+
+// setupRoutes configures all HTTP routes
+func (adapter *ApiServiceAdapter) setupRoutes() {
+	// Health check endpoint (unconditional liveness)
+	adapter.router.GET("/health", adapter.healthHandler)
+
+	// Readiness endpoint, gated on adapter.ready so Kubernetes doesn't send
+	// traffic until the order-events consumer group has caught up on the
+	// backlog it found waiting at startup
+	adapter.router.GET("/readyz", adapter.readyHandler)
+
+	// Prometheus-format metrics: the outbox lag gauge and the consumer dedup counters
+	adapter.router.GET("/metrics", adapter.metricsHandler)
+
+	// Batch endpoints
+	v1 := adapter.router.Group("/api/v1")
+	{
+		v1.GET("/batches", adapter.getAllBatchesHandler)
+		v1.GET("/batches/product/:productId", adapter.getBatchesByProductHandler)
+		v1.GET("/batches/status/:status", adapter.getBatchesByStatusHandler)
+		v1.GET("/batches/order/:orderId", adapter.getBatchByOrderHandler)
+
+		v1.GET("/sagas", adapter.getAllSagasHandler)
+		v1.GET("/sagas/:id", adapter.getSagaByIDHandler)
+		v1.POST("/sagas/:id/retry", adapter.retrySagaHandler)
+
+		v1.PUT("/policies/:productId", adapter.updatePolicyHandler)
+
+		v1.GET("/outbox/lag", adapter.getOutboxLagHandler)
+
+		v1.GET("/admin/kafka/topics", adapter.getKafkaTopicsHandler)
+		v1.POST("/admin/dlq/replay", adapter.replayDLQHandler)
+	}
+}
+
+// healthHandler handles health check requests
+func (adapter *ApiServiceAdapter) healthHandler(c *gin.Context) {
+	response := gin.H{
+		"status":    "healthy",
+		"service":   "warehouse-batch",
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// readyHandler handles readiness probe requests. With no ready channel
+// configured, it always reports ready; otherwise it reports 503 "starting"
+// until the channel is closed, then 200 "ready".
+func (adapter *ApiServiceAdapter) readyHandler(c *gin.Context) {
+	if adapter.ready != nil {
+		select {
+		case <-adapter.ready:
+		default:
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "starting",
+				"service": "warehouse-batch",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ready",
+		"service": "warehouse-batch",
+	})
+}
+
+// Start begins the HTTP server
+func (adapter *ApiServiceAdapter) Start(ctx context.Context) {
+	log.Printf("Starting HTTP API service adapter on port %s...", adapter.port)
+
+	// Start server in a goroutine
+	go func() {
+		if err := adapter.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("HTTP server error: %v", err)
+		}
+	}()
+
+	// Wait for context cancellation
+	<-ctx.Done()
+	log.Println("HTTP API service adapter stopping...")
+
+	// Graceful shutdown
+	adapter.Stop()
+}
+
+// Stop gracefully shuts down the HTTP server
+func (adapter *ApiServiceAdapter) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := adapter.server.Shutdown(ctx); err != nil {
+		log.Printf("HTTP server shutdown error: %v", err)
+	} else {
+		log.Println("HTTP API service adapter stopped gracefully")
+	}
+}
+
+// getAllBatchesHandler handles GET /api/v1/batches
+func (adapter *ApiServiceAdapter) getAllBatchesHandler(c *gin.Context) {
+	batches, err := adapter.batchService.GetAllBatches()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve batches",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	batchDTOs := application.ToBatchDTOs(batches)
+	c.JSON(http.StatusOK, gin.H{
+		"batches": batchDTOs,
+		"count":   len(batchDTOs),
+	})
+}
+
+// getBatchesByProductHandler handles GET /api/v1/batches/product/:productId
+func (adapter *ApiServiceAdapter) getBatchesByProductHandler(c *gin.Context) {
+	productID := c.Param("productId")
+	if productID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Product ID is required",
+		})
+		return
+	}
+
+	batches, err := adapter.batchService.GetBatchesByProductID(productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve batches for product",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	batchDTOs := application.ToBatchDTOs(batches)
+	c.JSON(http.StatusOK, gin.H{
+		"product_id": productID,
+		"batches":    batchDTOs,
+		"count":      len(batchDTOs),
+	})
+}
+
+// getBatchesByStatusHandler handles GET /api/v1/batches/status/:status
+func (adapter *ApiServiceAdapter) getBatchesByStatusHandler(c *gin.Context) {
+	statusStr := c.Param("status")
+	if statusStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Status is required",
+		})
+		return
+	}
+
+	status := domain.BatchStatus(statusStr)
+	batches, err := adapter.batchService.GetBatchesByStatus(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve batches by status",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	batchDTOs := application.ToBatchDTOs(batches)
+	c.JSON(http.StatusOK, gin.H{
+		"status":  status,
+		"batches": batchDTOs,
+		"count":   len(batchDTOs),
+	})
+}
+
+// getBatchByOrderHandler handles GET /api/v1/batches/order/:orderId
+func (adapter *ApiServiceAdapter) getBatchByOrderHandler(c *gin.Context) {
+	orderID := c.Param("orderId")
+	if orderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Order ID is required",
+		})
+		return
+	}
+
+	batch, err := adapter.batchService.GetBatchByOrderID(orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Batch not found for order",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	batchDTO := application.ToBatchDTO(batch)
+	c.JSON(http.StatusOK, gin.H{
+		"order_id": orderID,
+		"batch":    batchDTO,
+	})
+}
+
+// getAllSagasHandler handles GET /api/v1/sagas
+func (adapter *ApiServiceAdapter) getAllSagasHandler(c *gin.Context) {
+	if adapter.sagaRepo == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Saga inspection is not enabled",
+		})
+		return
+	}
+
+	sagas, err := adapter.sagaRepo.FindAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve sagas",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	sagaDTOs := application.ToSagaDTOs(sagas)
+	c.JSON(http.StatusOK, gin.H{
+		"sagas": sagaDTOs,
+		"count": len(sagaDTOs),
+	})
+}
+
+// getSagaByIDHandler handles GET /api/v1/sagas/:id
+func (adapter *ApiServiceAdapter) getSagaByIDHandler(c *gin.Context) {
+	if adapter.sagaRepo == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Saga inspection is not enabled",
+		})
+		return
+	}
+
+	id := c.Param("id")
+	sagaRecord, err := adapter.sagaRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Saga not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"saga": application.ToSagaDTO(sagaRecord),
+	})
+}
+
+// updatePolicyHandler handles PUT /api/v1/policies/:productId, letting a
+// product's batching policy be reconfigured live without a restart.
+func (adapter *ApiServiceAdapter) updatePolicyHandler(c *gin.Context) {
+	if adapter.policyRepo == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Policy configuration is not enabled",
+		})
+		return
+	}
+
+	productID := c.Param("productId")
+	if productID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Product ID is required",
+		})
+		return
+	}
+
+	var req struct {
+		MaxItems    int     `json:"max_items"`
+		MaxQuantity int     `json:"max_quantity"`
+		MaxAge      string  `json:"max_age"`
+		MaxWeightKg float64 `json:"max_weight_kg"`
+		CutoffCron  string  `json:"cutoff_cron"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid policy payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	policy := domain.BatchPolicy{
+		ProductID:   productID,
+		MaxItems:    req.MaxItems,
+		MaxQuantity: req.MaxQuantity,
+		MaxWeightKg: req.MaxWeightKg,
+		CutoffCron:  req.CutoffCron,
+	}
+	if req.MaxAge != "" {
+		maxAge, err := time.ParseDuration(req.MaxAge)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid max_age duration",
+				"details": err.Error(),
+			})
+			return
+		}
+		policy.MaxAge = maxAge
+	}
+
+	if err := adapter.policyRepo.Save(policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy": policy,
+	})
+}
+
+// getOutboxLagHandler handles GET /api/v1/outbox/lag, reporting how many
+// outbox rows are still waiting on the relay to publish them - a growing
+// value usually means the downstream broker is unreachable.
+func (adapter *ApiServiceAdapter) getOutboxLagHandler(c *gin.Context) {
+	if adapter.outboxRepo == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Outbox is not enabled",
+		})
+		return
+	}
+
+	lag, err := adapter.outboxRepo.CountUnpublished()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to compute outbox lag",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lag": lag,
+	})
+}
+
+// metricsHandler handles GET /metrics, exposing outbox_unpublished_events as
+// a Prometheus-format gauge - the same value as getOutboxLagHandler's JSON
+// "lag" field, for scraping instead of polling. Reports 0 when the outbox
+// isn't enabled, since a scrape target with no gauge at all is harder to
+// tell apart from a broken one than a flat zero.
+func (adapter *ApiServiceAdapter) metricsHandler(c *gin.Context) {
+	var lag int
+	if adapter.outboxRepo != nil {
+		var err error
+		lag, err = adapter.outboxRepo.CountUnpublished()
+		if err != nil {
+			log.Printf("metrics: failed to compute outbox lag: %v", err)
+		}
+	}
+
+	var dedupHits, dedupChecked int64
+	for _, src := range adapter.dedupSources {
+		dedupHits += src.DuplicatesSuppressed()
+		dedupChecked += src.DedupChecked()
+	}
+
+	c.String(http.StatusOK,
+		"# HELP outbox_unpublished_events Number of outbox rows not yet published to Kafka.\n"+
+			"# TYPE outbox_unpublished_events gauge\n"+
+			"outbox_unpublished_events %d\n"+
+			"# HELP consumer_dedup_hits_total Order events short-circuited as already-processed redeliveries.\n"+
+			"# TYPE consumer_dedup_hits_total counter\n"+
+			"consumer_dedup_hits_total %d\n"+
+			"# HELP consumer_dedup_checked_total Order events checked against the processed-event ledger.\n"+
+			"# TYPE consumer_dedup_checked_total counter\n"+
+			"consumer_dedup_checked_total %d\n",
+		lag, dedupHits, dedupChecked)
+}
+
+// getKafkaTopicsHandler handles GET /api/v1/admin/kafka/topics, returning
+// each configured topic's partition/leader/ISR state as kafkaadmin.DescribeTopics
+// sees it, so an operator can check broker state without shelling into a
+// pod.
+func (adapter *ApiServiceAdapter) getKafkaTopicsHandler(c *gin.Context) {
+	if adapter.admin == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Kafka admin introspection is not enabled",
+		})
+		return
+	}
+
+	topics, err := adapter.admin.DescribeTopics(c.Request.Context(), adapter.kafkaTopics)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to describe Kafka topics",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"topics": topics,
+	})
+}
+
+// replayDLQHandler handles POST /api/v1/admin/dlq/replay?count=N, reading up
+// to count messages (default 10) off the order-events DLQ topic and
+// re-injecting each back onto the topic named in its dlq_source_topic
+// header, so an operator can recover from a transient failure that dead-
+// lettered otherwise-valid order events without reaching for a separate
+// Kafka tool.
+func (adapter *ApiServiceAdapter) replayDLQHandler(c *gin.Context) {
+	if adapter.dlqReplayer == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "DLQ replay is not enabled",
+		})
+		return
+	}
+
+	count := 10
+	if raw := c.Query("count"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "count must be a positive integer",
+			})
+			return
+		}
+		count = parsed
+	}
+
+	replayed, err := adapter.dlqReplayer.Replay(c.Request.Context(), count)
+	if err != nil && replayed == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to replay DLQ messages",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"replayed": replayed,
+	})
+}
+
+// retrySagaHandler handles POST /api/v1/sagas/:id/retry, resuming a saga
+// that is stuck mid-run or mid-compensation (e.g. after a crash) from its
+// persisted current step.
+func (adapter *ApiServiceAdapter) retrySagaHandler(c *gin.Context) {
+	if adapter.sagas == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Saga inspection is not enabled",
+		})
+		return
+	}
+
+	id := c.Param("id")
+	if err := adapter.sagas.Retry(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retry saga",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":     id,
+		"status": "retried",
+	})
+}
@@ -0,0 +1,180 @@
+package drivingadapters
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ConsumerGroupOffsetChecker blocks a startup readiness gate until groupID's
+// consumer group has committed offsets at or beyond the high-water mark
+// each partition of topic had when the checker started. It is the Kafka-
+// native analogue of order_management's ReadinessChecker, which compares
+// RabbitMQ queue depth instead since AMQP has no partition/offset concept -
+// here the startup baseline is each partition's latest offset, and
+// "committed" is what OffsetFetch reports for the consumer group.
+type ConsumerGroupOffsetChecker struct {
+	client    *kafka.Client
+	topic     string
+	groupID   string
+	timeout   time.Duration
+	pollEvery time.Duration
+	readyCh   chan struct{}
+}
+
+// NewConsumerGroupOffsetChecker creates a ConsumerGroupOffsetChecker for
+// groupID's consumption of topic against the broker at brokerAddress.
+// timeout bounds how long WaitForCatchup blocks before giving up and
+// reporting ready anyway, so a pod isn't held out of rotation forever by a
+// backlog that keeps being replenished.
+func NewConsumerGroupOffsetChecker(brokerAddress, topic, groupID string, timeout time.Duration) *ConsumerGroupOffsetChecker {
+	return &ConsumerGroupOffsetChecker{
+		client: &kafka.Client{
+			Addr:    kafka.TCP(brokerAddress),
+			Timeout: 10 * time.Second,
+		},
+		topic:     topic,
+		groupID:   groupID,
+		timeout:   timeout,
+		pollEvery: 500 * time.Millisecond,
+		readyCh:   make(chan struct{}),
+	}
+}
+
+// Start launches WaitForCatchup in a background goroutine, closing Ready()
+// once it returns either way, so a handler selecting on it never blocks
+// forever.
+func (c *ConsumerGroupOffsetChecker) Start(ctx context.Context) {
+	go func() {
+		defer close(c.readyCh)
+		if err := c.WaitForCatchup(ctx); err != nil {
+			log.Printf("Consumer group offset checker: %v, reporting ready anyway", err)
+		}
+	}()
+}
+
+// WaitForCatchup snapshots every partition of topic's high-water mark (its
+// latest offset) via ListOffsets, then polls groupID's committed offsets via
+// OffsetFetch until every partition has caught up to its snapshotted mark,
+// ctx is cancelled, or timeout elapses.
+func (c *ConsumerGroupOffsetChecker) WaitForCatchup(ctx context.Context) error {
+	partitions, err := c.partitions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list partitions for topic %s: %w", c.topic, err)
+	}
+	if len(partitions) == 0 {
+		return nil
+	}
+
+	targets, err := c.highWaterMarks(ctx, partitions)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot high-water marks for topic %s: %w", c.topic, err)
+	}
+
+	log.Printf("Consumer group offset checker: waiting for group %s to catch up on %d partition(s) of topic %s", c.groupID, len(partitions), c.topic)
+
+	deadline := time.NewTimer(c.timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(c.pollEvery)
+	defer ticker.Stop()
+
+	for {
+		caughtUp, err := c.caughtUp(ctx, partitions, targets)
+		if err != nil {
+			log.Printf("Consumer group offset checker: failed to fetch committed offsets for group %s: %v", c.groupID, err)
+		} else if caughtUp {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("timed out after %s waiting for group %s to catch up on topic %s", c.timeout, c.groupID, c.topic)
+		case <-ticker.C:
+		}
+	}
+}
+
+// partitions returns every partition ID of c.topic.
+func (c *ConsumerGroupOffsetChecker) partitions(ctx context.Context) ([]int, error) {
+	metadata, err := c.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{c.topic}})
+	if err != nil {
+		return nil, err
+	}
+	for _, topic := range metadata.Topics {
+		if topic.Name != c.topic {
+			continue
+		}
+		partitions := make([]int, len(topic.Partitions))
+		for i, p := range topic.Partitions {
+			partitions[i] = p.ID
+		}
+		return partitions, nil
+	}
+	return nil, fmt.Errorf("topic %s not found", c.topic)
+}
+
+// highWaterMarks returns the latest offset of each of partitions for
+// c.topic, as observed at call time.
+func (c *ConsumerGroupOffsetChecker) highWaterMarks(ctx context.Context, partitions []int) (map[int]int64, error) {
+	requests := make([]kafka.OffsetRequest, len(partitions))
+	for i, p := range partitions {
+		requests[i] = kafka.OffsetRequest{Partition: p, Timestamp: kafka.LastOffset}
+	}
+
+	resp, err := c.client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Addr:   c.client.Addr,
+		Topics: map[string][]kafka.OffsetRequest{c.topic: requests},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	marks := make(map[int]int64, len(partitions))
+	for _, po := range resp.Topics[c.topic] {
+		if po.Error != nil {
+			return nil, po.Error
+		}
+		marks[po.Partition] = po.LastOffset
+	}
+	return marks, nil
+}
+
+// caughtUp reports whether groupID's committed offset on every one of
+// partitions is at or beyond its snapshotted target in targets. A
+// partition with no events at all (high-water mark 0) is always considered
+// caught up.
+func (c *ConsumerGroupOffsetChecker) caughtUp(ctx context.Context, partitions []int, targets map[int]int64) (bool, error) {
+	resp, err := c.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: c.groupID,
+		Topics:  map[string][]int{c.topic: partitions},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, po := range resp.Topics[c.topic] {
+		if po.Error != nil {
+			return false, po.Error
+		}
+		target, ok := targets[po.Partition]
+		if !ok || target == 0 {
+			continue
+		}
+		if po.CommittedOffset < target {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Ready returns a channel that's closed once WaitForCatchup has either
+// observed the group catch up or given up (timeout or ctx cancellation).
+// The /readyz handler selects on it to decide whether to return 200.
+func (c *ConsumerGroupOffsetChecker) Ready() <-chan struct{} {
+	return c.readyCh
+}
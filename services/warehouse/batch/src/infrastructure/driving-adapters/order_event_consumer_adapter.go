@@ -1,112 +1,336 @@
-package drivingadapters
-
-import (
-	"context"
-	"encoding/json"
-	"log"
-	"strings"
-	"time"
-
-	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
-	"github.com/segmentio/kafka-go"
-)
-
-// OrderEventConsumerAdapter is responsible for consuming order events from Kafka
-// and translating them into domain order events for the application layer
-type OrderEventConsumerAdapter struct {
-	reader            *kafka.Reader
-	orderEventHandler domain.OrderEventHandler
-}
-
-// NewOrderEventConsumerAdapter creates a new OrderEventConsumerAdapter
-func NewOrderEventConsumerAdapter(brokerAddress, topic, groupID string, orderEventHandler domain.OrderEventHandler) *OrderEventConsumerAdapter {
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:     []string{brokerAddress},
-		Topic:       topic,
-		GroupID:     groupID,
-		MinBytes:    10e3, // 10KB
-		MaxBytes:    10e6, // 10MB
-		StartOffset: kafka.LastOffset,
-		// Add retry configurations for Kubernetes
-		MaxAttempts: 3,
-		Dialer: &kafka.Dialer{
-			Timeout: 10 * time.Second,
-		},
-	})
-
-	return &OrderEventConsumerAdapter{
-		reader:            reader,
-		orderEventHandler: orderEventHandler,
-	}
-}
-
-// Start begins consuming order events from the message broker
-func (adapter *OrderEventConsumerAdapter) Start(ctx context.Context) {
-	config := adapter.reader.Config()
-	log.Printf("Starting order event consumer adapter with group ID: %s", config.GroupID)
-	log.Printf("Consuming from topic: %s, brokers: %v", config.Topic, config.Brokers)
-	log.Printf("Waiting for order events... (timeout errors are normal when no messages are available)")
-	
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("Order event consumer adapter stopping...")
-			adapter.Close()
-			return
-		default:
-			// Create a context with timeout for reading messages
-			readCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-			
-			// Fetch the next message from Kafka
-			msg, err := adapter.reader.ReadMessage(readCtx)
-			cancel()
-			
-			if err != nil {
-				// Only log non-timeout errors to reduce noise
-				if !strings.Contains(err.Error(), "context deadline exceeded") {
-					log.Printf("Error reading order event message: %v", err)
-				}
-				// Add backoff for connection errors
-				time.Sleep(2 * time.Second)
-				continue
-			}
-
-			// Translate Kafka message to domain order event
-			orderEvent, err := adapter.translateMessage(msg)
-			if err != nil {
-				log.Printf("Error translating order event message: %v", err)
-				continue
-			}
-			
-			// Handle the order event through the application layer
-			if err := adapter.orderEventHandler.HandleOrderEvent(orderEvent); err != nil {
-				log.Printf("Error handling order event: %v", err)
-			}
-		}
-	}
-}
-
-// translateMessage converts a Kafka message to a domain order event
-func (adapter *OrderEventConsumerAdapter) translateMessage(msg kafka.Message) (domain.OrderEvent, error) {
-	var orderEvent domain.OrderEvent
-	
-	// Parse the JSON message value
-	if err := json.Unmarshal(msg.Value, &orderEvent); err != nil {
-		log.Printf("Failed to unmarshal order event JSON: %v", err)
-		log.Printf("Message value: %s", string(msg.Value))
-		return orderEvent, err
-	}
-	
-	log.Printf("Successfully parsed order event: Type=%s, OrderID=%s", 
-		orderEvent.EventType, orderEvent.OrderID)
-	
-	return orderEvent, nil
-}
-
-// Close closes the Kafka reader
-func (adapter *OrderEventConsumerAdapter) Close() error {
-	if adapter.reader != nil {
-		return adapter.reader.Close()
-	}
-	return nil
-}
\ No newline at end of file
+package drivingadapters
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/messaging"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/retry"
+)
+
+// DeadLetterSink accepts messages the consumer could not process (e.g.
+// malformed JSON) so a poison message can be routed aside instead of
+// blocking the consumer group. It may be nil, in which case unprocessable
+// messages are simply logged and skipped.
+type DeadLetterSink interface {
+	Send(ctx context.Context, msg messaging.RawMessage, reason string) error
+}
+
+// CommitMode controls when a fetched message is acknowledged relative to
+// the domain handler call.
+type CommitMode string
+
+const (
+	// CommitAtLeastOnce acks the message only after the handler returns nil,
+	// so a crash mid-handling redelivers the message on the next rebalance.
+	CommitAtLeastOnce CommitMode = "at-least-once"
+	// CommitAtMostOnce acks the message before invoking the handler, so a
+	// crash mid-handling never redelivers the message.
+	CommitAtMostOnce CommitMode = "at-most-once"
+)
+
+// RebalanceListener mirrors the confluent-kafka-go / Sarama consumer-group
+// rebalance callbacks, letting callers react to partition assignment churn
+// (e.g. to flush in-flight batches before partitions move to another pod).
+// Sources with no partition concept (e.g. AMQP) never report assignments.
+type RebalanceListener interface {
+	OnPartitionsAssigned(partitions []int)
+	OnPartitionsRevoked(partitions []int)
+}
+
+// noopRebalanceListener is used when no listener is supplied.
+type noopRebalanceListener struct{}
+
+func (noopRebalanceListener) OnPartitionsAssigned(partitions []int) {}
+func (noopRebalanceListener) OnPartitionsRevoked(partitions []int)  {}
+
+// retryCountHeaderKey must match messaging's own retryCountHeader constant:
+// it's the header a source's Retry implementation (e.g. AMQPSource) sets to
+// record a message's delivery attempt count.
+const retryCountHeaderKey = "x-retry-count"
+
+// Handler processes one decoded domain.OrderEvent.
+type Handler func(event domain.OrderEvent) error
+
+// HandlerRegistry routes a decoded event to a Handler by its EventType,
+// falling back to the adapter's default handler for any type with no
+// specific registration. This lets one adapter instance - e.g. one backed
+// by RabbitMQ for damage events - dispatch a subset of event types to a
+// different application-layer handler than the Kafka-backed order-events
+// instance uses.
+type HandlerRegistry map[string]Handler
+
+// OrderEventConsumerAdapter consumes order events from a messaging.MessageSource,
+// decodes them via a messaging.Registry of codecs, and dispatches each one
+// to a domain.OrderEventHandler (or a HandlerRegistry override by event
+// type) for the application layer. Composing a MessageSource instead of
+// talking to Kafka directly lets the same adapter run against Kafka or
+// RabbitMQ, selected by how the source is constructed.
+type OrderEventConsumerAdapter struct {
+	source            messaging.MessageSource
+	codecs            *messaging.Registry
+	orderEventHandler domain.OrderEventHandler
+	handlerOverrides  HandlerRegistry
+	rebalanceListener RebalanceListener
+	commitMode        CommitMode
+	deadLetterSink    DeadLetterSink
+	// ledger, when non-nil, suppresses re-dispatching an order event that
+	// was already processed, so a Kafka/AMQP redelivery after a crash
+	// doesn't re-run the handler's side effects (e.g. AddOrderToBatch
+	// adding the same order twice).
+	ledger domain.ProcessedEventLedger
+	// duplicatesSuppressed counts events short-circuited via the ledger,
+	// logged as the consumer.duplicate_suppressed metric. dedupChecked
+	// counts every event that went through the ledger check at all (hits
+	// and misses both), so a dedup hit rate is duplicatesSuppressed /
+	// dedupChecked - see ApiServiceAdapter.metricsHandler.
+	duplicatesSuppressed int64
+	dedupChecked         int64
+
+	mu                 sync.Mutex
+	assignedPartitions map[int]struct{}
+}
+
+// NewOrderEventConsumerAdapter creates a new OrderEventConsumerAdapter over
+// the given source and codecs. handlerOverrides may be nil, in which case
+// every event type is dispatched to orderEventHandler. rebalanceListener,
+// deadLetterSink and ledger may also be nil; see their respective doc
+// comments.
+func NewOrderEventConsumerAdapter(source messaging.MessageSource, codecs *messaging.Registry, orderEventHandler domain.OrderEventHandler, handlerOverrides HandlerRegistry, commitMode CommitMode, rebalanceListener RebalanceListener, deadLetterSink DeadLetterSink, ledger domain.ProcessedEventLedger) *OrderEventConsumerAdapter {
+	if rebalanceListener == nil {
+		rebalanceListener = noopRebalanceListener{}
+	}
+	if commitMode == "" {
+		commitMode = CommitAtLeastOnce
+	}
+	if handlerOverrides == nil {
+		handlerOverrides = HandlerRegistry{}
+	}
+
+	return &OrderEventConsumerAdapter{
+		source:             source,
+		codecs:             codecs,
+		orderEventHandler:  orderEventHandler,
+		handlerOverrides:   handlerOverrides,
+		rebalanceListener:  rebalanceListener,
+		commitMode:         commitMode,
+		deadLetterSink:     deadLetterSink,
+		ledger:             ledger,
+		assignedPartitions: make(map[int]struct{}),
+	}
+}
+
+// translateRetryPolicy governs the single attempt at decoding a fetched
+// message. Decode failures are terminal: the message is malformed and
+// retrying it won't help, so it is routed to the dead-letter sink instead.
+var translateRetryPolicy = retry.Policy{
+	MaxAttempts: 1,
+	Classify:    func(error) retry.Classification { return retry.Terminal },
+}
+
+// Start begins consuming order events from the configured source
+func (adapter *OrderEventConsumerAdapter) Start(ctx context.Context) {
+	log.Printf("Starting order event consumer adapter (commit mode: %s)", adapter.commitMode)
+
+	messages, err := adapter.source.Consume(ctx)
+	if err != nil {
+		log.Printf("Failed to start consuming order events: %v", err)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Order event consumer adapter stopping, draining in-flight messages...")
+			adapter.revokeAllPartitions()
+			adapter.Close()
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				log.Println("Order event message channel closed")
+				return
+			}
+
+			if msg.Partition >= 0 {
+				adapter.notifyAssignment(msg.Partition)
+			}
+
+			if adapter.commitMode == CommitAtMostOnce {
+				if err := adapter.source.Ack(msg); err != nil {
+					log.Printf("Error acking order event message (at-most-once): %v", err)
+				}
+			}
+
+			// Decode the message. Unmarshal failures are terminal, so route
+			// the message to the dead-letter sink instead of leaving it
+			// stuck at this offset.
+			var orderEvent domain.OrderEvent
+			translateErr := retry.Do(ctx, func() error {
+				return adapter.translateMessage(msg, &orderEvent)
+			}, translateRetryPolicy)
+			if translateErr != nil {
+				log.Printf("Error decoding order event message: %v", translateErr)
+				adapter.sendToDeadLetter(ctx, msg, translateErr)
+				continue
+			}
+
+			// Short-circuit redelivered events: a crash between handling
+			// and committing can cause the broker to redeliver a message
+			// whose side effects already landed.
+			if adapter.ledger != nil {
+				ledgerKey := orderEvent.LedgerKey()
+				atomic.AddInt64(&adapter.dedupChecked, 1)
+				if seen, err := adapter.ledger.SeenBefore(ledgerKey); err != nil {
+					log.Printf("Error checking processed-event ledger for %s: %v", ledgerKey, err)
+				} else if seen {
+					atomic.AddInt64(&adapter.duplicatesSuppressed, 1)
+					log.Printf("consumer.duplicate_suppressed: event %s already processed, acking without redispatch", ledgerKey)
+					if adapter.commitMode == CommitAtLeastOnce {
+						if err := adapter.source.Ack(msg); err != nil {
+							log.Printf("Error acking duplicate order event message: %v", err)
+						}
+					}
+					continue
+				}
+			}
+
+			handlerErr := adapter.resolveHandler(orderEvent.EventType)(orderEvent)
+			if handlerErr != nil {
+				log.Printf("Error handling order event: %v", handlerErr)
+			} else if adapter.ledger != nil {
+				if err := adapter.ledger.MarkProcessed(orderEvent.LedgerKey(), "ok"); err != nil {
+					log.Printf("Error recording processed event %s: %v", orderEvent.LedgerKey(), err)
+				}
+			}
+
+			if adapter.commitMode == CommitAtLeastOnce {
+				if handlerErr == nil {
+					if err := adapter.source.Ack(msg); err != nil {
+						log.Printf("Error acking order event message (at-least-once): %v", err)
+					}
+				} else {
+					attempt := retryCount(msg.Headers) + 1
+					if err := adapter.source.Retry(msg, attempt); err != nil {
+						log.Printf("Error routing order event message for retry (attempt %d): %v", attempt, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+// retryCount reads a message's x-retry-count header (set by a source's
+// Retry implementation, e.g. AMQPSource), or 0 if it's absent or malformed.
+func retryCount(headers map[string]string) int {
+	n, err := strconv.Atoi(headers[retryCountHeaderKey])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// resolveHandler returns the HandlerRegistry override for eventType, or the
+// adapter's default handler if none is registered.
+func (adapter *OrderEventConsumerAdapter) resolveHandler(eventType string) Handler {
+	if handler, ok := adapter.handlerOverrides[eventType]; ok {
+		return handler
+	}
+	return adapter.orderEventHandler.HandleOrderEvent
+}
+
+// sendToDeadLetter routes a message that failed decoding to the
+// dead-letter sink, if one is configured. Failures to do so are logged but
+// otherwise swallowed: the consumer still advances rather than getting
+// stuck retrying a message that will never parse.
+func (adapter *OrderEventConsumerAdapter) sendToDeadLetter(ctx context.Context, msg messaging.RawMessage, reason error) {
+	if adapter.deadLetterSink == nil {
+		log.Printf("No dead-letter sink configured, dropping unprocessable order event message (topic %s, partition %d, offset %d)", msg.Topic, msg.Partition, msg.Offset)
+		return
+	}
+
+	dlqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := adapter.deadLetterSink.Send(dlqCtx, msg, reason.Error()); err != nil {
+		log.Printf("Failed to send message to dead-letter sink: %v", err)
+	}
+}
+
+// notifyAssignment reports a partition the source just fetched from as
+// assigned the first time it is observed. Sources built on kafka-go don't
+// surface the raw rebalance protocol callbacks, so partition assignment is
+// inferred from the fetched messages themselves.
+func (adapter *OrderEventConsumerAdapter) notifyAssignment(partition int) {
+	adapter.mu.Lock()
+	_, known := adapter.assignedPartitions[partition]
+	if !known {
+		adapter.assignedPartitions[partition] = struct{}{}
+	}
+	adapter.mu.Unlock()
+
+	if !known {
+		adapter.rebalanceListener.OnPartitionsAssigned([]int{partition})
+	}
+}
+
+// revokeAllPartitions notifies the listener that every currently known
+// partition is being given up, e.g. on graceful shutdown ahead of a rolling
+// restart.
+func (adapter *OrderEventConsumerAdapter) revokeAllPartitions() {
+	adapter.mu.Lock()
+	partitions := make([]int, 0, len(adapter.assignedPartitions))
+	for p := range adapter.assignedPartitions {
+		partitions = append(partitions, p)
+	}
+	adapter.assignedPartitions = make(map[int]struct{})
+	adapter.mu.Unlock()
+
+	if len(partitions) > 0 {
+		adapter.rebalanceListener.OnPartitionsRevoked(partitions)
+	}
+}
+
+// translateMessage decodes a RawMessage into a domain order event via the
+// adapter's codec registry, dispatched on the message's content type. This
+// keeps the same JSON/CloudEvents-or-legacy fallback behavior regardless of
+// which broker the message came from.
+func (adapter *OrderEventConsumerAdapter) translateMessage(msg messaging.RawMessage, out *domain.OrderEvent) error {
+	if err := adapter.codecs.Decode(msg, out); err != nil {
+		log.Printf("Failed to decode order event message: %v", err)
+		log.Printf("Message value: %s", string(msg.Value))
+		return err
+	}
+
+	if traceParent, ok := msg.Headers["traceparent"]; ok {
+		out.TraceParent = traceParent
+	}
+
+	log.Printf("Successfully parsed order event: Type=%s, OrderID=%s", out.EventType, out.OrderID)
+	return nil
+}
+
+// DuplicatesSuppressed returns the running count of redelivered events
+// short-circuited via the ledger, i.e. the consumer.duplicate_suppressed
+// metric.
+func (adapter *OrderEventConsumerAdapter) DuplicatesSuppressed() int64 {
+	return atomic.LoadInt64(&adapter.duplicatesSuppressed)
+}
+
+// DedupChecked returns the running count of events checked against the
+// ledger (hits and misses both), i.e. the denominator of the dedup hit rate.
+func (adapter *OrderEventConsumerAdapter) DedupChecked() int64 {
+	return atomic.LoadInt64(&adapter.dedupChecked)
+}
+
+// Close closes the underlying message source
+func (adapter *OrderEventConsumerAdapter) Close() error {
+	if adapter.source != nil {
+		return adapter.source.Close()
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package drivingadapters
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/messaging"
+)
+
+var errInvalidJSON = errors.New("invalid character 'n' looking for beginning of value")
+
+// recordingDeadLetterSink captures messages sent to it for assertions.
+type recordingDeadLetterSink struct {
+	mu      sync.Mutex
+	sent    []messaging.RawMessage
+	reasons []string
+}
+
+func (s *recordingDeadLetterSink) Send(ctx context.Context, msg messaging.RawMessage, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	s.reasons = append(s.reasons, reason)
+	return nil
+}
+
+// recordingRebalanceListener captures assigned/revoked partitions for assertions.
+type recordingRebalanceListener struct {
+	mu       sync.Mutex
+	assigned [][]int
+	revoked  [][]int
+}
+
+func (r *recordingRebalanceListener) OnPartitionsAssigned(partitions []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.assigned = append(r.assigned, partitions)
+}
+
+func (r *recordingRebalanceListener) OnPartitionsRevoked(partitions []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revoked = append(r.revoked, partitions)
+}
+
+func TestOrderEventConsumerAdapter_NotifyAssignmentOnlyOncePerPartition(t *testing.T) {
+	listener := &recordingRebalanceListener{}
+	adapter := &OrderEventConsumerAdapter{
+		rebalanceListener:  listener,
+		assignedPartitions: make(map[int]struct{}),
+	}
+
+	adapter.notifyAssignment(0)
+	adapter.notifyAssignment(0)
+	adapter.notifyAssignment(1)
+
+	if len(listener.assigned) != 2 {
+		t.Fatalf("expected 2 assignment notifications, got %d: %v", len(listener.assigned), listener.assigned)
+	}
+	if listener.assigned[0][0] != 0 || listener.assigned[1][0] != 1 {
+		t.Errorf("unexpected assignment contents: %v", listener.assigned)
+	}
+}
+
+func TestOrderEventConsumerAdapter_RevokeAllPartitions(t *testing.T) {
+	listener := &recordingRebalanceListener{}
+	adapter := &OrderEventConsumerAdapter{
+		rebalanceListener:  listener,
+		assignedPartitions: make(map[int]struct{}),
+	}
+
+	adapter.notifyAssignment(0)
+	adapter.notifyAssignment(2)
+	adapter.revokeAllPartitions()
+
+	if len(listener.revoked) != 1 || len(listener.revoked[0]) != 2 {
+		t.Fatalf("expected a single revocation with 2 partitions, got %v", listener.revoked)
+	}
+	if len(adapter.assignedPartitions) != 0 {
+		t.Errorf("expected assignedPartitions to be cleared after revoke, got %v", adapter.assignedPartitions)
+	}
+
+	// Revoking again with nothing assigned should be a no-op.
+	adapter.revokeAllPartitions()
+	if len(listener.revoked) != 1 {
+		t.Errorf("expected no additional revocation notifications, got %d", len(listener.revoked))
+	}
+}
+
+func TestOrderEventConsumerAdapter_SendToDeadLetterRoutesUnprocessableMessage(t *testing.T) {
+	sink := &recordingDeadLetterSink{}
+	adapter := &OrderEventConsumerAdapter{deadLetterSink: sink}
+
+	msg := messaging.RawMessage{Partition: 1, Offset: 42, Value: []byte("not json")}
+	adapter.sendToDeadLetter(context.Background(), msg, errInvalidJSON)
+
+	if len(sink.sent) != 1 {
+		t.Fatalf("expected 1 message sent to dead-letter sink, got %d", len(sink.sent))
+	}
+	if sink.reasons[0] != errInvalidJSON.Error() {
+		t.Errorf("expected reason %q, got %q", errInvalidJSON.Error(), sink.reasons[0])
+	}
+}
+
+func TestOrderEventConsumerAdapter_SendToDeadLetterWithoutSinkIsNoop(t *testing.T) {
+	adapter := &OrderEventConsumerAdapter{}
+
+	// Should not panic when no dead-letter sink is configured.
+	adapter.sendToDeadLetter(context.Background(), messaging.RawMessage{}, errInvalidJSON)
+}
+
+func TestOrderEventConsumerAdapter_ResolveHandlerUsesOverrideByEventType(t *testing.T) {
+	var defaultCalled, overrideCalled bool
+	adapter := &OrderEventConsumerAdapter{
+		orderEventHandler: handlerFunc(func(event domain.OrderEvent) error {
+			defaultCalled = true
+			return nil
+		}),
+		handlerOverrides: HandlerRegistry{
+			"order.damage_processed": func(event domain.OrderEvent) error {
+				overrideCalled = true
+				return nil
+			},
+		},
+	}
+
+	if err := adapter.resolveHandler("order.damage_processed")(domain.OrderEvent{EventType: "order.damage_processed"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !overrideCalled || defaultCalled {
+		t.Errorf("expected the override handler to be used, overrideCalled=%v defaultCalled=%v", overrideCalled, defaultCalled)
+	}
+
+	if err := adapter.resolveHandler("order.created")(domain.OrderEvent{EventType: "order.created"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !defaultCalled {
+		t.Error("expected the default handler to be used for an event type with no override")
+	}
+}
+
+type handlerFunc func(event domain.OrderEvent) error
+
+func (f handlerFunc) HandleOrderEvent(event domain.OrderEvent) error { return f(event) }
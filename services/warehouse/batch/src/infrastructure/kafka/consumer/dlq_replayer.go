@@ -0,0 +1,145 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaReader is the subset of *kafka.Reader DLQReplayer needs, narrowed so
+// tests can fake it without a live broker, mirroring kafkaWriter in
+// escalator.go.
+type kafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// DLQReplayer re-injects messages parked on a DLQ topic back onto the topic
+// they originally failed on, read from the "dlq_source_topic" header
+// DeadLetterWriter and Escalator both set. It consumes the DLQ topic as its
+// own consumer group, so replaying advances past replayed messages and a
+// later call picks up where the previous one left off instead of re-replaying
+// the same backlog.
+type DLQReplayer struct {
+	brokerAddress string
+	topic         string
+	fallbackTopic string
+
+	newReader func(brokerAddress, topic string) kafkaReader
+	newWriter func(brokerAddress, topic string) kafkaWriter
+
+	// mu guards reader/writers: replayDLQHandler invokes Replay directly from
+	// a Gin handler, so two concurrent /admin/dlq/replay requests must not
+	// race on the lazily-initialized reader or the writers map, exactly like
+	// Escalator's retryWriters/dlqWriter in escalator.go.
+	mu      sync.Mutex
+	reader  kafkaReader
+	writers map[string]kafkaWriter
+}
+
+// NewDLQReplayer creates a DLQReplayer consuming topic. fallbackTopic is
+// where a DLQ message missing a "dlq_source_topic" header is replayed to.
+func NewDLQReplayer(brokerAddress, topic, fallbackTopic string) *DLQReplayer {
+	return &DLQReplayer{
+		brokerAddress: brokerAddress,
+		topic:         topic,
+		fallbackTopic: fallbackTopic,
+		newReader:     newKafkaReader,
+		newWriter:     newKafkaWriter,
+		writers:       make(map[string]kafkaWriter),
+	}
+}
+
+func newKafkaReader(brokerAddress, topic string) kafkaReader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers: []string{brokerAddress},
+		Topic:   topic,
+		GroupID: "dlq-replayer",
+		Dialer: &kafka.Dialer{
+			Timeout: 10 * time.Second,
+		},
+	})
+}
+
+// Replay fetches up to maxMessages from the DLQ topic, without blocking past
+// whatever is immediately available, and republishes each to the topic named
+// in its "dlq_source_topic" header (or fallbackTopic if that header is
+// absent), committing its DLQ offset only once the republish succeeds. It
+// returns the number of messages replayed.
+func (r *DLQReplayer) Replay(ctx context.Context, maxMessages int) (int, error) {
+	reader := r.readerHandle()
+
+	replayed := 0
+	for replayed < maxMessages {
+		fetchCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		msg, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		if err != nil {
+			if replayed > 0 || ctx.Err() != nil {
+				break
+			}
+			return replayed, fmt.Errorf("consumer: failed to fetch DLQ message: %w", err)
+		}
+
+		destination := r.fallbackTopic
+		for _, h := range msg.Headers {
+			if h.Key == "dlq_source_topic" && len(h.Value) > 0 {
+				destination = string(h.Value)
+			}
+		}
+
+		if err := r.writerFor(destination).WriteMessages(ctx, kafka.Message{Key: msg.Key, Value: msg.Value, Headers: msg.Headers}); err != nil {
+			return replayed, fmt.Errorf("consumer: failed to replay DLQ message to %s: %w", destination, err)
+		}
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			return replayed, fmt.Errorf("consumer: failed to commit replayed DLQ message: %w", err)
+		}
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+func (r *DLQReplayer) readerHandle() kafkaReader {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reader == nil {
+		r.reader = r.newReader(r.brokerAddress, r.topic)
+	}
+	return r.reader
+}
+
+func (r *DLQReplayer) writerFor(topic string) kafkaWriter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if w, ok := r.writers[topic]; ok {
+		return w
+	}
+	w := r.newWriter(r.brokerAddress, topic)
+	r.writers[topic] = w
+	return w
+}
+
+// Close closes the DLQ reader and every destination writer this replayer has
+// opened.
+func (r *DLQReplayer) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var firstErr error
+	if r.reader != nil {
+		if err := r.reader.Close(); err != nil {
+			firstErr = err
+		}
+	}
+	for _, w := range r.writers {
+		if err := w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,151 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type fakeReader struct {
+	messages []kafka.Message
+	pos      int
+	commits  []kafka.Message
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if r.pos >= len(r.messages) {
+		return kafka.Message{}, io.EOF
+	}
+	msg := r.messages[r.pos]
+	r.pos++
+	return msg, nil
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.commits = append(r.commits, msgs...)
+	return nil
+}
+
+func (r *fakeReader) Close() error { return nil }
+
+func newTestDLQReplayer(messages []kafka.Message) (*DLQReplayer, *fakeReader, map[string]*fakeWriter) {
+	reader := &fakeReader{messages: messages}
+	written := make(map[string]*fakeWriter)
+	r := NewDLQReplayer("unused:9092", "order-events.dlq", "order-events")
+	r.newReader = func(brokerAddress, topic string) kafkaReader { return reader }
+	r.newWriter = func(brokerAddress, topic string) kafkaWriter {
+		w := &fakeWriter{topic: topic}
+		written[topic] = w
+		return w
+	}
+	return r, reader, written
+}
+
+func TestDLQReplayer_Replay_RoutesToSourceTopicHeader(t *testing.T) {
+	messages := []kafka.Message{
+		{Key: []byte("k1"), Value: []byte("v1"), Headers: []kafka.Header{{Key: "dlq_source_topic", Value: []byte("order-events")}}},
+		{Key: []byte("k2"), Value: []byte("v2"), Headers: []kafka.Header{{Key: "dlq_source_topic", Value: []byte("batch-events")}}},
+	}
+	r, reader, written := newTestDLQReplayer(messages)
+
+	n, err := r.Replay(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Replay() returned error: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 messages replayed, got %d", n)
+	}
+	if len(written["order-events"].messages) != 1 {
+		t.Errorf("expected 1 message replayed to order-events, got %d", len(written["order-events"].messages))
+	}
+	if len(written["batch-events"].messages) != 1 {
+		t.Errorf("expected 1 message replayed to batch-events, got %d", len(written["batch-events"].messages))
+	}
+	if len(reader.commits) != 2 {
+		t.Errorf("expected both messages committed, got %d", len(reader.commits))
+	}
+}
+
+func TestDLQReplayer_Replay_FallsBackToFallbackTopicWhenHeaderMissing(t *testing.T) {
+	messages := []kafka.Message{{Key: []byte("k"), Value: []byte("v")}}
+	r, _, written := newTestDLQReplayer(messages)
+
+	if _, err := r.Replay(context.Background(), 10); err != nil {
+		t.Fatalf("Replay() returned error: %v", err)
+	}
+	if len(written["order-events"].messages) != 1 {
+		t.Errorf("expected message replayed to fallback topic order-events, got topics: %v", written)
+	}
+}
+
+func TestDLQReplayer_Replay_StopsAtMaxMessages(t *testing.T) {
+	messages := []kafka.Message{
+		{Key: []byte("k1"), Value: []byte("v1")},
+		{Key: []byte("k2"), Value: []byte("v2")},
+		{Key: []byte("k3"), Value: []byte("v3")},
+	}
+	r, _, _ := newTestDLQReplayer(messages)
+
+	n, err := r.Replay(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Replay() returned error: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 messages replayed, got %d", n)
+	}
+}
+
+func TestDLQReplayer_Replay_ReturnsErrorWhenNothingAvailableYet(t *testing.T) {
+	r, _, _ := newTestDLQReplayer(nil)
+
+	if _, err := r.Replay(context.Background(), 5); err == nil {
+		t.Fatal("expected an error when the DLQ has no messages available")
+	} else if !errors.Is(err, io.EOF) {
+		t.Errorf("expected wrapped io.EOF, got %v", err)
+	}
+}
+
+// TestDLQReplayer_ConcurrentReplayDoesNotRaceOnReaderOrWriters guards against
+// the replayDLQHandler HTTP handler invoking Replay concurrently from two
+// /admin/dlq/replay requests and racing on the lazily-initialized reader or
+// the writers map (run with -race to catch a regression).
+func TestDLQReplayer_ConcurrentReplayDoesNotRaceOnReaderOrWriters(t *testing.T) {
+	var readerInits int32
+	var writerInits int32
+
+	r := NewDLQReplayer("unused:9092", "order-events.dlq", "order-events")
+	r.newReader = func(brokerAddress, topic string) kafkaReader {
+		atomic.AddInt32(&readerInits, 1)
+		return &fakeReader{}
+	}
+	r.newWriter = func(brokerAddress, topic string) kafkaWriter {
+		atomic.AddInt32(&writerInits, 1)
+		return &fakeWriter{topic: topic}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r.readerHandle()
+		}()
+		go func() {
+			defer wg.Done()
+			r.writerFor("order-events")
+		}()
+	}
+	wg.Wait()
+
+	if readerInits != 1 {
+		t.Errorf("expected exactly 1 reader to be created, got %d", readerInits)
+	}
+	if writerInits != 1 {
+		t.Errorf("expected exactly 1 writer to be created for order-events, got %d", writerInits)
+	}
+}
@@ -0,0 +1,189 @@
+// Package consumer holds the Kafka-specific pieces of the order-events
+// consumer runtime that don't belong in infrastructure/messaging: the
+// retry/backoff/DLQ pipeline backing messaging.KafkaSource.Retry, and a
+// helper for bounding how many messages from the same partition are
+// in flight at once. It has no dependency on infrastructure/messaging -
+// messaging.KafkaSource depends on this package's Escalator through a
+// narrow interface it declares itself, not the other way around - so there
+// is no import cycle between the two.
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// RetryAttemptHeader carries the delivery attempt count on a republished
+// message, mirroring the x-retry-count header messaging.AMQPSource already
+// sets for the same purpose.
+const RetryAttemptHeader = "x-retry-attempt"
+
+// RetryNotBeforeHeader carries the Unix-milliseconds timestamp a retried
+// message becomes eligible for reprocessing, per the exponential backoff
+// schedule Config.RetryBaseBackoff drives.
+const RetryNotBeforeHeader = "x-retry-not-before"
+
+// Config configures an Escalator's retry/DLQ topology.
+type Config struct {
+	// MaxRetries is how many times Escalate will route a message to a
+	// "<topic>.retry.<n>" topic before giving up and sending it to DLQTopic.
+	MaxRetries int
+	// RetryBaseBackoff is the delay before a first retry becomes eligible
+	// for reprocessing; each subsequent attempt doubles it.
+	RetryBaseBackoff time.Duration
+	// DLQTopic receives messages whose attempt exceeds MaxRetries.
+	DLQTopic string
+}
+
+func (c Config) withDefaults() Config {
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = 3
+	}
+	if c.RetryBaseBackoff <= 0 {
+		c.RetryBaseBackoff = 2 * time.Second
+	}
+	return c
+}
+
+// kafkaWriter is the subset of *kafka.Writer Escalator needs, narrowed so
+// tests can fake it without a live broker.
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Escalator republishes a message a handler failed to process: to a
+// numbered retry topic carrying an exponential backoff deadline while
+// attempts remain, or to the DLQ topic once Config.MaxRetries is exceeded.
+// One Escalator serves every retry level and the DLQ for a single base
+// topic, lazily opening a kafka.Writer per destination topic.
+type Escalator struct {
+	brokerAddress string
+	cfg           Config
+	newWriter     func(brokerAddress, topic string) kafkaWriter
+
+	mu           sync.Mutex
+	retryWriters map[string]kafkaWriter
+	dlqWriter    kafkaWriter
+}
+
+// NewEscalator creates an Escalator publishing against brokerAddress.
+func NewEscalator(brokerAddress string, cfg Config) *Escalator {
+	return &Escalator{
+		brokerAddress: brokerAddress,
+		cfg:           cfg.withDefaults(),
+		newWriter:     newKafkaWriter,
+		retryWriters:  make(map[string]kafkaWriter),
+	}
+}
+
+func newKafkaWriter(brokerAddress, topic string) kafkaWriter {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(brokerAddress),
+		Topic:        topic,
+		Balancer:     &kafka.LeastBytes{},
+		RequiredAcks: kafka.RequireOne,
+		WriteTimeout: 10 * time.Second,
+	}
+}
+
+// RetryTopic returns the topic Escalate routes attempt n of baseTopic to.
+func RetryTopic(baseTopic string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", baseTopic, attempt)
+}
+
+// RetryTopics returns every "<baseTopic>.retry.<n>" topic this Escalator's
+// Config.MaxRetries implies, so a caller (e.g. kafkaadmin bootstrapping) can
+// ensure they exist ahead of time.
+func (e *Escalator) RetryTopics(baseTopic string) []string {
+	return RetryTopicsFor(baseTopic, e.cfg.MaxRetries)
+}
+
+// RetryTopicsFor returns every "<baseTopic>.retry.<n>" topic for 1..maxRetries,
+// without needing an Escalator instance - useful for bootstrapping topics
+// ahead of the Escalator that will eventually publish to them.
+func RetryTopicsFor(baseTopic string, maxRetries int) []string {
+	if maxRetries <= 0 {
+		maxRetries = Config{}.withDefaults().MaxRetries
+	}
+	topics := make([]string, maxRetries)
+	for i := range topics {
+		topics[i] = RetryTopic(baseTopic, i+1)
+	}
+	return topics
+}
+
+// DLQTopic returns the topic exhausted messages are escalated to.
+func (e *Escalator) DLQTopic() string {
+	return e.cfg.DLQTopic
+}
+
+// Escalate republishes key/value/headers - a copy of a message baseTopic's
+// consumer failed to handle on attempt - to the next retry topic, or to the
+// DLQ topic if attempt exceeds Config.MaxRetries. headers is not mutated.
+func (e *Escalator) Escalate(ctx context.Context, baseTopic string, key, value []byte, headers map[string]string, attempt int) error {
+	out := make([]kafka.Header, 0, len(headers)+2)
+	for k, v := range headers {
+		out = append(out, kafka.Header{Key: k, Value: []byte(v)})
+	}
+
+	if attempt > e.cfg.MaxRetries {
+		out = append(out,
+			kafka.Header{Key: "dlq_reason", Value: []byte("max retries exceeded")},
+			kafka.Header{Key: "dlq_source_topic", Value: []byte(baseTopic)},
+		)
+		return e.dlqWriterFor().WriteMessages(ctx, kafka.Message{Key: key, Value: value, Headers: out})
+	}
+
+	backoff := e.cfg.RetryBaseBackoff * time.Duration(uint(1)<<uint(attempt-1))
+	out = append(out,
+		kafka.Header{Key: RetryAttemptHeader, Value: []byte(fmt.Sprintf("%d", attempt))},
+		kafka.Header{Key: RetryNotBeforeHeader, Value: []byte(fmt.Sprintf("%d", time.Now().Add(backoff).UnixMilli()))},
+	)
+
+	topic := RetryTopic(baseTopic, attempt)
+	return e.retryWriterFor(topic).WriteMessages(ctx, kafka.Message{Key: key, Value: value, Headers: out})
+}
+
+func (e *Escalator) retryWriterFor(topic string) kafkaWriter {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if w, ok := e.retryWriters[topic]; ok {
+		return w
+	}
+	w := e.newWriter(e.brokerAddress, topic)
+	e.retryWriters[topic] = w
+	return w
+}
+
+func (e *Escalator) dlqWriterFor() kafkaWriter {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.dlqWriter == nil {
+		e.dlqWriter = e.newWriter(e.brokerAddress, e.cfg.DLQTopic)
+	}
+	return e.dlqWriter
+}
+
+// Close closes every writer this Escalator has opened.
+func (e *Escalator) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var firstErr error
+	for _, w := range e.retryWriters {
+		if err := w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if e.dlqWriter != nil {
+		if err := e.dlqWriter.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
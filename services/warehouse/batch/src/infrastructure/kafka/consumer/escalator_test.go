@@ -0,0 +1,149 @@
+package consumer
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type fakeWriter struct {
+	topic    string
+	messages []kafka.Message
+	closed   bool
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func newTestEscalator(cfg Config) (*Escalator, map[string]*fakeWriter) {
+	written := make(map[string]*fakeWriter)
+	e := NewEscalator("unused:9092", cfg)
+	e.newWriter = func(brokerAddress, topic string) kafkaWriter {
+		w := &fakeWriter{topic: topic}
+		written[topic] = w
+		return w
+	}
+	return e, written
+}
+
+func TestEscalator_Escalate_RoutesToNumberedRetryTopicWithBackoffHeader(t *testing.T) {
+	e, written := newTestEscalator(Config{MaxRetries: 3, RetryBaseBackoff: time.Second, DLQTopic: "order-events.dlq"})
+
+	before := time.Now()
+	if err := e.Escalate(context.Background(), "order-events", []byte("k"), []byte("v"), map[string]string{"content-type": "application/json"}, 1); err != nil {
+		t.Fatalf("Escalate() returned error: %v", err)
+	}
+
+	w, ok := written["order-events.retry.1"]
+	if !ok {
+		t.Fatalf("expected a message on order-events.retry.1, got topics: %v", written)
+	}
+	if len(w.messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(w.messages))
+	}
+
+	msg := w.messages[0]
+	headers := headerMap(msg.Headers)
+	if headers["content-type"] != "application/json" {
+		t.Errorf("expected original headers to be preserved, got %v", headers)
+	}
+	if headers[RetryAttemptHeader] != "1" {
+		t.Errorf("expected %s=1, got %q", RetryAttemptHeader, headers[RetryAttemptHeader])
+	}
+
+	notBeforeMs, err := parseInt64(headers[RetryNotBeforeHeader])
+	if err != nil {
+		t.Fatalf("invalid %s header: %v", RetryNotBeforeHeader, err)
+	}
+	notBefore := time.UnixMilli(notBeforeMs)
+	if !notBefore.After(before) {
+		t.Errorf("expected %s to be in the future, got %v (before %v)", RetryNotBeforeHeader, notBefore, before)
+	}
+}
+
+func TestEscalator_Escalate_BackoffDoublesPerAttempt(t *testing.T) {
+	e, written := newTestEscalator(Config{MaxRetries: 3, RetryBaseBackoff: time.Second, DLQTopic: "order-events.dlq"})
+
+	if err := e.Escalate(context.Background(), "order-events", nil, nil, nil, 1); err != nil {
+		t.Fatalf("Escalate(attempt=1) returned error: %v", err)
+	}
+	if err := e.Escalate(context.Background(), "order-events", nil, nil, nil, 2); err != nil {
+		t.Fatalf("Escalate(attempt=2) returned error: %v", err)
+	}
+
+	notBefore1 := mustNotBefore(t, written["order-events.retry.1"])
+	notBefore2 := mustNotBefore(t, written["order-events.retry.2"])
+
+	gap1 := notBefore1.Sub(time.Now())
+	gap2 := notBefore2.Sub(time.Now())
+	if gap2 <= gap1 {
+		t.Errorf("expected attempt 2's backoff (%v) to be longer than attempt 1's (%v)", gap2, gap1)
+	}
+}
+
+func TestEscalator_Escalate_EscalatesToDLQAfterMaxRetries(t *testing.T) {
+	e, written := newTestEscalator(Config{MaxRetries: 2, RetryBaseBackoff: time.Second, DLQTopic: "order-events.dlq"})
+
+	if err := e.Escalate(context.Background(), "order-events", []byte("k"), []byte("v"), nil, 3); err != nil {
+		t.Fatalf("Escalate() returned error: %v", err)
+	}
+
+	w, ok := written["order-events.dlq"]
+	if !ok {
+		t.Fatalf("expected a message on order-events.dlq, got topics: %v", written)
+	}
+	headers := headerMap(w.messages[0].Headers)
+	if headers["dlq_source_topic"] != "order-events" {
+		t.Errorf("expected dlq_source_topic=order-events, got %q", headers["dlq_source_topic"])
+	}
+}
+
+func TestEscalator_RetryTopics_ListsOneTopicPerMaxRetries(t *testing.T) {
+	e, _ := newTestEscalator(Config{MaxRetries: 3, DLQTopic: "order-events.dlq"})
+
+	got := e.RetryTopics("order-events")
+	want := []string{"order-events.retry.1", "order-events.retry.2", "order-events.retry.3"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected topic %d to be %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func headerMap(headers []kafka.Header) map[string]string {
+	out := make(map[string]string, len(headers))
+	for _, h := range headers {
+		out[h.Key] = string(h.Value)
+	}
+	return out
+}
+
+func mustNotBefore(t *testing.T, w *fakeWriter) time.Time {
+	t.Helper()
+	if w == nil || len(w.messages) == 0 {
+		t.Fatalf("expected at least one message written")
+	}
+	headers := headerMap(w.messages[0].Headers)
+	ms, err := parseInt64(headers[RetryNotBeforeHeader])
+	if err != nil {
+		t.Fatalf("invalid %s header: %v", RetryNotBeforeHeader, err)
+	}
+	return time.UnixMilli(ms)
+}
+
+func parseInt64(s string) (int64, error) {
+	return strconv.ParseInt(s, 10, 64)
+}
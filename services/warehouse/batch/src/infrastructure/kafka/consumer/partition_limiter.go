@@ -0,0 +1,62 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+)
+
+// PartitionLimiter bounds how many messages from the same partition are
+// processed concurrently, so a slow or stuck handler on one partition can't
+// starve the in-flight budget every other partition draws from under a
+// single global semaphore. Each partition gets its own buffered channel of
+// size maxInFlight; Acquire blocks only when that specific partition is
+// already at its limit.
+type PartitionLimiter struct {
+	maxInFlight int
+
+	mu    sync.Mutex
+	slots map[int]chan struct{}
+}
+
+// NewPartitionLimiter creates a PartitionLimiter allowing up to maxInFlight
+// concurrent messages per partition. maxInFlight <= 0 is treated as 1.
+func NewPartitionLimiter(maxInFlight int) *PartitionLimiter {
+	if maxInFlight <= 0 {
+		maxInFlight = 1
+	}
+	return &PartitionLimiter{
+		maxInFlight: maxInFlight,
+		slots:       make(map[int]chan struct{}),
+	}
+}
+
+// Acquire blocks until partition has a free in-flight slot, or ctx is
+// cancelled.
+func (l *PartitionLimiter) Acquire(ctx context.Context, partition int) error {
+	select {
+	case l.slotFor(partition) <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees the in-flight slot a prior Acquire(ctx, partition) reserved.
+func (l *PartitionLimiter) Release(partition int) {
+	select {
+	case <-l.slotFor(partition):
+	default:
+	}
+}
+
+func (l *PartitionLimiter) slotFor(partition int) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slot, ok := l.slots[partition]
+	if !ok {
+		slot = make(chan struct{}, l.maxInFlight)
+		l.slots[partition] = slot
+	}
+	return slot
+}
@@ -0,0 +1,68 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPartitionLimiter_AcquireBlocksOnlyTheSamePartitionOnceFull(t *testing.T) {
+	limiter := NewPartitionLimiter(1)
+	ctx := context.Background()
+
+	if err := limiter.Acquire(ctx, 0); err != nil {
+		t.Fatalf("first Acquire(partition 0) returned error: %v", err)
+	}
+
+	// Partition 0 is now full, but partition 1 has its own independent slot.
+	if err := limiter.Acquire(ctx, 1); err != nil {
+		t.Fatalf("Acquire(partition 1) returned error: %v", err)
+	}
+
+	acquiredAgain := make(chan error, 1)
+	go func() {
+		acquiredAgain <- limiter.Acquire(ctx, 0)
+	}()
+
+	select {
+	case <-acquiredAgain:
+		t.Fatal("expected second Acquire(partition 0) to block while the first slot is held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	limiter.Release(0)
+
+	select {
+	case err := <-acquiredAgain:
+		if err != nil {
+			t.Fatalf("Acquire(partition 0) after Release returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Acquire(partition 0) to unblock after Release")
+	}
+}
+
+func TestPartitionLimiter_AcquireRespectsContextCancellation(t *testing.T) {
+	limiter := NewPartitionLimiter(1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := limiter.Acquire(context.Background(), 0); err != nil {
+		t.Fatalf("first Acquire returned error: %v", err)
+	}
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- limiter.Acquire(ctx, 0)
+	}()
+
+	cancel()
+
+	select {
+	case err := <-blocked:
+		if err != ctx.Err() {
+			t.Errorf("expected ctx.Err(), got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected blocked Acquire to return once ctx is cancelled")
+	}
+}
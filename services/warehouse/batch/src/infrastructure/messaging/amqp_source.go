@@ -0,0 +1,249 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// retryCountHeader carries a message's delivery attempt count, set by Retry
+// each time it republishes a message to the retry queue.
+const retryCountHeader = "x-retry-count"
+
+// RetryConfig configures the per-message retry/dead-letter topology
+// NewAMQPSource declares alongside the main queue, following the pattern
+// popularized by bunnify: a "<queue>.retry" queue whose messages expire
+// after RetryTTL and dead-letter back onto the main exchange for
+// redelivery, and a terminal "<queue>.dlq" queue for messages that have
+// exhausted MaxAttempts.
+type RetryConfig struct {
+	// MaxAttempts is how many times Retry will route a message to the retry
+	// queue before routing it to the dead-letter queue instead. A zero value
+	// defaults to 5.
+	MaxAttempts int
+	// RetryTTL is how long a message waits on the retry queue before RabbitMQ
+	// dead-letters it back onto the main exchange. A zero value defaults to
+	// 5 seconds.
+	RetryTTL time.Duration
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.RetryTTL <= 0 {
+		c.RetryTTL = 5 * time.Second
+	}
+	return c
+}
+
+// AMQPSource implements MessageSource over a RabbitMQ queue.
+type AMQPSource struct {
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	queue   string
+
+	retryConfig RetryConfig
+	retryQueue  string
+	dlqQueue    string
+}
+
+// NewAMQPSource connects to rabbitMQURL and declares/binds queueName to
+// exchangeName with routingKey, mirroring the order service's consumer
+// setup. It also declares queueName's retry and dead-letter queues per
+// retryConfig; see RetryConfig and Retry.
+func NewAMQPSource(rabbitMQURL, exchangeName, queueName, routingKey string, retryConfig RetryConfig) (*AMQPSource, error) {
+	retryConfig = retryConfig.withDefaults()
+
+	conn, err := amqp.Dial(rabbitMQURL)
+	if err != nil {
+		return nil, fmt.Errorf("messaging: failed to dial RabbitMQ: %w", err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("messaging: failed to open channel: %w", err)
+	}
+
+	if err := channel.ExchangeDeclare(exchangeName, "direct", true, false, false, false, nil); err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("messaging: failed to declare exchange: %w", err)
+	}
+
+	if _, err := channel.QueueDeclare(queueName, true, false, false, false, nil); err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("messaging: failed to declare queue: %w", err)
+	}
+
+	if err := channel.QueueBind(queueName, routingKey, exchangeName, false, nil); err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("messaging: failed to bind queue: %w", err)
+	}
+
+	retryQueue := queueName + ".retry"
+	if _, err := channel.QueueDeclare(retryQueue, true, false, false, false, amqp.Table{
+		"x-message-ttl":             int64(retryConfig.RetryTTL / time.Millisecond),
+		"x-dead-letter-exchange":    exchangeName,
+		"x-dead-letter-routing-key": routingKey,
+	}); err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("messaging: failed to declare retry queue: %w", err)
+	}
+
+	dlqQueue := queueName + ".dlq"
+	if _, err := channel.QueueDeclare(dlqQueue, true, false, false, false, nil); err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("messaging: failed to declare dead-letter queue: %w", err)
+	}
+
+	return &AMQPSource{
+		conn:        conn,
+		channel:     channel,
+		queue:       queueName,
+		retryConfig: retryConfig,
+		retryQueue:  retryQueue,
+		dlqQueue:    dlqQueue,
+	}, nil
+}
+
+// Consume implements MessageSource.
+func (s *AMQPSource) Consume(ctx context.Context) (<-chan RawMessage, error) {
+	deliveries, err := s.channel.Consume(
+		s.queue,
+		"",    // consumer
+		false, // auto-ack is false, Ack/Nack are driven by the caller
+		false, // exclusive
+		false, // no-local
+		false, // no-wait
+		nil,   // args
+	)
+	if err != nil {
+		return nil, fmt.Errorf("messaging: failed to register AMQP consumer: %w", err)
+	}
+
+	out := make(chan RawMessage)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case delivery, ok := <-deliveries:
+				if !ok {
+					return
+				}
+				select {
+				case out <- toAMQPRawMessage(s.queue, delivery):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func toAMQPRawMessage(queue string, delivery amqp.Delivery) RawMessage {
+	headers := make(map[string]string, len(delivery.Headers))
+	for key, value := range delivery.Headers {
+		switch v := value.(type) {
+		case string:
+			headers[key] = v
+		case int32:
+			headers[key] = strconv.FormatInt(int64(v), 10)
+		case int64:
+			headers[key] = strconv.FormatInt(v, 10)
+		}
+	}
+
+	contentType := delivery.ContentType
+	if contentType == "" {
+		contentType = headers["content-type"]
+	}
+
+	return RawMessage{
+		Value:       delivery.Body,
+		ContentType: contentType,
+		Headers:     headers,
+		Topic:       queue,
+		Partition:   -1,
+		ref:         delivery,
+	}
+}
+
+// Ack implements MessageSource.
+func (s *AMQPSource) Ack(msg RawMessage) error {
+	delivery, ok := msg.ref.(amqp.Delivery)
+	if !ok {
+		return fmt.Errorf("messaging: RawMessage was not produced by an AMQPSource")
+	}
+	return delivery.Ack(false)
+}
+
+// Nack implements MessageSource.
+func (s *AMQPSource) Nack(msg RawMessage, requeue bool) error {
+	delivery, ok := msg.ref.(amqp.Delivery)
+	if !ok {
+		return fmt.Errorf("messaging: RawMessage was not produced by an AMQPSource")
+	}
+	return delivery.Nack(false, requeue)
+}
+
+// Retry implements MessageSource by acking the original delivery and
+// republishing a copy carrying attempt in its x-retry-count header: to the
+// retry queue while attempt is within retryConfig.MaxAttempts, so it waits
+// out RetryTTL and dead-letters back onto the main exchange for
+// redelivery, or straight to the dead-letter queue once attempts are
+// exhausted.
+func (s *AMQPSource) Retry(msg RawMessage, attempt int) error {
+	delivery, ok := msg.ref.(amqp.Delivery)
+	if !ok {
+		return fmt.Errorf("messaging: RawMessage was not produced by an AMQPSource")
+	}
+
+	target := s.retryQueue
+	if attempt > s.retryConfig.MaxAttempts {
+		target = s.dlqQueue
+	}
+
+	headers := amqp.Table{}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	headers[retryCountHeader] = int64(attempt)
+
+	// Publishing via the default exchange ("") with the target queue's own
+	// name as the routing key relies on every queue's implicit binding to
+	// it, so this doesn't need its own exchange/binding declarations.
+	err := s.channel.PublishWithContext(context.Background(), "", target, false, false, amqp.Publishing{
+		ContentType: delivery.ContentType,
+		Body:        delivery.Body,
+		Headers:     headers,
+	})
+	if err != nil {
+		return fmt.Errorf("messaging: failed to republish message to %s: %w", target, err)
+	}
+
+	return delivery.Ack(false)
+}
+
+// Close implements MessageSource.
+func (s *AMQPSource) Close() error {
+	if s.channel != nil {
+		s.channel.Close()
+	}
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// structuredEnvelope is the minimal set of CloudEvents 1.0 structured-mode
+// fields this codec needs: everything else is carried in Data and decoded
+// straight into the caller's out value.
+type structuredEnvelope struct {
+	SpecVersion string          `json:"specversion"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// CloudEventsContentType is the content-type CloudEventsCodec handles.
+const CloudEventsContentType = "application/cloudevents+json"
+
+// CloudEventsCodec decodes "application/cloudevents+json" structured-mode
+// messages: a single JSON envelope whose "data" field carries the event
+// payload untouched.
+type CloudEventsCodec struct{}
+
+// ContentType implements Codec.
+func (CloudEventsCodec) ContentType() string { return CloudEventsContentType }
+
+// Decode implements Codec.
+func (CloudEventsCodec) Decode(raw RawMessage, out any) error {
+	var envelope structuredEnvelope
+	if err := json.Unmarshal(raw.Value, &envelope); err != nil {
+		return fmt.Errorf("messaging: failed to unmarshal cloudevents envelope: %w", err)
+	}
+	if envelope.SpecVersion == "" {
+		return fmt.Errorf("messaging: message missing required specversion attribute")
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
@@ -0,0 +1,68 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Codec decodes a RawMessage's Value into out.
+type Codec interface {
+	// ContentType is the content-type value this codec handles (e.g.
+	// "application/json").
+	ContentType() string
+	Decode(raw RawMessage, out any) error
+}
+
+// Registry dispatches a RawMessage to the Codec matching its ContentType,
+// falling back to a default codec for messages with no (or an
+// unrecognized) content type.
+type Registry struct {
+	codecs   map[string]Codec
+	fallback Codec
+}
+
+// NewRegistry builds a Registry from the given codecs, using fallback for
+// messages whose ContentType is empty or doesn't match a registered codec.
+func NewRegistry(fallback Codec, codecs ...Codec) *Registry {
+	reg := &Registry{codecs: make(map[string]Codec, len(codecs)), fallback: fallback}
+	for _, codec := range codecs {
+		reg.codecs[codec.ContentType()] = codec
+	}
+	return reg
+}
+
+// Decode picks the codec matching msg.ContentType and decodes msg into out.
+// When msg.ContentType is empty or unrecognized, it also sniffs the message
+// body for a CloudEvents "specversion" attribute before giving up on the
+// fallback codec, so structured-mode envelopes are still decoded correctly
+// even when a producer/bridge didn't set the "application/cloudevents+json"
+// content type.
+func (r *Registry) Decode(msg RawMessage, out any) error {
+	codec := r.fallback
+	if msg.ContentType != "" {
+		if c, ok := r.codecs[msg.ContentType]; ok {
+			codec = c
+		} else if c, ok := r.codecs[CloudEventsContentType]; ok && looksLikeStructuredCloudEvent(msg.Value) {
+			codec = c
+		}
+	} else if c, ok := r.codecs[CloudEventsContentType]; ok && looksLikeStructuredCloudEvent(msg.Value) {
+		codec = c
+	}
+	if codec == nil {
+		return fmt.Errorf("messaging: no codec registered for content type %q", msg.ContentType)
+	}
+	return codec.Decode(msg, out)
+}
+
+// looksLikeStructuredCloudEvent reports whether value is a JSON object
+// carrying a non-empty top-level "specversion" attribute, the one field
+// every CloudEvents structured-mode envelope is required to have.
+func looksLikeStructuredCloudEvent(value []byte) bool {
+	var probe struct {
+		SpecVersion string `json:"specversion"`
+	}
+	if err := json.Unmarshal(value, &probe); err != nil {
+		return false
+	}
+	return probe.SpecVersion != ""
+}
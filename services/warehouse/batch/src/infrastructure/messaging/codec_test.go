@@ -0,0 +1,82 @@
+package messaging
+
+import "testing"
+
+type captured struct {
+	Name string `json:"name"`
+}
+
+func TestRegistry_DecodeDispatchesByContentType(t *testing.T) {
+	registry := NewRegistry(JSONCodec{}, JSONCodec{}, CloudEventsCodec{})
+
+	var plain captured
+	msg := RawMessage{ContentType: "application/json", Value: []byte(`{"name":"plain"}`)}
+	if err := registry.Decode(msg, &plain); err != nil {
+		t.Fatalf("expected JSON decode to succeed, got %v", err)
+	}
+	if plain.Name != "plain" {
+		t.Errorf("expected name %q, got %q", "plain", plain.Name)
+	}
+
+	var wrapped captured
+	ceMsg := RawMessage{
+		ContentType: "application/cloudevents+json",
+		Value:       []byte(`{"specversion":"1.0","data":{"name":"wrapped"}}`),
+	}
+	if err := registry.Decode(ceMsg, &wrapped); err != nil {
+		t.Fatalf("expected CloudEvents decode to succeed, got %v", err)
+	}
+	if wrapped.Name != "wrapped" {
+		t.Errorf("expected name %q, got %q", "wrapped", wrapped.Name)
+	}
+}
+
+func TestRegistry_DecodeFallsBackWhenContentTypeEmpty(t *testing.T) {
+	registry := NewRegistry(JSONCodec{}, CloudEventsCodec{})
+
+	var legacy captured
+	msg := RawMessage{Value: []byte(`{"name":"legacy"}`)}
+	if err := registry.Decode(msg, &legacy); err != nil {
+		t.Fatalf("expected fallback codec to decode legacy message, got %v", err)
+	}
+	if legacy.Name != "legacy" {
+		t.Errorf("expected name %q, got %q", "legacy", legacy.Name)
+	}
+}
+
+func TestRegistry_DecodeSniffsStructuredCloudEventWithoutMatchingContentType(t *testing.T) {
+	registry := NewRegistry(JSONCodec{}, JSONCodec{}, CloudEventsCodec{})
+
+	var sniffed captured
+	// A bridge that forwards the envelope under an unrecognized or missing
+	// content type should still be decoded as CloudEvents, since the body
+	// carries a specversion attribute.
+	msg := RawMessage{
+		ContentType: "text/plain",
+		Value:       []byte(`{"specversion":"1.0","data":{"name":"sniffed"}}`),
+	}
+	if err := registry.Decode(msg, &sniffed); err != nil {
+		t.Fatalf("expected sniffed CloudEvents decode to succeed, got %v", err)
+	}
+	if sniffed.Name != "sniffed" {
+		t.Errorf("expected name %q, got %q", "sniffed", sniffed.Name)
+	}
+}
+
+func TestRegistry_DecodeErrorsWithoutFallback(t *testing.T) {
+	registry := NewRegistry(nil, JSONCodec{})
+
+	var out captured
+	msg := RawMessage{ContentType: "application/unknown", Value: []byte(`{}`)}
+	if err := registry.Decode(msg, &out); err == nil {
+		t.Fatal("expected an error for an unregistered content type with no fallback")
+	}
+}
+
+func TestProtobufCodec_DecodeIsNotImplemented(t *testing.T) {
+	codec := ProtobufCodec{}
+	var out captured
+	if err := codec.Decode(RawMessage{Value: []byte{0x01}}, &out); err == nil {
+		t.Fatal("expected an error since protobuf decoding has no schema yet")
+	}
+}
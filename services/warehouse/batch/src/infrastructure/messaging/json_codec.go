@@ -0,0 +1,16 @@
+package messaging
+
+import "encoding/json"
+
+// JSONCodec decodes a plain "application/json" message body. It is also
+// the right fallback for messages produced before any content-type
+// convention existed, since their payload is plain JSON too.
+type JSONCodec struct{}
+
+// ContentType implements Codec.
+func (JSONCodec) ContentType() string { return "application/json" }
+
+// Decode implements Codec.
+func (JSONCodec) Decode(raw RawMessage, out any) error {
+	return json.Unmarshal(raw.Value, out)
+}
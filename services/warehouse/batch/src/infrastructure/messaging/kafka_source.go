@@ -0,0 +1,140 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// RetryEscalator republishes a message a handler failed to process to its
+// next retry topic, or to a DLQ topic once its attempt exceeds the
+// escalator's own configured limit. infrastructure/kafka/consumer.Escalator
+// satisfies this; it's declared here (rather than imported) so messaging
+// doesn't depend on that package - consumer depends on messaging's RawMessage
+// shape conceptually, not the other way around.
+type RetryEscalator interface {
+	Escalate(ctx context.Context, baseTopic string, key, value []byte, headers map[string]string, attempt int) error
+}
+
+// KafkaSource implements MessageSource over a Kafka topic/consumer group.
+type KafkaSource struct {
+	reader         *kafka.Reader
+	topic          string
+	retryEscalator RetryEscalator
+}
+
+// NewKafkaSource creates a KafkaSource consuming topic within the given
+// consumer group. retryEscalator may be nil, in which case Retry is a no-op
+// exactly as it was before this parameter existed: a message that failed
+// handling simply isn't acked, and is refetched on the next rebalance.
+func NewKafkaSource(brokerAddress, topic, groupID string, retryEscalator RetryEscalator) *KafkaSource {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     []string{brokerAddress},
+		Topic:       topic,
+		GroupID:     groupID,
+		MinBytes:    10e3, // 10KB
+		MaxBytes:    10e6, // 10MB
+		StartOffset: kafka.LastOffset,
+		MaxAttempts: 3,
+		Dialer: &kafka.Dialer{
+			Timeout: 10 * time.Second,
+		},
+	})
+
+	return &KafkaSource{reader: reader, topic: topic, retryEscalator: retryEscalator}
+}
+
+// Consume implements MessageSource.
+func (s *KafkaSource) Consume(ctx context.Context) (<-chan RawMessage, error) {
+	out := make(chan RawMessage)
+
+	go func() {
+		defer close(out)
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+
+			readCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			msg, err := s.reader.FetchMessage(readCtx)
+			cancel()
+
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("KafkaSource: error fetching message from topic %s: %v", s.topic, err)
+				continue
+			}
+
+			select {
+			case out <- toRawMessage(msg):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func toRawMessage(msg kafka.Message) RawMessage {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	return RawMessage{
+		Key:         msg.Key,
+		Value:       msg.Value,
+		ContentType: headers["content-type"],
+		Headers:     headers,
+		Topic:       msg.Topic,
+		Partition:   msg.Partition,
+		Offset:      msg.Offset,
+		ref:         msg,
+	}
+}
+
+// Ack implements MessageSource by committing msg's offset.
+func (s *KafkaSource) Ack(msg RawMessage) error {
+	kafkaMsg, ok := msg.ref.(kafka.Message)
+	if !ok {
+		return fmt.Errorf("messaging: RawMessage was not produced by a KafkaSource")
+	}
+	return s.reader.CommitMessages(context.Background(), kafkaMsg)
+}
+
+// Nack implements MessageSource. Kafka has no native reject/requeue;
+// simply not committing the offset is enough for the message to be
+// refetched after a rebalance, so this is a no-op.
+func (s *KafkaSource) Nack(msg RawMessage, requeue bool) error {
+	return nil
+}
+
+// Retry implements MessageSource. With no retryEscalator configured, Kafka
+// has no native retry-queue topology and, like Nack, simply not committing
+// the offset is enough for the message to be refetched after a rebalance,
+// so this is a no-op. With one configured, it republishes msg via
+// retryEscalator.Escalate (to a numbered retry topic, or the DLQ topic once
+// attempt is exhausted) and then commits the original offset itself, the
+// same acked-then-republished pattern AMQPSource.Retry already uses.
+func (s *KafkaSource) Retry(msg RawMessage, attempt int) error {
+	if s.retryEscalator == nil {
+		return nil
+	}
+
+	if err := s.retryEscalator.Escalate(context.Background(), s.topic, msg.Key, msg.Value, msg.Headers, attempt); err != nil {
+		return fmt.Errorf("messaging: failed to escalate message for retry: %w", err)
+	}
+
+	return s.Ack(msg)
+}
+
+// Close implements MessageSource.
+func (s *KafkaSource) Close() error {
+	return s.reader.Close()
+}
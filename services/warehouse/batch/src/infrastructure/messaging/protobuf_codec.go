@@ -0,0 +1,17 @@
+package messaging
+
+import "fmt"
+
+// ProtobufCodec is registered for "application/protobuf" so content-type
+// dispatch recognizes the media type, but this service has no generated
+// protobuf message types yet. Decode always fails until a concrete schema
+// is wired in.
+type ProtobufCodec struct{}
+
+// ContentType implements Codec.
+func (ProtobufCodec) ContentType() string { return "application/protobuf" }
+
+// Decode implements Codec.
+func (ProtobufCodec) Decode(raw RawMessage, out any) error {
+	return fmt.Errorf("messaging: application/protobuf decoding is not implemented")
+}
@@ -0,0 +1,39 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain/schema"
+)
+
+// SchemaRegistryContentType is the content-type a producer sets when a
+// message's value is wrapped in the Confluent Schema Registry wire format
+// (see domain/schema.Codec), so Registry.Decode routes it to
+// SchemaRegistryCodec instead of plain JSONCodec.
+const SchemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+
+// SchemaRegistryCodec decodes messages wrapped in the Confluent wire format,
+// delegating envelope parsing and schema ID resolution to a schema.Codec and
+// JSON-unmarshaling the payload it returns.
+type SchemaRegistryCodec struct {
+	codec schema.Codec
+}
+
+// NewSchemaRegistryCodec returns a SchemaRegistryCodec backed by codec.
+func NewSchemaRegistryCodec(codec schema.Codec) SchemaRegistryCodec {
+	return SchemaRegistryCodec{codec: codec}
+}
+
+// ContentType implements Codec.
+func (c SchemaRegistryCodec) ContentType() string { return SchemaRegistryContentType }
+
+// Decode implements Codec.
+func (c SchemaRegistryCodec) Decode(raw RawMessage, out any) error {
+	data, err := c.codec.Decode(context.Background(), raw.Value)
+	if err != nil {
+		return fmt.Errorf("messaging: failed to decode schema registry envelope: %w", err)
+	}
+	return json.Unmarshal(data, out)
+}
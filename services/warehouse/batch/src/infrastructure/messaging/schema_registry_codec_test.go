@@ -0,0 +1,33 @@
+package messaging
+
+import (
+	"testing"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain/schema"
+)
+
+func TestSchemaRegistryCodec_Decode_DelegatesToUnderlyingCodec(t *testing.T) {
+	codec := NewSchemaRegistryCodec(schema.PassthroughCodec{})
+
+	var out captured
+	msg := RawMessage{ContentType: SchemaRegistryContentType, Value: []byte(`{"name":"envelope"}`)}
+	if err := codec.Decode(msg, &out); err != nil {
+		t.Fatalf("Decode() returned error: %v", err)
+	}
+	if out.Name != "envelope" {
+		t.Errorf("expected name %q, got %q", "envelope", out.Name)
+	}
+}
+
+func TestRegistry_DecodeDispatchesToSchemaRegistryContentType(t *testing.T) {
+	registry := NewRegistry(JSONCodec{}, JSONCodec{}, NewSchemaRegistryCodec(schema.PassthroughCodec{}))
+
+	var out captured
+	msg := RawMessage{ContentType: SchemaRegistryContentType, Value: []byte(`{"name":"dispatched"}`)}
+	if err := registry.Decode(msg, &out); err != nil {
+		t.Fatalf("Decode() returned error: %v", err)
+	}
+	if out.Name != "dispatched" {
+		t.Errorf("expected name %q, got %q", "dispatched", out.Name)
+	}
+}
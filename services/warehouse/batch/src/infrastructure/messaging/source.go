@@ -0,0 +1,58 @@
+// Package messaging abstracts consuming from a message broker behind a
+// single MessageSource contract, so a driving adapter can be backed by
+// Kafka or RabbitMQ (or both, for different event types) selected by
+// config instead of a bespoke per-broker consume/ack loop. A Codec
+// registry decodes a RawMessage's body based on its content type, letting
+// the same adapter accept plain JSON, CloudEvents-wrapped JSON, or other
+// encodings without knowing in advance which one a given message uses.
+package messaging
+
+import "context"
+
+// RawMessage is one message fetched from a MessageSource, broker-agnostic
+// aside from the fields a Kafka-specific caller may want (Partition,
+// Offset), which are -1/0 for sources with no such concept (e.g. AMQP).
+type RawMessage struct {
+	Key         []byte
+	Value       []byte
+	ContentType string
+	Headers     map[string]string
+
+	// Topic is the source topic/queue name, used e.g. to tag dead-lettered
+	// messages with where they came from.
+	Topic string
+	// Partition is the Kafka partition this message was fetched from, or -1
+	// for sources with no partition concept.
+	Partition int
+	Offset    int64
+
+	// ref is the broker-specific handle (a kafka.Message or an
+	// amqp.Delivery) the owning MessageSource needs to Ack or Nack this
+	// message.
+	ref any
+}
+
+// MessageSource abstracts consuming from a message broker so driving
+// adapters don't need bespoke per-broker consume/ack loops.
+type MessageSource interface {
+	// Consume starts delivering messages on the returned channel until ctx
+	// is cancelled, at which point the channel is closed.
+	Consume(ctx context.Context) (<-chan RawMessage, error)
+	// Ack acknowledges successful processing of msg.
+	Ack(msg RawMessage) error
+	// Nack rejects msg, optionally requeuing it for redelivery. Sources
+	// with no native reject/requeue (e.g. Kafka) may treat this as a no-op:
+	// simply not acking is enough for the message to be redelivered.
+	Nack(msg RawMessage, requeue bool) error
+	// Retry routes msg for attempt's redelivery, acking the original and
+	// republishing a copy carrying attempt in a header - to a delayed retry
+	// queue or topic while attempt is within the source's configured limit,
+	// or to a terminal dead-letter queue once it isn't. AMQPSource records
+	// attempt in x-retry-count. KafkaSource built with a retry escalator
+	// republishes to a numbered retry topic or the DLQ topic with attempt in
+	// x-retry-attempt; built without one, it treats this as a no-op: not
+	// acking already redelivers the message, see Nack.
+	Retry(msg RawMessage, attempt int) error
+	// Close releases the underlying broker connection.
+	Close() error
+}
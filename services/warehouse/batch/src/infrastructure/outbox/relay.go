@@ -0,0 +1,114 @@
+// Package outbox relays domain.OutboxEvent rows persisted by the
+// transactional outbox pattern (domain.OutboxRepository) to the service's
+// domain.BatchEventPublisher. Because the batch state change and its
+// outgoing events are saved atomically by the repository, a Kafka outage no
+// longer loses the event outright: the row just sits unpublished until the
+// next relay pass retries it.
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/retry"
+)
+
+// RelayConfig configures the Relay's polling cadence and per-event retry
+// backoff.
+type RelayConfig struct {
+	// PollInterval is how often the relay checks for unpublished rows.
+	PollInterval time.Duration
+	// BatchSize caps how many rows are fetched per poll.
+	BatchSize int
+	// Retry governs the backoff applied while retrying a single event's
+	// publish within one poll. A zero value defaults to defaultRelayPolicy,
+	// since retry.Policy's own zero value (MaxAttempts 0, InitialDelay 0)
+	// means unbounded retries with no delay between them - a busy loop, not
+	// a single attempt.
+	Retry retry.Policy
+}
+
+// defaultRelayPolicy is applied when a caller leaves RelayConfig.Retry
+// unset, mirroring driven-adapters/reconnector.go's defaultReconnectPolicy.
+var defaultRelayPolicy = retry.Policy{
+	MaxAttempts:  5,
+	InitialDelay: 500 * time.Millisecond,
+	MaxDelay:     30 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+// Relay polls an OutboxRepository for unpublished rows and hands each to a
+// BatchEventPublisher, marking it sent on success or recording the failure
+// for the next pass otherwise.
+type Relay struct {
+	repo      domain.OutboxRepository
+	publisher domain.BatchEventPublisher
+	cfg       RelayConfig
+}
+
+// NewRelay creates a Relay. A zero PollInterval defaults to 2 seconds and a
+// zero BatchSize defaults to 100.
+func NewRelay(repo domain.OutboxRepository, publisher domain.BatchEventPublisher, cfg RelayConfig) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Retry.MaxAttempts == 0 && cfg.Retry.InitialDelay == 0 {
+		cfg.Retry = defaultRelayPolicy
+	}
+	return &Relay{repo: repo, publisher: publisher, cfg: cfg}
+}
+
+// Start polls until ctx is cancelled.
+func (r *Relay) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.relayOnce(ctx)
+		}
+	}
+}
+
+// relayOnce publishes every currently unpublished row, oldest first.
+func (r *Relay) relayOnce(ctx context.Context) {
+	rows, err := r.repo.FindUnpublished(r.cfg.BatchSize)
+	if err != nil {
+		log.Printf("outbox: failed to list unpublished rows: %v", err)
+		return
+	}
+
+	for _, row := range rows {
+		err := retry.Do(ctx, func() error {
+			return r.publisher.PublishBatchEvent(ctx, row.Event)
+		}, r.cfg.Retry)
+
+		if err != nil {
+			if markErr := r.repo.MarkFailed(row.ID, err); markErr != nil {
+				log.Printf("outbox: failed to record failed attempt for row %s: %v", row.ID, markErr)
+			}
+			log.Printf("outbox: failed to publish %s event for aggregate %s (seq %d), will retry next poll: %v",
+				row.Event.EventType, row.AggregateID, row.Sequence, err)
+			continue
+		}
+
+		if err := r.repo.MarkPublished(row.ID); err != nil {
+			log.Printf("outbox: failed to mark row %s published: %v", row.ID, err)
+		}
+	}
+}
+
+// Lag reports the number of rows not yet published, for callers that expose
+// it as a metric (e.g. an HTTP endpoint or periodic log line).
+func (r *Relay) Lag() (int, error) {
+	return r.repo.CountUnpublished()
+}
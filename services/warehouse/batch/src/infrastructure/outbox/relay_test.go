@@ -0,0 +1,121 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	drivenadapters "github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/driven-adapters"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/retry"
+)
+
+func TestRelay_PublishesUnpublishedRowsAndMarksThemSent(t *testing.T) {
+	repo := drivenadapters.NewBatchMemoryRepository()
+	publisher := domain.NewMockBatchEventPublisher()
+
+	batch := domain.NewBatch("BATCH-1", "product-1")
+	if err := repo.SaveBatchAndEvents(batch, []*domain.BatchEvent{domain.NewBatchCreatedEvent(context.Background(), batch)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	relay := NewRelay(repo, publisher, RelayConfig{PollInterval: time.Millisecond, BatchSize: 10})
+	relay.relayOnce(context.Background())
+
+	if publisher.GetEventCount() != 1 {
+		t.Fatalf("expected 1 published event, got %d", publisher.GetEventCount())
+	}
+
+	lag, err := relay.Lag()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lag != 0 {
+		t.Errorf("expected lag 0 after relaying, got %d", lag)
+	}
+}
+
+func TestRelay_RetriesAndLeavesRowUnpublishedOnPersistentFailure(t *testing.T) {
+	repo := drivenadapters.NewBatchMemoryRepository()
+	publisher := domain.NewMockBatchEventPublisher()
+	publisher.SetShouldFail(true, nil)
+
+	batch := domain.NewBatch("BATCH-2", "product-2")
+	if err := repo.SaveBatchAndEvents(batch, []*domain.BatchEvent{domain.NewBatchCreatedEvent(context.Background(), batch)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	relay := NewRelay(repo, publisher, RelayConfig{
+		PollInterval: time.Millisecond,
+		BatchSize:    10,
+		Retry:        retry.Policy{MaxAttempts: 3, InitialDelay: time.Millisecond},
+	})
+	relay.relayOnce(context.Background())
+
+	lag, err := relay.Lag()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lag != 1 {
+		t.Errorf("expected the row to remain unpublished after a failed publish, got lag %d", lag)
+	}
+}
+
+// TestRelay_SurvivesCrashMidDrain simulates killing the dispatcher partway
+// through a poll - one row publishes, then the process dies before the next
+// row is handled - and a fresh Relay picking the backlog back up afterwards.
+// The outbox's persisted Published flag must mean the first row is never
+// republished, while the second is guaranteed to eventually go out: at
+// least once, never lost.
+func TestRelay_SurvivesCrashMidDrain(t *testing.T) {
+	repo := drivenadapters.NewBatchMemoryRepository()
+	publisher := domain.NewMockBatchEventPublisher()
+
+	firstBatch := domain.NewBatch("BATCH-3", "product-3")
+	if err := repo.SaveBatchAndEvents(firstBatch, []*domain.BatchEvent{domain.NewBatchCreatedEvent(context.Background(), firstBatch)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// "Kill" the dispatcher before the second row is ever saved, as if the
+	// process died right after the first row's relayOnce iteration.
+	relay := NewRelay(repo, publisher, RelayConfig{PollInterval: time.Millisecond, BatchSize: 10})
+	relay.relayOnce(context.Background())
+
+	if publisher.GetEventCount() != 1 {
+		t.Fatalf("expected the first row to have published before the simulated crash, got %d", publisher.GetEventCount())
+	}
+
+	secondBatch := domain.NewBatch("BATCH-4", "product-4")
+	if err := repo.SaveBatchAndEvents(secondBatch, []*domain.BatchEvent{domain.NewBatchCreatedEvent(context.Background(), secondBatch)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A fresh Relay, as a restarted dispatcher process would construct,
+	// picking up against the same (persisted) repository.
+	restarted := NewRelay(repo, publisher, RelayConfig{PollInterval: time.Millisecond, BatchSize: 10})
+	restarted.relayOnce(context.Background())
+
+	if publisher.GetEventCount() != 2 {
+		t.Fatalf("expected the second row to publish after restart without losing it, got %d total published events", publisher.GetEventCount())
+	}
+
+	// The row published before the crash must not have been republished.
+	publishedBatchIDs := make(map[string]int)
+	for _, event := range publisher.GetPublishedEvents() {
+		publishedBatchIDs[event.BatchID]++
+	}
+	if publishedBatchIDs["BATCH-3"] != 1 {
+		t.Errorf("expected BATCH-3's event to have been published exactly once, got %d", publishedBatchIDs["BATCH-3"])
+	}
+	if publishedBatchIDs["BATCH-4"] != 1 {
+		t.Errorf("expected BATCH-4's event to have been published exactly once, got %d", publishedBatchIDs["BATCH-4"])
+	}
+
+	lag, err := restarted.Lag()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lag != 0 {
+		t.Errorf("expected no backlog left after the restarted relay caught up, got lag %d", lag)
+	}
+}
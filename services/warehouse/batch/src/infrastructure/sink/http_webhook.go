@@ -0,0 +1,102 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/retry"
+)
+
+// HTTPWebhookConfig configures HTTPWebhookSink.
+type HTTPWebhookConfig struct {
+	// URL is the webhook endpoint every payload is POSTed to.
+	URL string
+	// Timeout bounds a single POST attempt.
+	Timeout time.Duration
+	// Retry governs retries of a failed POST (5xx or transport error); a
+	// non-2xx 4xx response is treated as terminal, since retrying it won't
+	// help. Zero value disables retries (a single attempt is made).
+	Retry retry.Policy
+}
+
+// HTTPWebhookSink POSTs each payload to a configured URL, retrying
+// transient failures with backoff per config.Retry. topic and key are
+// carried as headers rather than folded into the body, so payload reaches
+// the webhook byte-for-byte identical to what a Kafka/MQTT sink would send.
+type HTTPWebhookSink struct {
+	config HTTPWebhookConfig
+	client *http.Client
+}
+
+// NewHTTPWebhookSink returns an HTTPWebhookSink POSTing to config.URL.
+func NewHTTPWebhookSink(config HTTPWebhookConfig) *HTTPWebhookSink {
+	return &HTTPWebhookSink{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Publish POSTs payload to s.config.URL, retrying per s.config.Retry. A
+// non-2xx response is classified Retryable for 5xx status codes (the
+// webhook may recover) and Terminal for anything else.
+func (s *HTTPWebhookSink) Publish(ctx context.Context, topic, key string, payload []byte) error {
+	policy := s.config.Retry
+	if policy.Classify == nil {
+		policy.Classify = classifyWebhookError
+	}
+	if policy.MaxAttempts == 0 && policy.InitialDelay == 0 {
+		policy.MaxAttempts = 1
+	}
+
+	return retry.Do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("sink: failed to build webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Sink-Topic", topic)
+		req.Header.Set("X-Sink-Key", key)
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("sink: webhook request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		return &webhookStatusError{statusCode: resp.StatusCode}
+	}, policy)
+}
+
+// Close is a no-op: HTTPWebhookSink's client needs no explicit teardown.
+func (s *HTTPWebhookSink) Close() error {
+	return nil
+}
+
+// webhookStatusError reports a non-2xx webhook response.
+type webhookStatusError struct {
+	statusCode int
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("sink: webhook responded with status %d", e.statusCode)
+}
+
+// classifyWebhookError retries 5xx responses and transport errors, but
+// treats a 4xx response as terminal: the request itself is malformed or
+// rejected, and retrying an unchanged payload won't help.
+func classifyWebhookError(err error) retry.Classification {
+	statusErr, ok := err.(*webhookStatusError)
+	if !ok {
+		return retry.Retryable
+	}
+	if statusErr.statusCode >= 400 && statusErr.statusCode < 500 {
+		return retry.Terminal
+	}
+	return retry.Retryable
+}
@@ -0,0 +1,84 @@
+package sink
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/retry"
+)
+
+func TestHTTPWebhookSink_Publish_SucceedsOn2xx(t *testing.T) {
+	var gotBody []byte
+	var gotTopic, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotTopic = r.Header.Get("X-Sink-Topic")
+		gotKey = r.Header.Get("X-Sink-Key")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := NewHTTPWebhookSink(HTTPWebhookConfig{URL: server.URL, Timeout: time.Second})
+
+	if err := s.Publish(context.Background(), "batches/prod-1/batch.created", "batch-1", []byte("payload")); err != nil {
+		t.Fatalf("Publish() returned error: %v", err)
+	}
+	if string(gotBody) != "payload" {
+		t.Errorf("server received body %q, want %q", gotBody, "payload")
+	}
+	if gotTopic != "batches/prod-1/batch.created" || gotKey != "batch-1" {
+		t.Errorf("server received topic=%q key=%q, want topic=%q key=%q", gotTopic, gotKey, "batches/prod-1/batch.created", "batch-1")
+	}
+}
+
+func TestHTTPWebhookSink_Publish_RetriesOn5xxThenSucceeds(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := NewHTTPWebhookSink(HTTPWebhookConfig{
+		URL:     server.URL,
+		Timeout: time.Second,
+		Retry:   retry.Policy{MaxAttempts: 3, InitialDelay: time.Millisecond},
+	})
+
+	if err := s.Publish(context.Background(), "topic", "key", []byte("payload")); err != nil {
+		t.Fatalf("Publish() returned error: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("server saw %d attempts, want 2", attempts)
+	}
+}
+
+func TestHTTPWebhookSink_Publish_DoesNotRetryOn4xx(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	s := NewHTTPWebhookSink(HTTPWebhookConfig{
+		URL:     server.URL,
+		Timeout: time.Second,
+		Retry:   retry.Policy{MaxAttempts: 3, InitialDelay: time.Millisecond},
+	})
+
+	if err := s.Publish(context.Background(), "topic", "key", []byte("payload")); err == nil {
+		t.Fatal("expected an error for a 400 response")
+	}
+	if attempts != 1 {
+		t.Errorf("server saw %d attempts, want 1 (4xx should not be retried)", attempts)
+	}
+}
@@ -0,0 +1,51 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig configures KafkaSink.
+type KafkaConfig struct {
+	// Brokers is the list of seed broker addresses.
+	Brokers []string
+}
+
+// KafkaSink writes each payload to Kafka with kafka-go's default writer
+// settings. It does NOT replace drivenadapters.BatchEventPublisherAdapter,
+// which remains the service's production Kafka egress and keeps the
+// self-heal/reconnect/auth/schema-validation machinery a generic Sink has
+// no business owning; KafkaSink exists only so "kafka" is a first-class
+// sink.Sink alongside the other transports, for callers that genuinely want
+// the minimal interface (e.g. a future fan-out destination with no need for
+// the production adapter's extras).
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink returns a KafkaSink seeded with config.Brokers. The topic is
+// supplied per Publish call rather than fixed at construction, since
+// kafka.Writer resolves it per-message when Topic is left unset.
+func NewKafkaSink(config KafkaConfig) *KafkaSink {
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(config.Brokers...),
+			Balancer: &kafka.LeastBytes{},
+		},
+	}
+}
+
+// Publish writes payload as a single Kafka message to topic, keyed by key.
+func (s *KafkaSink) Publish(ctx context.Context, topic, key string, payload []byte) error {
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Topic: topic,
+		Key:   []byte(key),
+		Value: payload,
+	})
+}
+
+// Close releases the underlying kafka.Writer's connections.
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,75 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// MQTTConfig configures MQTTSink.
+type MQTTConfig struct {
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+	QoS      byte
+	Retained bool
+}
+
+// MQTTSink publishes each payload to an MQTT topic via the same paho client
+// library the sensor-side MQTT generator and
+// publisher/mqtt.Publisher already use. It is a protocol-only building
+// block: publisher/mqtt.Publisher remains the production
+// domain.BatchEventPublisher for the "mqtt" target, since it already wraps
+// events in the CloudEvents structured envelope and resolves topics from
+// TopicTemplate placeholders - concerns a generic Sink deliberately leaves
+// to its caller. MQTTSink is for callers that just want "publish these
+// bytes to this MQTT topic" behind the sink.Sink interface.
+type MQTTSink struct {
+	client paho.Client
+	qos    byte
+	retain bool
+}
+
+// NewMQTTSink connects to config.Broker and returns an MQTTSink.
+func NewMQTTSink(config MQTTConfig) (*MQTTSink, error) {
+	opts := paho.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(config.ClientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetConnectTimeout(10 * time.Second)
+
+	client := paho.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("sink: failed to connect to MQTT broker %s: %w", config.Broker, token.Error())
+	}
+
+	return &MQTTSink{client: client, qos: config.QoS, retain: config.Retained}, nil
+}
+
+// Publish publishes payload to topic; key is unused, since MQTT has no
+// notion of a message key.
+func (s *MQTTSink) Publish(ctx context.Context, topic, key string, payload []byte) error {
+	token := s.client.Publish(topic, s.qos, s.retain, payload)
+	done := make(chan struct{})
+	go func() {
+		token.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return token.Error()
+	}
+}
+
+// Close disconnects the underlying MQTT client.
+func (s *MQTTSink) Close() error {
+	s.client.Disconnect(250)
+	return nil
+}
@@ -0,0 +1,20 @@
+// Package sink defines a minimal, transport-agnostic fan-out destination
+// for raw event payloads. It exists alongside, not instead of, the
+// specialized domain.BatchEventPublisher adapters this service already has
+// (Kafka's drivenadapters.BatchEventPublisherAdapter with its
+// reconnect/self-heal/schema-validation machinery, and MQTT's
+// publisher/mqtt.Publisher) - a generic Sink has no business knowing about
+// any of that. New destinations that don't need it (an HTTP webhook,
+// stdout for local dev) are implemented here instead and wrapped into a
+// domain.BatchEventPublisher by drivenadapters.NewSinkBatchEventPublisher,
+// so the application/domain layers never see the difference.
+package sink
+
+import "context"
+
+// Sink publishes one payload to topic, addressed by key (e.g. for
+// partitioning or correlation - not every implementation uses it).
+type Sink interface {
+	Publish(ctx context.Context, topic, key string, payload []byte) error
+	Close() error
+}
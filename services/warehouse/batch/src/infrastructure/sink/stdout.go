@@ -0,0 +1,32 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// StdoutSink writes every payload to an io.Writer (os.Stdout by default),
+// one line per publish, for local development when no real broker is
+// running.
+type StdoutSink struct {
+	out io.Writer
+}
+
+// NewStdoutSink returns a StdoutSink writing to os.Stdout.
+func NewStdoutSink() *StdoutSink {
+	return &StdoutSink{out: os.Stdout}
+}
+
+// Publish writes "topic key payload" as a single line, returning any error
+// from the underlying writer.
+func (s *StdoutSink) Publish(ctx context.Context, topic, key string, payload []byte) error {
+	_, err := fmt.Fprintf(s.out, "%s %s %s\n", topic, key, payload)
+	return err
+}
+
+// Close is a no-op: StdoutSink owns nothing that needs releasing.
+func (s *StdoutSink) Close() error {
+	return nil
+}
@@ -0,0 +1,21 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestStdoutSink_Publish_WritesTopicKeyPayload(t *testing.T) {
+	var buf bytes.Buffer
+	s := &StdoutSink{out: &buf}
+
+	if err := s.Publish(context.Background(), "batches/prod-1/batch.created", "batch-1", []byte(`{"ok":true}`)); err != nil {
+		t.Fatalf("Publish() returned error: %v", err)
+	}
+
+	want := "batches/prod-1/batch.created batch-1 {\"ok\":true}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Publish() wrote %q, want %q", got, want)
+	}
+}
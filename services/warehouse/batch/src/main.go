@@ -1,89 +1,359 @@
-package main
-
-import (
-	"context"
-	"log"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
-
-	"github.com/joho/godotenv"
-	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/application"
-	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/config"
-	drivenadapters "github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/driven-adapters"
-	drivingadapters "github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/driving-adapters"
-)
-
-func main() {
-	log.Println("Starting warehouse batch application...")
-
-	// Load environment variables from .env file
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: Could not load .env file: %v", err)
-	}
-
-	// Load configuration from environment variables
-	cfg := config.LoadConfig()
-	log.Printf("Configuration - Order Events Topic: %s, Batch Events Topic: %s, Group ID: %s, Broker: %s, HTTP Port: %s", 
-		cfg.Kafka.OrderEventsTopic, cfg.Kafka.BatchEventsTopic, cfg.Kafka.GroupID, cfg.Kafka.BrokerAddress, cfg.HTTP.Port)
-
-	// Create a context that can be cancelled
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Initialize driven adapters (repositories and event publishers)
-	batchRepo := drivenadapters.NewBatchMemoryRepository()
-	batchEventPublisher := drivenadapters.NewBatchEventPublisherAdapter(
-		cfg.Kafka.BrokerAddress,
-		cfg.Kafka.BatchEventsTopic,
-	)
-	
-	// Initialize application layer (business logic)
-	batchService := application.NewBatchService(batchRepo, batchEventPublisher)
-	orderService := application.NewOrderService(batchService)
-
-	// Initialize driving adapters
-	// OrderEventConsumerAdapter for order events processing
-	orderEventConsumerAdapter := drivingadapters.NewOrderEventConsumerAdapter(
-		cfg.Kafka.BrokerAddress,
-		cfg.Kafka.OrderEventsTopic,
-		cfg.Kafka.GroupID,
-		orderService,
-	)
-	
-	// ApiServiceAdapter for synchronous HTTP requests
-	apiServiceAdapter := drivingadapters.NewApiServiceAdapter(cfg.HTTP.Port, batchService)
-
-	// Start the order event consumer adapter in a goroutine
-	go orderEventConsumerAdapter.Start(ctx)
-
-	// Start the HTTP API service adapter in a goroutine
-	go apiServiceAdapter.Start(ctx)
-
-	// Set up graceful shutdown
-	setupGracefulShutdown(cancel, batchEventPublisher)
-
-	log.Println("Application shut down gracefully.")
-}
-
-// setupGracefulShutdown handles OS signals for graceful shutdown
-func setupGracefulShutdown(cancel context.CancelFunc, batchEventPublisher *drivenadapters.BatchEventPublisherAdapter) {
-	sigchan := make(chan os.Signal, 1)
-	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
-
-	// Block until a signal is received
-	<-sigchan
-	log.Println("Shutdown signal received, cancelling context...")
-
-	// Cancel the context to signal goroutines to stop
-	cancel()
-
-	// Close the event publisher
-	if err := batchEventPublisher.Close(); err != nil {
-		log.Printf("Error closing batch event publisher: %v", err)
-	}
-
-	// Give goroutines a moment to clean up
-	time.Sleep(2 * time.Second)
-}
\ No newline at end of file
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/application"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/application/saga"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/config"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/domain/schema"
+	drivenadapters "github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/driven-adapters"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/driven-adapters/kafkaadmin"
+	mqttpublisher "github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/driven-adapters/publisher/mqtt"
+	drivingadapters "github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/driving-adapters"
+	consumerkafka "github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/kafka/consumer"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/messaging"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/outbox"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/infrastructure/sink"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/eventschema"
+	"github.com/MATI-MBIT/arqnewgen-medisupply-eda/simple-service/batch/src/pkg/retry"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	log.Println("Starting warehouse batch application...")
+
+	reconcileTopics := flag.Bool("reconcile-topics", false, "alter existing topic configs that have drifted from the requested spec")
+	flag.Parse()
+
+	// Load environment variables from .env file
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: Could not load .env file: %v", err)
+	}
+
+	// Load configuration from environment variables
+	cfg := config.LoadConfig()
+	log.Printf("Configuration - Order Events Topic: %s, Batch Events Topic: %s, Group ID: %s, Broker: %s, HTTP Port: %s",
+		cfg.Kafka.OrderEventsTopic, cfg.Kafka.BatchEventsTopic, cfg.Kafka.GroupID, cfg.Kafka.BrokerAddress, cfg.HTTP.Port)
+
+	// Create a context that can be cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Ensure the topics this service depends on exist before consuming or
+	// producing, so the pod fails fast with a clear error instead of
+	// spamming UnknownTopicOrPartition on a missing broker/topic.
+	bootstrapper := kafkaadmin.NewBootstrapper(cfg.Kafka.BrokerAddress, *reconcileTopics)
+	bootstrapCtx, bootstrapCancel := context.WithTimeout(ctx, 30*time.Second)
+	defer bootstrapCancel()
+	orderEventsTopicSpecs := []kafkaadmin.TopicSpec{
+		{Name: cfg.Kafka.OrderEventsTopic, NumPartitions: 3, ReplicationFactor: 1, RetentionMs: 7 * 24 * 60 * 60 * 1000},
+		{Name: cfg.Kafka.BatchEventsTopic, NumPartitions: 3, ReplicationFactor: 1, RetentionMs: 7 * 24 * 60 * 60 * 1000},
+		{Name: cfg.Kafka.DeadLetterTopic, NumPartitions: 1, ReplicationFactor: 1, RetentionMs: 7 * 24 * 60 * 60 * 1000},
+		{Name: cfg.Kafka.DLQTopic, NumPartitions: 1, ReplicationFactor: 1, RetentionMs: 7 * 24 * 60 * 60 * 1000},
+	}
+	// One retry topic per escalation level, so a handler failure on
+	// OrderEventsTopic has somewhere to land the moment it occurs instead of
+	// failing the write against a topic that doesn't exist yet.
+	for _, retryTopic := range consumerkafka.RetryTopicsFor(cfg.Kafka.OrderEventsTopic, cfg.Kafka.MaxRetries) {
+		orderEventsTopicSpecs = append(orderEventsTopicSpecs, kafkaadmin.TopicSpec{Name: retryTopic, NumPartitions: 1, ReplicationFactor: 1, RetentionMs: 24 * 60 * 60 * 1000})
+	}
+	if err := bootstrapper.Ensure(bootstrapCtx, orderEventsTopicSpecs); err != nil {
+		log.Fatalf("Failed to bootstrap Kafka topics: %v", err)
+	}
+
+	// schemaCodec wraps published/consumed events in the Confluent Schema
+	// Registry wire format when cfg.SchemaRegistry.URL is set, falling back
+	// to a PassthroughCodec (today's plain-JSON wire format) otherwise. See
+	// domain/schema's package doc for why this isn't binary Avro/Protobuf.
+	// Built once here so the producer (newBatchEventPublisher) and the
+	// consumer (codecs below) share the same RegistryClient cache.
+	schemaCodec, schemaCodecEnabled := schema.NewCodec(cfg.SchemaRegistry.URL)
+
+	// Initialize driven adapters (repositories and event publishers)
+	batchRepo := drivenadapters.NewBatchMemoryRepository()
+	batchEventPublisher, batchEventPublisherCloser, err := newBatchEventPublisher(cfg, bootstrapper, schemaCodec, schemaCodecEnabled)
+	if err != nil {
+		log.Fatalf("Failed to initialize batch event publisher(s): %v", err)
+	}
+	deadLetterWriter := drivenadapters.NewDeadLetterWriter(cfg.Kafka.BrokerAddress, cfg.Kafka.DeadLetterTopic)
+
+	// Initialize application layer (business logic)
+	policyRepo := drivenadapters.NewPolicyMemoryRepository()
+	// batchRepo doubles as the domain.OutboxRepository: BatchService saves a
+	// batch mutation and its outgoing events atomically against it, and the
+	// relay below is the only thing that actually talks to Kafka, so a
+	// broker outage delays publishing instead of losing the event.
+	batchService := application.NewBatchService(batchRepo, batchEventPublisher, batchRepo)
+	outboxRelay := outbox.NewRelay(batchRepo, batchEventPublisher, outbox.RelayConfig{
+		PollInterval: 2 * time.Second,
+		BatchSize:    100,
+		Retry: retry.Policy{
+			MaxAttempts:  5,
+			InitialDelay: 500 * time.Millisecond,
+			MaxDelay:     10 * time.Second,
+			Multiplier:   2,
+			Jitter:       0.2,
+		},
+	})
+	go outboxRelay.Start(ctx)
+	batchAggregator := application.NewBatchAggregator(batchService, application.BatchAggregatorConfig{
+		MaxItemsPerBatch:    cfg.Batching.MaxItemsPerBatch,
+		MaxQuantityPerBatch: cfg.Batching.MaxQuantityPerBatch,
+		BatchTimeout:        cfg.Batching.BatchTimeout,
+		CheckInterval:       cfg.Batching.CheckInterval,
+	}, policyRepo)
+	defer batchAggregator.Stop()
+
+	// Backstop scan for products whose per-line watch goroutine isn't
+	// running yet (e.g. right after a restart), so a configured MaxAge or
+	// CutoffCron is still honored.
+	go batchAggregator.ScanPolicies(ctx, time.Minute)
+
+	// The allocate_inventory saga coordinates the order-created -> batch
+	// allocation flow, compensating by removing the order from its batch if
+	// the allocation can't be confirmed. process_damage does the same for
+	// major damage processing, compensating a failed MarkBatchAsDamaged
+	// instead of leaving the order in an unflagged damage_major batch.
+	sagaRepo := drivenadapters.NewSagaMemoryRepository()
+	allocateInventorySaga := application.NewAllocateInventorySagaDefinition(batchAggregator)
+	processDamageSaga := application.NewProcessDamageSagaDefinition(batchAggregator)
+	sagaOrchestrator := saga.NewOrchestrator(sagaRepo, batchEventPublisher, []saga.Definition{allocateInventorySaga, processDamageSaga}, nil)
+
+	orderService := application.NewOrderService(batchAggregator, sagaOrchestrator)
+
+	// Initialize driving adapters
+	// codecs decodes order event payloads regardless of which broker they
+	// arrived over: plain JSON (legacy), a CloudEvents 1.0 envelope, or a
+	// Schema Registry-wrapped payload (if a producer tagged it with
+	// messaging.SchemaRegistryContentType).
+	codecs := messaging.NewRegistry(messaging.JSONCodec{}, messaging.JSONCodec{}, messaging.CloudEventsCodec{}, messaging.NewSchemaRegistryCodec(schemaCodec))
+
+	// orderEventLedger short-circuits redelivered order events (Kafka/AMQP
+	// at-least-once redelivery after a crash) so they aren't re-applied to a
+	// batch twice; shared by both adapters below since they consume events
+	// keyed by the same (EventType, OrderID, Timestamp) space.
+	orderEventLedger := drivenadapters.NewMemoryProcessedEventLedger()
+
+	// OrderEventConsumerAdapter for order events processing. The source is
+	// selected by ORDER_EVENTS_SOURCE so the same adapter code can run
+	// against Kafka or RabbitMQ.
+	orderEventsSource, err := newOrderEventsSource(cfg)
+	if err != nil {
+		log.Fatalf("Failed to start order events source: %v", err)
+	}
+	orderEventConsumerAdapter := drivingadapters.NewOrderEventConsumerAdapter(
+		orderEventsSource,
+		codecs,
+		orderService,
+		nil,
+		drivingadapters.CommitMode(cfg.Kafka.ConsumerCommitMode),
+		nil,
+		deadLetterWriter,
+		orderEventLedger,
+	)
+
+	// A second adapter consumes damage events from RabbitMQ independently of
+	// wherever order events come from, so damage processing is never gated
+	// on the Kafka consumer group catching up. Both route to the same
+	// application-layer handler.
+	damageEventsSource, err := messaging.NewAMQPSource(cfg.RabbitMQ.URL, cfg.RabbitMQ.ExchangeName, cfg.RabbitMQ.QueueName, cfg.RabbitMQ.RoutingKey, cfg.RabbitMQ.Retry)
+	if err != nil {
+		log.Fatalf("Failed to start damage events source: %v", err)
+	}
+	damageEventConsumerAdapter := drivingadapters.NewOrderEventConsumerAdapter(
+		damageEventsSource,
+		codecs,
+		orderService,
+		drivingadapters.HandlerRegistry{"process_damage": orderService.HandleOrderEvent},
+		drivingadapters.CommitMode(cfg.Kafka.ConsumerCommitMode),
+		nil,
+		deadLetterWriter,
+		orderEventLedger,
+	)
+
+	// Readiness probe: when order events are Kafka-backed, blocks /readyz
+	// until the order-events consumer group has caught up to the backlog
+	// each partition had at startup, so Kubernetes doesn't route traffic to
+	// a pod that's still replaying events that arrived while it was down.
+	// AMQP-backed order events have no consumer-group offset concept to
+	// check, so ready is left nil (always ready) in that case.
+	var ready <-chan struct{}
+	if cfg.Kafka.OrderEventsSource == "kafka" || cfg.Kafka.OrderEventsSource == "" {
+		offsetChecker := drivingadapters.NewConsumerGroupOffsetChecker(cfg.Kafka.BrokerAddress, cfg.Kafka.OrderEventsTopic, cfg.Kafka.GroupID, cfg.Readiness.Timeout)
+		offsetChecker.Start(ctx)
+		ready = offsetChecker.Ready()
+	}
+
+	// ApiServiceAdapter for synchronous HTTP requests
+	kafkaTopics := []string{cfg.Kafka.OrderEventsTopic, cfg.Kafka.BatchEventsTopic, cfg.Kafka.DeadLetterTopic, cfg.Kafka.DLQTopic}
+
+	// DLQ replay is only meaningful when order events actually flow through
+	// Kafka; AMQP-backed order events dead-letter through a different path.
+	var dlqReplayer *consumerkafka.DLQReplayer
+	if cfg.Kafka.OrderEventsSource == "kafka" || cfg.Kafka.OrderEventsSource == "" {
+		dlqReplayer = consumerkafka.NewDLQReplayer(cfg.Kafka.BrokerAddress, cfg.Kafka.DLQTopic, cfg.Kafka.OrderEventsTopic)
+	}
+
+	apiServiceAdapter := drivingadapters.NewApiServiceAdapter(cfg.HTTP.Port, batchService, sagaRepo, sagaOrchestrator, policyRepo, batchRepo, ready, bootstrapper, kafkaTopics, []*drivingadapters.OrderEventConsumerAdapter{orderEventConsumerAdapter, damageEventConsumerAdapter}, dlqReplayer)
+
+	// Start the order event consumer adapters in goroutines
+	go orderEventConsumerAdapter.Start(ctx)
+	go damageEventConsumerAdapter.Start(ctx)
+
+	// Start the HTTP API service adapter in a goroutine
+	go apiServiceAdapter.Start(ctx)
+
+	// Set up graceful shutdown
+	setupGracefulShutdown(cancel, batchEventPublisherCloser, deadLetterWriter, dlqReplayer)
+
+	log.Println("Application shut down gracefully.")
+}
+
+// newBatchEventPublisher builds the domain.BatchEventPublisher the rest of
+// the application publishes batch events through, selected by
+// cfg.Publishing.Targets ("kafka", "mqtt", "httpwebhook", "stdout", or any
+// combination). The returned io.Closer is the same value when exactly one
+// target is configured, or a drivenadapters.MultiBatchEventPublisher fanning
+// out to all of them otherwise, so main always has exactly one thing to
+// close on shutdown regardless of how many destinations are active.
+func newBatchEventPublisher(cfg *config.Config, bootstrapper *kafkaadmin.Bootstrapper, schemaCodec schema.Codec, schemaCodecEnabled bool) (domain.BatchEventPublisher, io.Closer, error) {
+	var publishers []drivenadapters.ClosableBatchEventPublisher
+
+	for _, target := range cfg.Publishing.Targets {
+		switch target {
+		case "kafka":
+			publishers = append(publishers, drivenadapters.NewBatchEventPublisherAdapter(
+				cfg.Kafka.BrokerAddress,
+				cfg.Kafka.BatchEventsTopic,
+				cfg.Kafka.CloudEventsMode,
+				cfg.Kafka.Auth,
+				cfg.Kafka.PublisherOptions,
+				cfg.Kafka.Reconnect,
+				drivenadapters.SelfHealConfig{
+					Admin:            bootstrapper,
+					AutoCreateTopics: cfg.Kafka.AutoCreateTopics,
+					TopicSpec:        kafkaadmin.TopicSpec{Name: cfg.Kafka.BatchEventsTopic, NumPartitions: 3, ReplicationFactor: 1, RetentionMs: 7 * 24 * 60 * 60 * 1000},
+				},
+				batchEventSchemas(),
+				schemaCodec,
+				schemaCodecEnabled,
+			))
+		case "mqtt":
+			mqttPublisher, err := mqttpublisher.NewPublisher(cfg.MQTT)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to initialize MQTT batch event publisher: %w", err)
+			}
+			publishers = append(publishers, mqttPublisher)
+		case "httpwebhook":
+			webhook := sink.NewHTTPWebhookSink(sink.HTTPWebhookConfig{
+				URL:     cfg.SinkHTTPWebhook.URL,
+				Timeout: cfg.SinkHTTPWebhook.Timeout,
+				Retry:   cfg.SinkHTTPWebhook.Retry,
+			})
+			publishers = append(publishers, drivenadapters.NewSinkBatchEventPublisher(webhook, cfg.SinkHTTPWebhook.TopicTemplate))
+		case "stdout":
+			publishers = append(publishers, drivenadapters.NewSinkBatchEventPublisher(sink.NewStdoutSink(), "batches/{product_id}/{event_type}"))
+		default:
+			return nil, nil, fmt.Errorf("unknown BATCH_EVENT_PUBLISHERS target %q, expected one of \"kafka\", \"mqtt\", \"httpwebhook\", \"stdout\"", target)
+		}
+	}
+
+	switch len(publishers) {
+	case 0:
+		return nil, nil, fmt.Errorf("no BATCH_EVENT_PUBLISHERS targets configured")
+	case 1:
+		return publishers[0], publishers[0], nil
+	default:
+		multi := drivenadapters.NewMultiBatchEventPublisher(publishers...)
+		return multi, multi, nil
+	}
+}
+
+// batchEventSchemas registers the required top-level fields for every
+// domain.BatchEventType, so BatchEventPublisherAdapter rejects a malformed
+// event locally instead of letting it reach a consumer.
+func batchEventSchemas() *eventschema.Registry {
+	registry := eventschema.NewRegistry()
+	for _, eventType := range []domain.BatchEventType{
+		domain.BatchEventCreated,
+		domain.BatchEventItemAdded,
+		domain.BatchEventItemRemoved,
+		domain.BatchEventItemUpdated,
+		domain.BatchEventProcessing,
+		domain.BatchEventCompleted,
+		domain.BatchEventCancelled,
+		domain.BatchEventDamaged,
+		domain.BatchEventSealedByPolicy,
+	} {
+		registry.Register(eventschema.Schema{
+			EventType: string(eventType),
+			Required:  []string{"event_type", "batch_id", "product_id", "timestamp"},
+		})
+	}
+	return registry
+}
+
+// newOrderEventsSource builds the messaging.MessageSource that backs order
+// event consumption, selected by cfg.Kafka.OrderEventsSource.
+func newOrderEventsSource(cfg *config.Config) (messaging.MessageSource, error) {
+	switch cfg.Kafka.OrderEventsSource {
+	case "amqp":
+		return messaging.NewAMQPSource(cfg.RabbitMQ.URL, cfg.RabbitMQ.ExchangeName, cfg.RabbitMQ.QueueName, cfg.RabbitMQ.RoutingKey, cfg.RabbitMQ.Retry)
+	case "kafka", "":
+		escalator := consumerkafka.NewEscalator(cfg.Kafka.BrokerAddress, consumerkafka.Config{
+			MaxRetries:       cfg.Kafka.MaxRetries,
+			RetryBaseBackoff: cfg.Kafka.RetryBaseBackoff,
+			DLQTopic:         cfg.Kafka.DLQTopic,
+		})
+		return messaging.NewKafkaSource(cfg.Kafka.BrokerAddress, cfg.Kafka.OrderEventsTopic, cfg.Kafka.GroupID, escalator), nil
+	default:
+		return nil, fmt.Errorf("unknown ORDER_EVENTS_SOURCE %q, expected \"kafka\" or \"amqp\"", cfg.Kafka.OrderEventsSource)
+	}
+}
+
+// setupGracefulShutdown handles OS signals for graceful shutdown.
+// batchEventPublisherCloser is an io.Closer rather than a concrete adapter
+// type because newBatchEventPublisher may hand back a
+// drivenadapters.MultiBatchEventPublisher fanning out to more than one
+// destination.
+func setupGracefulShutdown(cancel context.CancelFunc, batchEventPublisherCloser io.Closer, deadLetterWriter *drivenadapters.DeadLetterWriter, dlqReplayer *consumerkafka.DLQReplayer) {
+	sigchan := make(chan os.Signal, 1)
+	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Block until a signal is received
+	<-sigchan
+	log.Println("Shutdown signal received, cancelling context...")
+
+	// Cancel the context to signal goroutines to stop
+	cancel()
+
+	// Close the event publisher(s)
+	if err := batchEventPublisherCloser.Close(); err != nil {
+		log.Printf("Error closing batch event publisher: %v", err)
+	}
+
+	// Close the dead-letter writer
+	if err := deadLetterWriter.Close(); err != nil {
+		log.Printf("Error closing dead-letter writer: %v", err)
+	}
+
+	// Close the DLQ replayer, if one was wired in
+	if dlqReplayer != nil {
+		if err := dlqReplayer.Close(); err != nil {
+			log.Printf("Error closing DLQ replayer: %v", err)
+		}
+	}
+
+	// Give goroutines a moment to clean up
+	time.Sleep(2 * time.Second)
+}
@@ -0,0 +1,104 @@
+// Package cron provides a minimal standard 5-field cron expression matcher,
+// enough for the batching policy's daily cutoff trigger without pulling in a
+// full scheduling library.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed 5-field cron expression (minute hour
+// day-of-month month day-of-week), supporting "*", a single value,
+// comma-separated values, and "*/N" step values in each field.
+type Schedule struct {
+	minute, hour, dom, month, dow field
+}
+
+// field is one of the five positions in a Schedule.
+type field struct {
+	wildcard bool
+	values   map[int]struct{}
+}
+
+// Parse parses a standard 5-field cron expression such as "0 18 * * *"
+// (every day at 18:00).
+func Parse(expr string) (Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return Schedule{}, fmt.Errorf("cron: expected 5 fields, got %d in %q", len(parts), expr)
+	}
+
+	minute, err := parseField(parts[0], 0, 59)
+	if err != nil {
+		return Schedule{}, err
+	}
+	hour, err := parseField(parts[1], 0, 23)
+	if err != nil {
+		return Schedule{}, err
+	}
+	dom, err := parseField(parts[2], 1, 31)
+	if err != nil {
+		return Schedule{}, err
+	}
+	month, err := parseField(parts[3], 1, 12)
+	if err != nil {
+		return Schedule{}, err
+	}
+	dow, err := parseField(parts[4], 0, 6)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	return Schedule{minute: minute, hour: hour, dom: dom, month: month, dow: dow}, nil
+}
+
+// parseField parses one "*", "N", "N,M,..." or "*/N" field, validating each
+// value falls within [min, max].
+func parseField(raw string, min, max int) (field, error) {
+	if raw == "*" {
+		return field{wildcard: true}, nil
+	}
+
+	values := make(map[int]struct{})
+	for _, part := range strings.Split(raw, ",") {
+		if strings.HasPrefix(part, "*/") {
+			step, err := strconv.Atoi(strings.TrimPrefix(part, "*/"))
+			if err != nil || step <= 0 {
+				return field{}, fmt.Errorf("cron: invalid step value %q", part)
+			}
+			for v := min; v <= max; v += step {
+				values[v] = struct{}{}
+			}
+			continue
+		}
+
+		v, err := strconv.Atoi(part)
+		if err != nil || v < min || v > max {
+			return field{}, fmt.Errorf("cron: invalid value %q (expected %d-%d)", part, min, max)
+		}
+		values[v] = struct{}{}
+	}
+
+	return field{values: values}, nil
+}
+
+// matches reports whether v satisfies this field.
+func (f field) matches(v int) bool {
+	if f.wildcard {
+		return true
+	}
+	_, ok := f.values[v]
+	return ok
+}
+
+// Matches reports whether t falls within the minute named by this schedule.
+func (s Schedule) Matches(t time.Time) bool {
+	return s.minute.matches(t.Minute()) &&
+		s.hour.matches(t.Hour()) &&
+		s.dom.matches(t.Day()) &&
+		s.month.matches(int(t.Month())) &&
+		s.dow.matches(int(t.Weekday()))
+}
@@ -0,0 +1,54 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_RejectsWrongFieldCount(t *testing.T) {
+	if _, err := Parse("0 18 * *"); err == nil {
+		t.Fatal("expected an error for a 4-field expression")
+	}
+}
+
+func TestParse_RejectsOutOfRangeValue(t *testing.T) {
+	if _, err := Parse("0 24 * * *"); err == nil {
+		t.Fatal("expected an error for an hour value out of range")
+	}
+}
+
+func TestSchedule_MatchesExactTime(t *testing.T) {
+	schedule, err := Parse("30 18 * * *")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	match := time.Date(2026, time.March, 5, 18, 30, 0, 0, time.UTC)
+	if !schedule.Matches(match) {
+		t.Errorf("expected %v to match schedule", match)
+	}
+
+	noMatch := time.Date(2026, time.March, 5, 18, 31, 0, 0, time.UTC)
+	if schedule.Matches(noMatch) {
+		t.Errorf("expected %v not to match schedule", noMatch)
+	}
+}
+
+func TestSchedule_StepValue(t *testing.T) {
+	schedule, err := Parse("*/15 * * * *")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, minute := range []int{0, 15, 30, 45} {
+		match := time.Date(2026, time.March, 5, 10, minute, 0, 0, time.UTC)
+		if !schedule.Matches(match) {
+			t.Errorf("expected minute %d to match */15 schedule", minute)
+		}
+	}
+
+	noMatch := time.Date(2026, time.March, 5, 10, 20, 0, 0, time.UTC)
+	if schedule.Matches(noMatch) {
+		t.Error("expected minute 20 not to match */15 schedule")
+	}
+}
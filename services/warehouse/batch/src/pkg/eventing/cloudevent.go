@@ -0,0 +1,131 @@
+// Package eventing implements a minimal CloudEvents 1.0 envelope for events
+// published to and consumed from Kafka using binary content mode: the
+// CloudEvents context attributes travel as "ce_*" message headers and the
+// message value carries only the event's own JSON payload.
+package eventing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// SpecVersion is the CloudEvents spec version this package implements.
+const SpecVersion = "1.0"
+
+// Attributes holds the CloudEvents 1.0 context attributes carried as Kafka
+// binary-mode headers alongside the event payload.
+type Attributes struct {
+	ID              string
+	Source          string
+	SpecVersion     string
+	Type            string
+	Subject         string
+	Time            time.Time
+	DataContentType string
+	// TraceParent is the W3C traceparent propagated from the context that
+	// triggered this event, and may be empty.
+	TraceParent string
+}
+
+// NewAttributes builds the CloudEvents attributes for an event about to be
+// published. ID is freshly generated per emission, as the spec requires.
+func NewAttributes(source, ceType, subject, traceParent string) Attributes {
+	return Attributes{
+		ID:              uuid.New().String(),
+		Source:          source,
+		SpecVersion:     SpecVersion,
+		Type:            ceType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		TraceParent:     traceParent,
+	}
+}
+
+const (
+	headerID              = "ce_id"
+	headerSource          = "ce_source"
+	headerSpecVersion     = "ce_specversion"
+	headerType            = "ce_type"
+	headerSubject         = "ce_subject"
+	headerTime            = "ce_time"
+	headerDataContentType = "content-type"
+	headerTraceParent     = "traceparent"
+)
+
+// Headers renders attrs as Kafka binary-mode CloudEvents headers.
+func (a Attributes) Headers() []kafka.Header {
+	headers := []kafka.Header{
+		{Key: headerID, Value: []byte(a.ID)},
+		{Key: headerSource, Value: []byte(a.Source)},
+		{Key: headerSpecVersion, Value: []byte(a.SpecVersion)},
+		{Key: headerType, Value: []byte(a.Type)},
+		{Key: headerSubject, Value: []byte(a.Subject)},
+		{Key: headerTime, Value: []byte(a.Time.Format(time.RFC3339Nano))},
+		{Key: headerDataContentType, Value: []byte(a.DataContentType)},
+	}
+	if a.TraceParent != "" {
+		headers = append(headers, kafka.Header{Key: headerTraceParent, Value: []byte(a.TraceParent)})
+	}
+	return headers
+}
+
+// AttributesFromHeaders parses CloudEvents binary-mode headers back into
+// Attributes. ok is false if the message carries no ce_specversion header,
+// meaning it predates this package and should be treated as a legacy
+// payload.
+func AttributesFromHeaders(headers []kafka.Header) (attrs Attributes, ok bool) {
+	lookup := make(map[string]string, len(headers))
+	for _, h := range headers {
+		lookup[h.Key] = string(h.Value)
+	}
+
+	specVersion := lookup[headerSpecVersion]
+	if specVersion == "" {
+		return Attributes{}, false
+	}
+
+	eventTime, err := time.Parse(time.RFC3339Nano, lookup[headerTime])
+	if err != nil {
+		eventTime = time.Time{}
+	}
+
+	return Attributes{
+		ID:              lookup[headerID],
+		Source:          lookup[headerSource],
+		SpecVersion:     specVersion,
+		Type:            lookup[headerType],
+		Subject:         lookup[headerSubject],
+		Time:            eventTime,
+		DataContentType: lookup[headerDataContentType],
+		TraceParent:     lookup[headerTraceParent],
+	}, true
+}
+
+// ValidationError reports a CloudEvents attribute that failed the minimal
+// 1.0 spec requirement that id, source, specversion and type are mandatory.
+type ValidationError struct {
+	Attribute string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("cloudevents: missing required attribute %q", e.Attribute)
+}
+
+// Validate checks that the mandatory CloudEvents 1.0 attributes are set.
+func (a Attributes) Validate() error {
+	switch {
+	case a.ID == "":
+		return &ValidationError{Attribute: "id"}
+	case a.Source == "":
+		return &ValidationError{Attribute: "source"}
+	case a.SpecVersion == "":
+		return &ValidationError{Attribute: "specversion"}
+	case a.Type == "":
+		return &ValidationError{Attribute: "type"}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package eventing
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestAttributes_HeadersRoundTrip(t *testing.T) {
+	attrs := NewAttributes("warehouse-batch-service", "com.medisupply.batch.created", "batch-123", "00-trace-01")
+
+	headers := attrs.Headers()
+	parsed, ok := AttributesFromHeaders(headers)
+	if !ok {
+		t.Fatal("expected AttributesFromHeaders to recognize the ce_specversion header")
+	}
+
+	if parsed.ID != attrs.ID {
+		t.Errorf("expected ID %q, got %q", attrs.ID, parsed.ID)
+	}
+	if parsed.Source != attrs.Source {
+		t.Errorf("expected Source %q, got %q", attrs.Source, parsed.Source)
+	}
+	if parsed.SpecVersion != SpecVersion {
+		t.Errorf("expected SpecVersion %q, got %q", SpecVersion, parsed.SpecVersion)
+	}
+	if parsed.Type != attrs.Type {
+		t.Errorf("expected Type %q, got %q", attrs.Type, parsed.Type)
+	}
+	if parsed.Subject != attrs.Subject {
+		t.Errorf("expected Subject %q, got %q", attrs.Subject, parsed.Subject)
+	}
+	if parsed.TraceParent != attrs.TraceParent {
+		t.Errorf("expected TraceParent %q, got %q", attrs.TraceParent, parsed.TraceParent)
+	}
+	if !parsed.Time.Equal(attrs.Time) {
+		t.Errorf("expected Time %v, got %v", attrs.Time, parsed.Time)
+	}
+}
+
+func TestAttributesFromHeaders_LegacyMessageIsNotRecognized(t *testing.T) {
+	headers := []kafka.Header{
+		{Key: "event_type", Value: []byte("batch.created")},
+		{Key: "batch_id", Value: []byte("batch-123")},
+	}
+
+	_, ok := AttributesFromHeaders(headers)
+	if ok {
+		t.Fatal("expected a legacy message without ce_specversion to not be recognized as CloudEvents")
+	}
+}
+
+func TestAttributes_Validate(t *testing.T) {
+	valid := NewAttributes("warehouse-batch-service", "com.medisupply.batch.created", "batch-123", "")
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected valid attributes to pass validation, got %v", err)
+	}
+
+	invalid := valid
+	invalid.ID = ""
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected missing ID to fail validation")
+	}
+}
@@ -0,0 +1,75 @@
+// Package eventschema is a small in-repo registry of the fields each
+// published event type must carry, consulted by a publisher before it sends
+// a message so a producer bug (e.g. a renamed or forgotten field) fails
+// locally with a clear error instead of reaching a consumer as a payload it
+// can't parse. It is not a JSON Schema (draft 2020-12) implementation - no
+// $ref, no nested subschemas, no format/type validators, no external
+// dependency - just the "required top-level fields present and non-empty"
+// check a schema most commonly exists to enforce here, which is all the
+// publishers in this repo need validated before a publish.
+package eventschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema describes the top-level fields a published event's JSON payload
+// must carry.
+type Schema struct {
+	EventType string
+	Required  []string
+}
+
+// Registry maps an event type to the Schema its payloads must satisfy.
+type Registry struct {
+	schemas map[string]Schema
+}
+
+// NewRegistry returns an empty Registry. Register schemas onto it before use.
+func NewRegistry() *Registry {
+	return &Registry{schemas: make(map[string]Schema)}
+}
+
+// Register adds schema to the registry, replacing any schema already
+// registered for the same EventType.
+func (r *Registry) Register(schema Schema) {
+	r.schemas[schema.EventType] = schema
+}
+
+// Validate checks payload (expected to be a JSON object) against the schema
+// registered for eventType. An eventType with no registered schema passes
+// unchecked, so the registry can be populated incrementally without
+// blocking publish of event types it doesn't know about yet.
+func (r *Registry) Validate(eventType string, payload []byte) error {
+	schema, ok := r.schemas[eventType]
+	if !ok {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return fmt.Errorf("eventschema: payload for %q is not a JSON object: %w", eventType, err)
+	}
+
+	var missing []string
+	for _, field := range schema.Required {
+		raw, present := fields[field]
+		if !present || isEmptyJSON(raw) {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("eventschema: event %q missing required field(s): %s", eventType, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// isEmptyJSON reports whether raw is the JSON encoding of "" or null, the
+// two zero-value shapes a required string/optional-pointer field would take
+// when a producer forgot to set it.
+func isEmptyJSON(raw json.RawMessage) bool {
+	trimmed := strings.TrimSpace(string(raw))
+	return trimmed == `""` || trimmed == "null"
+}
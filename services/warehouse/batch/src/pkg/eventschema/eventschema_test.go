@@ -0,0 +1,51 @@
+package eventschema
+
+import "testing"
+
+func TestValidate_UnknownEventTypePasses(t *testing.T) {
+	registry := NewRegistry()
+
+	if err := registry.Validate("batch.created", []byte(`{}`)); err != nil {
+		t.Fatalf("expected no error for an unregistered event type, got %v", err)
+	}
+}
+
+func TestValidate_MissingRequiredField(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Schema{EventType: "batch.created", Required: []string{"batch_id", "product_id"}})
+
+	err := registry.Validate("batch.created", []byte(`{"batch_id": "BATCH-1"}`))
+	if err == nil {
+		t.Fatal("expected an error for a missing required field")
+	}
+}
+
+func TestValidate_EmptyRequiredField(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Schema{EventType: "batch.created", Required: []string{"batch_id"}})
+
+	err := registry.Validate("batch.created", []byte(`{"batch_id": ""}`))
+	if err == nil {
+		t.Fatal("expected an error for an empty required field")
+	}
+}
+
+func TestValidate_AllRequiredFieldsPresent(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Schema{EventType: "batch.created", Required: []string{"batch_id", "product_id"}})
+
+	err := registry.Validate("batch.created", []byte(`{"batch_id": "BATCH-1", "product_id": "PROD-1"}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_NonObjectPayload(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Schema{EventType: "batch.created", Required: []string{"batch_id"}})
+
+	err := registry.Validate("batch.created", []byte(`[1, 2, 3]`))
+	if err == nil {
+		t.Fatal("expected an error for a non-object payload")
+	}
+}
@@ -0,0 +1,155 @@
+// Package kafkaoptions builds the throughput/latency/reliability tuning for
+// a kafka.Writer - compression, batch sizing, and async mode - read from
+// environment variables. It mirrors the mqtt-order-event-client service's
+// own kafkaoptions package so both keep the same env var names and
+// defaults, even though the two are independent packages (this repo has no
+// shared Go module to hang a single implementation off of).
+package kafkaoptions
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// PublisherOptions tunes a kafka.Writer's throughput/latency/reliability
+// trade-offs:
+//   - Compression trades CPU for network bandwidth; worth it for
+//     high-volume streams like damage/batch events, at the cost of a little
+//     producer and consumer CPU per message.
+//   - BatchSize/BatchBytes/BatchTimeout trade latency for throughput: larger
+//     batches (or a longer timeout before a partial batch is flushed) mean
+//     fewer, bigger requests to the broker, at the cost of each message
+//     sitting longer before it's actually sent.
+//   - Async, when true, returns from WriteMessages without waiting for the
+//     broker acknowledgment, which hides broker/network latency from the
+//     caller but means a write that ultimately fails is only observed via
+//     Completion - callers that need to know a specific event was dropped
+//     must supply one. Async is NOT appropriate for a publisher a caller
+//     depends on for at-least-once delivery (e.g. behind a transactional
+//     outbox relay) unless Completion feeds failures back into a retry path.
+type PublisherOptions struct {
+	Compression  kafka.Compression
+	BatchSize    int
+	BatchBytes   int64
+	BatchTimeout time.Duration
+	Async        bool
+	// Completion is invoked after each batch write completes, successfully
+	// or not. It is only meaningful when Async is true: synchronous writes
+	// already surface their error to the WriteMessages caller. May be nil,
+	// in which case async write failures are only logged.
+	Completion func(messages []kafka.Message, err error)
+}
+
+// OptionsFromEnv reads PublisherOptions from environment variables:
+//   - KAFKA_COMPRESSION (none|gzip|snappy|lz4|zstd, default: none)
+//   - KAFKA_BATCH_SIZE (message count, default: kafka-go's own default)
+//   - KAFKA_BATCH_BYTES (default: kafka-go's own default)
+//   - KAFKA_BATCH_TIMEOUT (duration, default: defaultBatchTimeout)
+//   - KAFKA_ASYNC (true/false, default: false)
+//
+// A Completion logging the write error is attached whenever Async is true,
+// so async failures aren't silently dropped even if the caller doesn't
+// supply its own hook via WithCompletion.
+func OptionsFromEnv(defaultBatchTimeout time.Duration) PublisherOptions {
+	compression, err := ParseCompression(getEnv("KAFKA_COMPRESSION", "none"))
+	if err != nil {
+		log.Printf("Invalid KAFKA_COMPRESSION, defaulting to none: %v", err)
+		compression = 0
+	}
+
+	opts := PublisherOptions{
+		Compression:  compression,
+		BatchSize:    getEnvInt("KAFKA_BATCH_SIZE", 0),
+		BatchBytes:   getEnvInt64("KAFKA_BATCH_BYTES", 0),
+		BatchTimeout: getEnvDuration("KAFKA_BATCH_TIMEOUT", defaultBatchTimeout),
+		Async:        getEnvBool("KAFKA_ASYNC", false),
+	}
+
+	if opts.Async {
+		opts.Completion = logCompletion
+	}
+
+	return opts
+}
+
+// WithCompletion returns a copy of opts using completion instead of the
+// default logging hook, for callers (e.g. tests, or a publisher that wants
+// to feed async failures into its own retry/dead-letter path) that need to
+// observe async write outcomes themselves.
+func (opts PublisherOptions) WithCompletion(completion func(messages []kafka.Message, err error)) PublisherOptions {
+	opts.Completion = completion
+	return opts
+}
+
+func logCompletion(messages []kafka.Message, err error) {
+	if err != nil {
+		log.Printf("Async Kafka write failed for %d message(s): %v", len(messages), err)
+	}
+}
+
+// ParseCompression maps name to a kafka.Compression codec.
+func ParseCompression(name string) (kafka.Compression, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("kafkaoptions: unknown compression %q", name)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,54 @@
+package kafkaoptions
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestParseCompression(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected kafka.Compression
+		wantErr  bool
+	}{
+		{name: "", expected: 0},
+		{name: "none", expected: 0},
+		{name: "gzip", expected: kafka.Gzip},
+		{name: "snappy", expected: kafka.Snappy},
+		{name: "lz4", expected: kafka.Lz4},
+		{name: "zstd", expected: kafka.Zstd},
+		{name: "bogus", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			compression, err := ParseCompression(tc.name)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error for compression %q", tc.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if compression != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, compression)
+			}
+		})
+	}
+}
+
+func TestPublisherOptions_WithCompletion(t *testing.T) {
+	called := false
+	opts := PublisherOptions{Async: true}.WithCompletion(func(messages []kafka.Message, err error) {
+		called = true
+	})
+
+	opts.Completion(nil, nil)
+
+	if !called {
+		t.Error("expected the supplied completion hook to be used instead of the default")
+	}
+}
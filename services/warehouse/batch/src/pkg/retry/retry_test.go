@@ -0,0 +1,126 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDo_RetriesUntilSuccess(t *testing.T) {
+	attempts := 0
+	err := Do(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}, Policy{
+		MaxAttempts:  5,
+		InitialDelay: time.Millisecond,
+		Multiplier:   2,
+	})
+
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestDo_StopsOnTerminalError(t *testing.T) {
+	attempts := 0
+	terminalErr := errors.New("bad message")
+	err := Do(context.Background(), func() error {
+		attempts++
+		return terminalErr
+	}, Policy{
+		MaxAttempts:  5,
+		InitialDelay: time.Millisecond,
+		Multiplier:   2,
+		Classify: func(err error) Classification {
+			return Terminal
+		},
+	})
+
+	if !errors.Is(err, terminalErr) {
+		t.Fatalf("expected terminal error to be returned, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected exactly 1 attempt for a terminal error, got %d", attempts)
+	}
+}
+
+func TestDo_GivesUpAfterMaxAttempts(t *testing.T) {
+	attempts := 0
+	err := Do(context.Background(), func() error {
+		attempts++
+		return errors.New("still failing")
+	}, Policy{
+		MaxAttempts:  3,
+		InitialDelay: time.Millisecond,
+		Multiplier:   2,
+	})
+
+	if err == nil {
+		t.Fatal("expected an error after exhausting attempts")
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestDo_ContextCancellationShortCircuits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+
+	err := Do(ctx, func() error {
+		attempts++
+		if attempts == 1 {
+			cancel()
+		}
+		return errors.New("still failing")
+	}, Policy{
+		MaxAttempts:  10,
+		InitialDelay: 50 * time.Millisecond,
+		Multiplier:   2,
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected retry loop to stop after cancellation, got %d attempts", attempts)
+	}
+}
+
+func TestDo_BackoffSchedule(t *testing.T) {
+	var gaps []time.Duration
+	var last time.Time
+
+	attempts := 0
+	Do(context.Background(), func() error {
+		now := time.Now()
+		if attempts > 0 {
+			gaps = append(gaps, now.Sub(last))
+		}
+		last = now
+		attempts++
+		return errors.New("retry me")
+	}, Policy{
+		MaxAttempts:  3,
+		InitialDelay: 10 * time.Millisecond,
+		Multiplier:   2,
+	})
+
+	if len(gaps) != 2 {
+		t.Fatalf("expected 2 gaps between 3 attempts, got %d", len(gaps))
+	}
+	if gaps[0] < 10*time.Millisecond {
+		t.Errorf("expected first gap >= initial delay (10ms), got %s", gaps[0])
+	}
+	if gaps[1] < gaps[0] {
+		t.Errorf("expected second gap (%s) >= first gap (%s) after doubling", gaps[1], gaps[0])
+	}
+}
@@ -0,0 +1,78 @@
+// Package tracing carries a W3C traceparent through a request's
+// context.Context so domain event constructors and publishers can attach it
+// without threading a separate string parameter everywhere. There is no
+// OpenTelemetry SDK (or any tracing library) vendored in this repo, so
+// StartSpan is a logging-based stand-in that records the same span
+// lifecycle (start, attributes, duration, error) a real OTel span would -
+// swapping in a real tracer later only means rewriting this one file.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+)
+
+// traceParentKey is the context.Context key under which the inbound
+// traceparent is stored.
+type traceParentKey struct{}
+
+// ContextWithTraceParent returns a copy of ctx carrying traceParent, so
+// everything downstream of the call can pick it up via
+// TraceParentFromContext without it being passed explicitly. Storing "" is a
+// no-op that still returns a usable context.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, traceParentKey{}, traceParent)
+}
+
+// TraceParentFromContext returns the traceparent carried by ctx, or "" if
+// none was attached.
+func TraceParentFromContext(ctx context.Context) string {
+	traceParent, _ := ctx.Value(traceParentKey{}).(string)
+	return traceParent
+}
+
+// NewTraceParent generates a fresh W3C Trace Context traceparent header
+// value (https://www.w3.org/TR/trace-context/#traceparent-header), for an
+// inbound HTTP request that arrived with no "traceparent" or
+// "X-Request-ID" header of its own to propagate.
+func NewTraceParent() string {
+	return fmt.Sprintf("00-%s-%s-01", randomHex(16), randomHex(8))
+}
+
+// randomHex returns n random bytes rendered as a hex string of length 2*n.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	// crypto/rand.Read only returns an error if the system CSPRNG is
+	// unavailable, in which case there's nothing sensible to do but fall
+	// back to an all-zero id rather than fail the request over a trace id.
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// StartSpan logs the start of an operation named name, carrying attrs (e.g.
+// broker address, topic) and the traceparent ctx carries, if any. It returns
+// a function the caller must invoke with the operation's outcome (nil on
+// success) when it completes; that call logs the duration and status,
+// standing in for a real OpenTelemetry span's attributes and end time.
+func StartSpan(ctx context.Context, name string, attrs map[string]string) func(err *error) {
+	start := time.Now()
+	traceParent := TraceParentFromContext(ctx)
+
+	return func(err *error) {
+		duration := time.Since(start)
+		status := "ok"
+		var errMsg any = ""
+		if err != nil && *err != nil {
+			status = "error"
+			errMsg = *err
+		}
+		log.Printf("span: %s traceparent=%q attrs=%v duration=%s status=%s err=%v", name, traceParent, attrs, duration, status, errMsg)
+	}
+}
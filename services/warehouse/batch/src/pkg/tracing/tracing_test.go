@@ -0,0 +1,31 @@
+package tracing
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+var traceParentPattern = regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`)
+
+func TestNewTraceParentFormat(t *testing.T) {
+	traceParent := NewTraceParent()
+	if !traceParentPattern.MatchString(traceParent) {
+		t.Fatalf("NewTraceParent() = %q, want a valid W3C traceparent", traceParent)
+	}
+}
+
+func TestNewTraceParentUnique(t *testing.T) {
+	if NewTraceParent() == NewTraceParent() {
+		t.Fatal("NewTraceParent() returned the same value twice")
+	}
+}
+
+func TestContextWithTraceParentRoundTrip(t *testing.T) {
+	traceParent := NewTraceParent()
+	ctx := ContextWithTraceParent(context.Background(), traceParent)
+
+	if got := TraceParentFromContext(ctx); got != traceParent {
+		t.Fatalf("TraceParentFromContext() = %q, want %q", got, traceParent)
+	}
+}